@@ -0,0 +1,58 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// requiresAuth reports whether a request must carry the API key
+// Mutating routes and LLM/streaming routes are always protected; reads only when configured
+func (s *Server) requiresAuth(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return true
+	}
+	if isExpensiveEndpoint(r.URL.Path) {
+		return true
+	}
+	return s.apiCfg.AuthProtectReads
+}
+
+// extractAPIKey reads the key from X-API-Key, a Bearer token, or the api_key query param
+// The query param exists because browser EventSource cannot set headers
+func extractAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// authMiddleware enforces the configured API key; disabled when API_KEY is empty
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	if s.apiCfg.APIKey == "" {
+		return next
+	}
+	expected := []byte(s.apiCfg.APIKey)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.requiresAuth(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		provided := []byte(extractAPIKey(r))
+		if subtle.ConstantTimeCompare(provided, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
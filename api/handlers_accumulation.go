@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -54,8 +55,30 @@ func (s *Server) handleAccumulationSummary(w http.ResponseWriter, r *http.Reques
 		now.Format("2006-01-02 15:04:05"), startTime.Format("2006-01-02 15:04:05"),
 		hoursBack, timeframeDescription)
 
+	// Classification basis: combined (count dominance + net value sign), count or value
+	basis := query.Get("basis")
+	switch basis {
+	case "":
+		basis = "combined"
+	case "combined", "count", "value":
+	default:
+		http.Error(w, "basis must be combined, count or value", http.StatusBadRequest)
+		return
+	}
+
+	// Ranking: net value (default) or net impact relative to free float / market cap
+	rank := query.Get("rank")
+	switch rank {
+	case "":
+		rank = "value"
+	case "value", "impact":
+	default:
+		http.Error(w, "rank must be value or impact", http.StatusBadRequest)
+		return
+	}
+
 	// Get accumulation/distribution summary (now returns 2 separate lists)
-	accumulation, distribution, err := s.repo.GetAccumulationDistributionSummary(startTime)
+	accumulation, distribution, err := s.repo.GetAccumulationDistributionSummary(startTime, basis, rank)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -67,6 +90,8 @@ func (s *Server) handleAccumulationSummary(w http.ResponseWriter, r *http.Reques
 		"distribution":       distribution,
 		"accumulation_count": len(accumulation),
 		"distribution_count": len(distribution),
+		"basis":              basis,
+		"rank":               rank,
 		"hours_back":         hoursBack,
 		"timeframe":          timeframeDescription,
 		"current_time":       now.Format("2006-01-02 15:04:05"),
@@ -118,3 +143,52 @@ func getMarketStatus(now time.Time) string {
 		return "CLOSED"
 	}
 }
+
+// handleGetSectorSweeps returns clusters of correlated symbols with whale BUY alerts in the same window
+func (s *Server) handleGetSectorSweeps(w http.ResponseWriter, r *http.Request) {
+	minMinutes, maxMinutes := 1, 240
+	minutes := getIntParam(r, "minutes", 15, &minMinutes, &maxMinutes)
+	minCount, maxCount := 2, 20
+	minSymbols := getIntParam(r, "min_symbols", 3, &minCount, &maxCount)
+	minCorrelation := getFloatParam(r, "min_correlation", 0.6)
+
+	sweeps, err := s.repo.GetSectorSweeps(time.Now().Add(-time.Duration(minutes)*time.Minute), minSymbols, minCorrelation)
+	if err != nil {
+		log.Printf("❌ Failed to get sector sweeps: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sweeps":          sweeps,
+		"count":           len(sweeps),
+		"minutes":         minutes,
+		"min_symbols":     minSymbols,
+		"min_correlation": minCorrelation,
+	})
+}
+
+// handleGetPatterns returns detected patterns (e.g. GAP_UP/GAP_DOWN) with optional symbol and type filters
+func (s *Server) handleGetPatterns(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	patternType := strings.ToUpper(r.URL.Query().Get("type"))
+	minHours, maxHours := 1, 168
+	hours := getIntParam(r, "hours", 24, &minHours, &maxHours)
+	minLimit, maxLimit := 1, 500
+	limit := getIntParam(r, "limit", 100, &minLimit, &maxLimit)
+
+	patterns, err := s.repo.GetPatterns(symbol, patternType, time.Now().Add(-time.Duration(hours)*time.Hour), limit)
+	if err != nil {
+		log.Printf("❌ Failed to get patterns: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"patterns": patterns,
+		"count":    len(patterns),
+		"hours":    hours,
+	})
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"stockbit-haka-haki/database"
@@ -105,52 +108,143 @@ func (s *Server) handleSymbolAnalysisStream(w http.ResponseWriter, r *http.Reque
 	flusher.Flush()
 }
 
-// handleCustomPromptStream streams AI analysis based on custom user prompt with database context
-func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request) {
-	// Check if LLM is enabled
-	if !s.llmEnabled || s.llmClient == nil {
-		http.Error(w, "LLM is not enabled", http.StatusServiceUnavailable)
+// charsPerToken is a rough average used to turn a token budget into characters
+const charsPerToken = 4
+
+// contextBudget accumulates LLM prompt context up to a character budget
+// Callers add items in priority order; once one doesn't fit, it and everything after are dropped
+type contextBudget struct {
+	sb      strings.Builder
+	limit   int // Characters; 0 means unlimited
+	dropped int
+}
+
+func newContextBudget(maxTokens int) *contextBudget {
+	return &contextBudget{limit: maxTokens * charsPerToken}
+}
+
+// add appends text if it fits in the remaining budget
+func (b *contextBudget) add(text string) {
+	if b.dropped > 0 || (b.limit > 0 && b.sb.Len()+len(text) > b.limit) {
+		b.dropped++
 		return
 	}
+	b.sb.WriteString(text)
+}
+
+func (b *contextBudget) truncated() bool { return b.dropped > 0 }
+
+func (b *contextBudget) String() string { return b.sb.String() }
+
+// insightCacheMaxEntries bounds the LLM insight cache; expired entries are pruned first
+const insightCacheMaxEntries = 500
+
+// insightCache remembers LLM answers keyed by a hash of the full prompt, so dashboards
+// polling with unchanged data don't re-query the LLM. A nil cache never hits.
+type insightCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]insightCacheEntry
+}
 
-	// Parse JSON request body
-	var reqBody struct {
-		Prompt      string   `json:"prompt"`
-		Symbols     []string `json:"symbols"`      // optional: specific symbols to analyze
-		HoursBack   int      `json:"hours_back"`   // hours of data to include
-		IncludeData string   `json:"include_data"` // comma-separated: alerts,regimes,patterns,signals
+type insightCacheEntry struct {
+	insight   string
+	expiresAt time.Time
+}
+
+func newInsightCache(ttl time.Duration) *insightCache {
+	return &insightCache{ttl: ttl, entries: make(map[[sha256.Size]byte]insightCacheEntry)}
+}
+
+func (c *insightCache) get(prompt string) (string, bool) {
+	if c == nil {
+		return "", false
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+	key := sha256.Sum256([]byte(prompt))
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
 	}
+	return entry.insight, true
+}
 
-	if reqBody.Prompt == "" {
-		http.Error(w, "prompt is required", http.StatusBadRequest)
+func (c *insightCache) put(prompt, insight string) {
+	if c == nil {
 		return
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= insightCacheMaxEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		// Still full: drop an arbitrary entry rather than grow without bound
+		for key := range c.entries {
+			if len(c.entries) < insightCacheMaxEntries {
+				break
+			}
+			delete(c.entries, key)
+		}
+	}
+	c.entries[sha256.Sum256([]byte(prompt))] = insightCacheEntry{insight: insight, expiresAt: now.Add(c.ttl)}
+}
 
-	// Default values
-	if reqBody.HoursBack <= 0 {
-		reqBody.HoursBack = 24
+// customPromptRequest is the body of the custom prompt endpoints
+type customPromptRequest struct {
+	Prompt      string   `json:"prompt"`
+	Symbols     []string `json:"symbols"`      // optional: specific symbols to analyze
+	HoursBack   int      `json:"hours_back"`   // hours of data to include
+	IncludeData string   `json:"include_data"` // comma-separated: alerts,regimes,patterns,signals,orderflow
+}
+
+// decodeCustomPromptRequest parses and defaults a custom prompt body, writing a 400 on failure
+func decodeCustomPromptRequest(w http.ResponseWriter, r *http.Request) (*customPromptRequest, bool) {
+	var req customPromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
 	}
-	if reqBody.IncludeData == "" {
-		reqBody.IncludeData = "alerts,regimes"
+
+	if req.Prompt == "" {
+		http.Error(w, "prompt is required", http.StatusBadRequest)
+		return nil, false
 	}
 
-	// Set SSE headers
-	flusher, ok := setupSSE(w)
-	if !ok {
-		respondWithError(w, http.StatusInternalServerError, "Streaming not supported", nil)
-		return
+	// Default values
+	if req.HoursBack <= 0 {
+		req.HoursBack = 24
 	}
+	if req.IncludeData == "" {
+		req.IncludeData = "alerts,regimes"
+	}
+	return &req, true
+}
 
+// buildCustomPrompt assembles the database context and user question sent to the LLM
+// Shared by the streaming and non-streaming endpoints; warning is set when context was truncated
+func (s *Server) buildCustomPrompt(req *customPromptRequest) (prompt, warning string) {
 	// Build context data based on user selection
 	var contextBuilder strings.Builder
 	contextBuilder.WriteString("KONTEKS DATA DARI DATABASE:\n\n")
 
-	includeTypes := strings.Split(reqBody.IncludeData, ",")
+	// Sections are written through a budget so large symbol lists can't blow the token limit
+	budget := newContextBudget(s.llmMaxContextTokens)
+
+	// Times are absolute (not "N minutes ago") so the prompt, and its insight cache key,
+	// only changes when the underlying data does
+	loc, err := time.LoadLocation(marketTimeZone)
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+
+	includeTypes := strings.Split(req.IncludeData, ",")
 
 	for _, dataType := range includeTypes {
 		dataType = strings.TrimSpace(dataType)
@@ -160,9 +254,9 @@ func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request
 			// Get whale alerts
 			var alerts []database.WhaleAlert
 
-			if len(reqBody.Symbols) > 0 {
+			if len(req.Symbols) > 0 {
 				// Get alerts for specific symbols
-				for _, symbol := range reqBody.Symbols {
+				for _, symbol := range req.Symbols {
 					symbolAlerts, e := s.repo.GetRecentAlertsBySymbol(symbol, 50)
 					if e == nil {
 						alerts = append(alerts, symbolAlerts...)
@@ -170,7 +264,7 @@ func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request
 				}
 			} else {
 				// Get recent alerts from accumulation patterns (top active stocks)
-				patterns, e := s.repo.GetAccumulationPattern(reqBody.HoursBack, 2)
+				patterns, e := s.repo.GetAccumulationPattern(req.HoursBack, 2)
 				if e == nil && len(patterns) > 0 {
 					// Get alerts for top 10 most active symbols
 					limit := 10
@@ -187,26 +281,29 @@ func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request
 			}
 
 			if len(alerts) > 0 {
-				contextBuilder.WriteString("=== WHALE ALERTS (Transaksi Besar) ===\n")
+				// Newest first so truncation drops the oldest alerts
+				sort.Slice(alerts, func(i, j int) bool {
+					return alerts[i].DetectedAt.After(alerts[j].DetectedAt)
+				})
+				budget.add("=== WHALE ALERTS (Transaksi Besar) ===\n")
 				for i, a := range alerts {
 					if i >= 20 { // Limit to 20 alerts
 						break
 					}
 					zScore := safeFloat64(a.ZScore, 0.0)
-					timeSince := time.Since(a.DetectedAt).Minutes()
-					contextBuilder.WriteString(fmt.Sprintf(
-						"- %s (%s): Rp %.1fM, Z-Score: %.2f, %.0f menit lalu\n",
-						a.StockSymbol, a.Action, a.TriggerValue/1000000.0, zScore, timeSince,
+					budget.add(fmt.Sprintf(
+						"- %s (%s): Rp %.1fM, Z-Score: %.2f, pukul %s WIB\n",
+						a.StockSymbol, a.Action, a.TriggerValue/1000000.0, zScore, a.DetectedAt.In(loc).Format("02/01 15:04"),
 					))
 				}
-				contextBuilder.WriteString("\n")
+				budget.add("\n")
 			}
 
 		case "patterns":
 			// Get accumulation patterns
-			patterns, err := s.repo.GetAccumulationPattern(reqBody.HoursBack, 3)
+			patterns, err := s.repo.GetAccumulationPattern(req.HoursBack, 3)
 			if err == nil && len(patterns) > 0 {
-				contextBuilder.WriteString("=== POLA AKUMULASI/DISTRIBUSI ===\n")
+				budget.add("=== POLA AKUMULASI/DISTRIBUSI ===\n")
 				for i, p := range patterns {
 					if i >= 10 {
 						break
@@ -215,20 +312,50 @@ func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request
 					if p.TotalVolumeLots > 0 {
 						avgPrice = p.TotalValue / (p.TotalVolumeLots * 100)
 					}
-					contextBuilder.WriteString(fmt.Sprintf(
+					budget.add(fmt.Sprintf(
 						"- %s (%s): %d alerts, Total: Rp %.2fM, Avg Price: %.0f, Z-Score: %.2f\n",
 						p.StockSymbol, p.Action, p.AlertCount,
 						p.TotalValue/1000000.0, avgPrice, p.AvgZScore,
 					))
 				}
-				contextBuilder.WriteString("\n")
+				budget.add("\n")
+			}
+
+		case "orderflow":
+			// Latest buy/sell pressure for the requested symbols (or the most active ones)
+			symbols := req.Symbols
+			if len(symbols) == 0 {
+				patterns, e := s.repo.GetAccumulationPattern(req.HoursBack, 2)
+				if e == nil {
+					for i := 0; i < len(patterns) && i < 10; i++ {
+						symbols = append(symbols, patterns[i].StockSymbol)
+					}
+				}
+			}
+
+			// OPTIMIZATION: One query for all symbols instead of one per symbol
+			flows, err := s.repo.GetLatestOrderFlowForSymbols(symbols)
+			if err == nil && len(flows) > 0 {
+				budget.add("=== ORDER FLOW TERAKHIR ===\n")
+				for _, symbol := range symbols {
+					flow, ok := flows[symbol]
+					if !ok {
+						continue
+					}
+					budget.add(fmt.Sprintf(
+						"- %s: Buy %.0f lot, Sell %.0f lot, Delta %.0f lot, Imbalance %.1f%%, pukul %s WIB\n",
+						symbol, flow.BuyVolumeLots, flow.SellVolumeLots, flow.DeltaVolume,
+						flow.VolumeImbalanceRatio*100, flow.Bucket.In(loc).Format("02/01 15:04"),
+					))
+				}
+				budget.add("\n")
 			}
 
 		case "signals":
 			// Get recent signals (lookback 24 hours * 60 minutes)
-			signals, err := s.repo.GetRecentSignalsWithOutcomes(reqBody.HoursBack*60, 0.0, "")
+			signals, err := s.repo.GetRecentSignalsWithOutcomes(req.HoursBack*60, 0.0, "")
 			if err == nil && len(signals) > 0 {
-				contextBuilder.WriteString("=== TRADING SIGNALS (AI) ===\n")
+				budget.add("=== TRADING SIGNALS (AI) ===\n")
 				for i, sig := range signals {
 					if i >= 15 {
 						break
@@ -237,22 +364,55 @@ func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request
 					if sig.Outcome != "" {
 						result = sig.Outcome
 					}
-					contextBuilder.WriteString(fmt.Sprintf(
+					budget.add(fmt.Sprintf(
 						"- %s (%s): %s, Price: %.0f, Confidence: %.0f%%, Result: %s\n",
 						sig.StockSymbol, sig.Strategy, sig.Decision,
 						sig.Price, sig.Confidence*100, result,
 					))
 				}
-				contextBuilder.WriteString("\n")
+				budget.add("\n")
 			}
 		}
 	}
 
+	contextBuilder.WriteString(budget.String())
+	if budget.truncated() {
+		warning = fmt.Sprintf("Context truncated to ~%d tokens (%d lines omitted)", s.llmMaxContextTokens, budget.dropped)
+		log.Printf("⚠️ Custom prompt: %s", warning)
+	}
+
 	contextBuilder.WriteString("=== PERTANYAAN USER ===\n")
-	contextBuilder.WriteString(reqBody.Prompt)
+	contextBuilder.WriteString(req.Prompt)
 	contextBuilder.WriteString("\n\nJawab berdasarkan DATA di atas. Jangan membuat asumsi atau data yang tidak ada. Fokus pada insight yang actionable.")
 
-	fullPrompt := contextBuilder.String()
+	return contextBuilder.String(), warning
+}
+
+// handleCustomPromptStream streams AI analysis based on custom user prompt with database context
+func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request) {
+	// Check if LLM is enabled
+	if !s.llmEnabled || s.llmClient == nil {
+		http.Error(w, "LLM is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	req, ok := decodeCustomPromptRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Set SSE headers
+	flusher, ok := setupSSE(w)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming not supported", nil)
+		return
+	}
+
+	fullPrompt, warning := s.buildCustomPrompt(req)
+	if warning != "" {
+		fmt.Fprintf(w, "event: warning\ndata: %s\n\n", warning)
+		flusher.Flush()
+	}
 
 	// Stream LLM response
 	err := s.llmClient.AnalyzeStream(r.Context(), fullPrompt, func(chunk string) error {
@@ -280,3 +440,96 @@ func (s *Server) handleCustomPromptStream(w http.ResponseWriter, r *http.Request
 	fmt.Fprintf(w, "event: done\ndata: Stream completed\n\n")
 	flusher.Flush()
 }
+
+// handleCustomPrompt returns the complete AI analysis of a custom prompt as JSON
+// Same context as handleCustomPromptStream, for scripts that don't want to parse SSE
+func (s *Server) handleCustomPrompt(w http.ResponseWriter, r *http.Request) {
+	if !s.llmEnabled || s.llmClient == nil {
+		http.Error(w, "LLM is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	req, ok := decodeCustomPromptRequest(w, r)
+	if !ok {
+		return
+	}
+
+	fullPrompt, warning := s.buildCustomPrompt(req)
+
+	analysis, cacheHit := s.llmCache.get(fullPrompt)
+	if !cacheHit {
+		var err error
+		analysis, err = s.llmClient.Analyze(r.Context(), fullPrompt)
+		if err != nil {
+			respondWithError(w, http.StatusBadGateway, "LLM analysis failed", err)
+			return
+		}
+		s.llmCache.put(fullPrompt, analysis)
+	}
+
+	response := map[string]interface{}{
+		"analysis":  analysis,
+		"cache_hit": cacheHit,
+	}
+	if warning != "" {
+		response["warning"] = warning
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleGetRegimeHistory returns the regime timeline of a symbol, oldest first, for plotting regime bands
+func (s *Server) handleGetRegimeHistory(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	minDays, maxDays := 1, 90
+	days := getIntParam(r, "days", 7, &minDays, &maxDays)
+
+	regimes, err := s.repo.GetRegimeHistory(symbol, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("❌ Failed to get regime history for %s: %v", symbol, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"symbol":  symbol,
+		"days":    days,
+		"regimes": regimes,
+		"count":   len(regimes),
+	})
+}
+
+// handleRecomputeBaseline recalculates a symbol's statistical baseline immediately instead of waiting
+// for the hourly run, e.g. for a newly active symbol or after a data gap
+func (s *Server) handleRecomputeBaseline(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	if s.baselineCalc == nil {
+		http.Error(w, "Baseline calculator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	baseline, err := s.baselineCalc.RecomputeSymbol(symbol)
+	if err != nil {
+		log.Printf("❌ Failed to recompute baseline for %s: %v", symbol, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if baseline == nil {
+		http.Error(w, fmt.Sprintf("Not enough recent candles to compute a baseline for %s", symbol), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(baseline)
+}
@@ -1,20 +1,240 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"stockbit-haka-haki/database"
 )
 
-// handleHealth returns the health status of the API
+// healthProbeTimeout bounds each dependency probe so /health answers quickly when one hangs
+const healthProbeTimeout = 2 * time.Second
+
+// handleHealth probes the database, Redis and candle_1min freshness for readiness/liveness checks
+// Responds 503 when any probed dependency is down
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	checks := map[string]map[string]string{
+		"database": s.probe(r.Context(), s.repo.Ping),
+		"candles":  s.candleFreshness(r.Context()),
+	}
+	if s.redis != nil {
+		checks["redis"] = s.probe(r.Context(), s.redis.Ping)
+	} else {
+		checks["redis"] = map[string]string{"status": "disabled"}
+	}
+
+	status, code := "ok", http.StatusOK
+	for _, check := range checks {
+		switch check["status"] {
+		case "ok", "disabled", "skipped":
+		default:
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// handleGetConfig returns the configuration loaded at startup with credentials and API keys masked.
+// Per-symbol and per-strategy overrides stored in the database are listed by their own endpoints.
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	if s.cfg == nil {
+		http.Error(w, "Configuration not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cfg.Sanitized())
+}
+
+// handleGetIngestStats returns websocket message counts and trade validation failures since startup
+func (s *Server) handleGetIngestStats(w http.ResponseWriter, r *http.Request) {
+	if s.ingest == nil {
+		http.Error(w, "Ingestion stats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.ingest.IngestStats())
+}
+
+// probe runs a dependency check with a timeout and reports its status and latency
+func (s *Server) probe(ctx context.Context, check func(context.Context) error) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := check(ctx); err != nil {
+		return map[string]string{"status": "down", "error": err.Error()}
+	}
+	return map[string]string{"status": "ok", "latency": time.Since(start).String()}
+}
+
+// candleFreshness checks that the candle_1min continuous aggregate keeps up with the tape.
+// Only evaluated while a trading session is running (and past the allowed lag after its open),
+// since no candles are produced outside market hours
+func (s *Server) candleFreshness(ctx context.Context) map[string]string {
+	maxLag := time.Duration(s.apiCfg.HealthMaxCandleLagMinutes) * time.Minute
+	if maxLag <= 0 {
+		return map[string]string{"status": "disabled"}
+	}
+
+	loc, err := time.LoadLocation(marketTimeZone)
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+	now := time.Now().In(loc)
+	session := s.sessions.SessionAt(now)
+	if weekday := now.Weekday(); weekday == time.Saturday || weekday == time.Sunday ||
+		(session != "SESSION_1" && session != "SESSION_2") {
+		return map[string]string{"status": "skipped", "reason": "market not in a trading session"}
+	}
+	if start, ok := s.sessions.SessionStart(now, session); ok && now.Sub(start) < maxLag {
+		return map[string]string{"status": "skipped", "reason": session + " just opened"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+	latest, err := s.repo.GetLatestCandleBucket(ctx)
+	if err != nil {
+		return map[string]string{"status": "down", "error": err.Error()}
+	}
+	if latest == nil {
+		return map[string]string{"status": "stale", "error": "no candle_1min buckets in the last day"}
+	}
+
+	lag := time.Since(*latest).Truncate(time.Second)
+	result := map[string]string{"latest_bucket": latest.UTC().Format(time.RFC3339), "lag": lag.String()}
+	if lag > maxLag {
+		result["status"] = "stale"
+		return result
+	}
+	result["status"] = "ok"
+	return result
 }
 
 // Configuration Handlers (Webhooks Only)
 
+// webhookAuthTypes are the supported WhaleWebhook.AuthType values ("" means none)
+// BEARER sends "Authorization: Bearer <auth_value>"; HEADER sends <auth_header>: <auth_value>
+var webhookAuthTypes = []string{"", "NONE", "BEARER", "HEADER"}
+
+// validateWebhook rejects configs that would misbehave at delivery time and normalizes the JSON fields
+// MinConfidence is 0-100 (like WhaleAlert.ConfidenceScore); a 0-1 value would let everything through
+func validateWebhook(webhook *database.WhaleWebhook) string {
+	if webhook.MinConfidence != nil {
+		c := *webhook.MinConfidence
+		if c < 0 || c > 100 {
+			return "min_confidence must be between 0 and 100"
+		}
+		if c > 0 && c < 1 {
+			return "min_confidence uses a 0-100 scale (e.g. 80 for 80%)"
+		}
+	}
+
+	webhook.MinSeverity = strings.ToUpper(strings.TrimSpace(webhook.MinSeverity))
+	if webhook.MinSeverity != "" && database.SeverityRank(webhook.MinSeverity) == 0 {
+		return "min_severity must be one of WARN, ALERT or CRITICAL"
+	}
+
+	webhook.AuthType = strings.ToUpper(strings.TrimSpace(webhook.AuthType))
+	if !slices.Contains(webhookAuthTypes, webhook.AuthType) {
+		return "auth_type must be one of NONE, BEARER or HEADER"
+	}
+	if webhook.AuthType == "HEADER" && strings.TrimSpace(webhook.AuthHeader) == "" {
+		return "auth_header is required when auth_type is HEADER"
+	}
+
+	var err error
+	if webhook.CustomHeaders, err = normalizeWebhookHeaders(webhook.CustomHeaders); err != nil {
+		return "custom_headers: " + err.Error()
+	}
+	if webhook.AlertTypes, err = normalizeWebhookList(webhook.AlertTypes); err != nil {
+		return "alert_types: " + err.Error()
+	}
+	if webhook.StockSymbols, err = normalizeWebhookList(webhook.StockSymbols); err != nil {
+		return "stock_symbols: " + err.Error()
+	}
+	return ""
+}
+
+// normalizeWebhookHeaders parses a JSON object of header name -> value and canonicalizes the names
+func normalizeWebhookHeaders(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return "", nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return "", errors.New(`must be a JSON object of string values, e.g. {"X-Source": "whale-alert"}`)
+	}
+
+	normalized := make(map[string]string, len(headers))
+	for name, value := range headers {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsFunc(name, func(r rune) bool { return r <= ' ' || r >= 0x7f || r == ':' }) {
+			return "", fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("header %q value must not contain line breaks", name)
+		}
+		normalized[http.CanonicalHeaderKey(name)] = value
+	}
+	if len(normalized) == 0 {
+		return "", nil
+	}
+
+	out, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// normalizeWebhookList parses a JSON array of strings (alert types or symbols), uppercased and deduplicated
+func normalizeWebhookList(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return "", nil
+	}
+
+	var items []string
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return "", errors.New(`must be a JSON array of strings, e.g. ["BBCA", "BBRI"]`)
+	}
+
+	normalized := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if item != "" && !slices.Contains(normalized, item) {
+			normalized = append(normalized, item)
+		}
+	}
+	if len(normalized) == 0 {
+		return "", nil
+	}
+
+	out, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
 func (s *Server) handleGetWebhooks(w http.ResponseWriter, r *http.Request) {
 	webhooks, err := s.repo.GetWebhooks()
 	if err != nil {
@@ -31,6 +251,10 @@ func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if msg := validateWebhook(&webhook); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
 
 	// Reset ID to let DB assign it
 	webhook.ID = 0
@@ -63,6 +287,10 @@ func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if msg := validateWebhook(&webhook); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
 
 	webhook.ID = id // Ensure ID matches path
 	if err := s.repo.SaveWebhook(&webhook); err != nil {
@@ -99,3 +327,520 @@ func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if s.webhookMq == nil {
+		http.Error(w, "Webhook manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	webhook, err := s.repo.GetWebhookByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if webhook == nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	result := s.webhookMq.TestWebhook(*webhook)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Corporate Action Handlers
+
+func (s *Server) handleGetCorporateActions(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	minLimit, maxLimit := 1, 500
+	limit := getIntParam(r, "limit", 100, &minLimit, &maxLimit)
+
+	actions, err := s.repo.GetCorporateActions(symbol, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(actions)
+}
+
+func (s *Server) handleCreateCorporateAction(w http.ResponseWriter, r *http.Request) {
+	var action database.CorporateAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	action.ID = 0
+	action.StockSymbol = strings.ToUpper(action.StockSymbol)
+	action.Source = "MANUAL"
+	if action.StockSymbol == "" || action.EffectiveDate.IsZero() || action.Ratio <= 0 {
+		http.Error(w, "stock_symbol, effective_date and a positive ratio are required", http.StatusBadRequest)
+		return
+	}
+	if action.ActionType == "" {
+		action.ActionType = "SPLIT"
+	}
+
+	if err := s.repo.SaveCorporateAction(&action); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Force baseline recompute from post-event data
+	if err := s.repo.InvalidateBaselines(action.StockSymbol); err != nil {
+		log.Printf("⚠️ Failed to invalidate baselines for %s: %v", action.StockSymbol, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(action)
+}
+
+// Symbol Meta Handlers
+
+func (s *Server) handleGetSymbolMeta(w http.ResponseWriter, r *http.Request) {
+	metas, err := s.repo.GetSymbolMetas()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metas)
+}
+
+func (s *Server) handleUpdateSymbolMeta(w http.ResponseWriter, r *http.Request) {
+	var meta database.SymbolMeta
+	if err := json.NewDecoder(r.Body).Decode(&meta); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	meta.StockSymbol = strings.ToUpper(strings.TrimSpace(meta.StockSymbol))
+	meta.Tier = strings.ToUpper(strings.TrimSpace(meta.Tier))
+	if meta.StockSymbol == "" || meta.FreeFloatShares < 0 || meta.MarketCap < 0 ||
+		(meta.FreeFloatShares == 0 && meta.MarketCap == 0 && meta.Tier == "") {
+		http.Error(w, "stock_symbol and a positive free_float_shares or market_cap (or a tier) are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.SaveSymbolMeta(&meta); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(meta)
+}
+
+// Symbol Filter Handlers
+
+func (s *Server) handleGetSymbolFilters(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.repo.GetSymbolFilters()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Effective lists include symbols configured via environment
+	var whitelist, blacklist []string
+	if s.symbolFilter != nil {
+		whitelist, blacklist = s.symbolFilter.Lists()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries":   entries,
+		"whitelist": whitelist,
+		"blacklist": blacklist,
+	})
+}
+
+func (s *Server) handleCreateSymbolFilter(w http.ResponseWriter, r *http.Request) {
+	var filter database.SymbolFilter
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	filter.ID = 0
+	filter.StockSymbol = strings.ToUpper(strings.TrimSpace(filter.StockSymbol))
+	filter.ListType = strings.ToUpper(filter.ListType)
+	if filter.StockSymbol == "" || (filter.ListType != "WHITELIST" && filter.ListType != "BLACKLIST") {
+		http.Error(w, "stock_symbol and list_type (WHITELIST or BLACKLIST) are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.SaveSymbolFilter(&filter); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.refreshSymbolFilter()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(filter)
+}
+
+func (s *Server) handleDeleteSymbolFilter(w http.ResponseWriter, r *http.Request) {
+	listType := strings.ToUpper(r.PathValue("list"))
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	if listType != "WHITELIST" && listType != "BLACKLIST" {
+		http.Error(w, "list must be WHITELIST or BLACKLIST", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.DeleteSymbolFilter(symbol, listType); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.refreshSymbolFilter()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refreshSymbolFilter applies list changes immediately instead of waiting for the periodic reload
+func (s *Server) refreshSymbolFilter() {
+	if s.symbolFilter == nil {
+		return
+	}
+	if err := s.symbolFilter.Refresh(); err != nil {
+		log.Printf("⚠️ Failed to refresh symbol filters: %v", err)
+	}
+}
+
+// Scheduled Event Handlers
+
+// validateScheduledEvent normalizes an event and returns an error message if invalid
+func validateScheduledEvent(event *database.ScheduledEvent) string {
+	event.Severity = strings.ToUpper(event.Severity)
+	event.StockSymbol = strings.ToUpper(strings.TrimSpace(event.StockSymbol))
+	if event.Severity == "" {
+		event.Severity = "HIGH"
+	}
+	if event.Description == "" || event.StartTime.IsZero() || event.EndTime.IsZero() {
+		return "description, start_time and end_time are required"
+	}
+	if !event.EndTime.After(event.StartTime) {
+		return "end_time must be after start_time"
+	}
+	if event.Severity != "HIGH" && event.Severity != "MEDIUM" && event.Severity != "LOW" {
+		return "severity must be HIGH, MEDIUM or LOW"
+	}
+	return ""
+}
+
+func (s *Server) handleGetScheduledEvents(w http.ResponseWriter, r *http.Request) {
+	// Upcoming and active events by default; ?include_past=true lists everything
+	since := time.Now()
+	if r.URL.Query().Get("include_past") == "true" {
+		since = time.Time{}
+	}
+
+	events, err := s.repo.GetScheduledEvents(since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func (s *Server) handleCreateScheduledEvent(w http.ResponseWriter, r *http.Request) {
+	var event database.ScheduledEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event.ID = 0
+	if msg := validateScheduledEvent(&event); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.SaveScheduledEvent(&event); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(event)
+}
+
+func (s *Server) handleUpdateScheduledEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var event database.ScheduledEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	event.ID = id // Ensure ID matches path
+	if msg := validateScheduledEvent(&event); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.SaveScheduledEvent(&event); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+}
+
+func (s *Server) handleDeleteScheduledEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.DeleteScheduledEvent(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Suppression Rule Handlers
+
+// validateSuppressionRule normalizes a rule and returns an error message if invalid
+func validateSuppressionRule(rule *database.SuppressionRule) string {
+	rule.StockSymbol = strings.ToUpper(strings.TrimSpace(rule.StockSymbol))
+	rule.AlertType = strings.ToUpper(strings.TrimSpace(rule.AlertType))
+	rule.Reason = strings.TrimSpace(rule.Reason)
+	if rule.Reason == "" || rule.Until.IsZero() {
+		return "reason and until are required"
+	}
+	if !rule.Until.After(time.Now()) {
+		return "until must be in the future"
+	}
+	if rule.StockSymbol == "" && rule.AlertType == "" {
+		return "stock_symbol or alert_type is required (muting every alert is not allowed)"
+	}
+	return ""
+}
+
+func (s *Server) handleGetSuppressionRules(w http.ResponseWriter, r *http.Request) {
+	// Active rules by default; ?include_expired=true lists everything
+	since := time.Now()
+	if r.URL.Query().Get("include_expired") == "true" {
+		since = time.Time{}
+	}
+
+	rules, err := s.repo.GetSuppressionRules(since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+func (s *Server) handleCreateSuppressionRule(w http.ResponseWriter, r *http.Request) {
+	var rule database.SuppressionRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule.ID = 0
+	if msg := validateSuppressionRule(&rule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.SaveSuppressionRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (s *Server) handleUpdateSuppressionRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var rule database.SuppressionRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule.ID = id // Ensure ID matches path
+	if msg := validateSuppressionRule(&rule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now() // Save would otherwise write a zero created_at and match old alerts
+	}
+
+	if err := s.repo.SaveSuppressionRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (s *Server) handleDeleteSuppressionRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.DeleteSuppressionRule(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Strategy Config Handlers
+
+// handleGetStrategyConfigs lists the enable/disable switch for every known strategy
+// Strategies without a stored row are reported with their defaults (enabled, no override)
+func (s *Server) handleGetStrategyConfigs(w http.ResponseWriter, r *http.Request) {
+	configs, err := s.repo.GetStrategyConfigs()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	seen := make(map[string]bool, len(configs))
+	for _, c := range configs {
+		seen[c.Strategy] = true
+	}
+	for _, strategy := range knownStrategies {
+		if !seen[strategy] {
+			configs = append(configs, database.StrategyConfig{Strategy: strategy, Enabled: true})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(configs)
+}
+
+// handleUpdateStrategyConfig enables/disables a strategy or sets its confidence override
+func (s *Server) handleUpdateStrategyConfig(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Strategy              string   `json:"strategy"`
+		Enabled               *bool    `json:"enabled"`
+		MinConfidenceOverride *float64 `json:"min_confidence_override"`
+		Notes                 string   `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cfg := database.StrategyConfig{
+		Strategy:              strings.ToUpper(strings.TrimSpace(req.Strategy)),
+		Enabled:               req.Enabled == nil || *req.Enabled,
+		MinConfidenceOverride: req.MinConfidenceOverride,
+		Notes:                 req.Notes,
+	}
+	if !slices.Contains(knownStrategies, cfg.Strategy) {
+		http.Error(w, "strategy must be one of "+strings.Join(knownStrategies, ", "), http.StatusBadRequest)
+		return
+	}
+	if o := cfg.MinConfidenceOverride; o != nil && (*o < 0 || *o > 1) {
+		http.Error(w, "min_confidence_override must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.SaveStrategyConfig(&cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// Symbol Strategy Preference Handlers
+
+func (s *Server) handleGetSymbolStrategyPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs, err := s.repo.GetSymbolStrategyPreferences()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// handleUpdateSymbolStrategyPreference creates or replaces the preference for a symbol/strategy pair
+func (s *Server) handleUpdateSymbolStrategyPreference(w http.ResponseWriter, r *http.Request) {
+	var pref database.SymbolStrategyPreference
+	if err := json.NewDecoder(r.Body).Decode(&pref); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pref.StockSymbol = strings.ToUpper(strings.TrimSpace(pref.StockSymbol))
+	pref.Strategy = strings.ToUpper(strings.TrimSpace(pref.Strategy))
+	pref.Preference = strings.ToUpper(strings.TrimSpace(pref.Preference))
+	if pref.StockSymbol == "" {
+		http.Error(w, "stock_symbol is required", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(knownStrategies, pref.Strategy) {
+		http.Error(w, "strategy must be one of "+strings.Join(knownStrategies, ", "), http.StatusBadRequest)
+		return
+	}
+	if pref.Preference != "PREFER" && pref.Preference != "AVOID" {
+		http.Error(w, "preference must be PREFER or AVOID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.SaveSymbolStrategyPreference(&pref); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pref)
+}
+
+func (s *Server) handleDeleteSymbolStrategyPreference(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	strategy := strings.ToUpper(r.PathValue("strategy"))
+
+	if err := s.repo.DeleteSymbolStrategyPreference(symbol, strategy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
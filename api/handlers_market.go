@@ -2,9 +2,16 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+
+	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/indicators"
 )
 
 func (s *Server) handleGetWhales(w http.ResponseWriter, r *http.Request) {
@@ -77,6 +84,163 @@ func (s *Server) handleGetWhales(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleGetTrades returns the time-and-sales tape for a symbol, newest first
+func (s *Server) handleGetTrades(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	symbol := strings.ToUpper(query.Get("symbol"))
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	minLimit, maxLimit := 1, 1000
+	limit := getIntParam(r, "limit", 100, &minLimit, &maxLimit)
+	action := strings.ToUpper(query.Get("action"))
+	board := strings.ToUpper(query.Get("board"))
+
+	// Time range parsing (RFC3339)
+	var startTime, endTime time.Time
+	if startStr := query.Get("start"); startStr != "" {
+		parsed, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			http.Error(w, "start must be RFC3339", http.StatusBadRequest)
+			return
+		}
+		startTime = parsed
+	}
+	if endStr := query.Get("end"); endStr != "" {
+		parsed, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			http.Error(w, "end must be RFC3339", http.StatusBadRequest)
+			return
+		}
+		endTime = parsed
+	}
+
+	trades, err := s.repo.GetTrades(symbol, startTime, endTime, action, board, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"symbol": symbol,
+		"trades": trades,
+		"count":  len(trades),
+		"limit":  limit,
+	})
+}
+
+// topWhaleOrders maps the by= parameter to a fixed ORDER BY clause
+var topWhaleOrders = map[string]string{
+	"value":  "trigger_value DESC",
+	"zscore": "z_score DESC NULLS LAST, trigger_value DESC",
+}
+
+// parseWindow parses a lookback like "30m", "1h" or "2d" (days are not supported by time.ParseDuration)
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// handleGetTopWhales ranks the biggest whale alerts in a window by Rupiah value or z-score
+func (s *Server) handleGetTopWhales(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	windowStr := query.Get("window")
+	if windowStr == "" {
+		windowStr = "1h"
+	}
+	window, err := parseWindow(windowStr)
+	if err != nil || window < time.Minute || window > 30*24*time.Hour {
+		http.Error(w, "window must be a duration between 1m and 30d (e.g. 30m, 1h, 2d)", http.StatusBadRequest)
+		return
+	}
+
+	by := query.Get("by")
+	if by == "" {
+		by = "value"
+	}
+	orderBy, ok := topWhaleOrders[by]
+	if !ok {
+		http.Error(w, "by must be value or zscore", http.StatusBadRequest)
+		return
+	}
+
+	minLimit, maxLimit := 1, 100
+	limit := getIntParam(r, "limit", 20, &minLimit, &maxLimit)
+	action := strings.ToUpper(query.Get("action"))
+
+	whales, err := s.repo.GetHistoricalWhalesOrdered("", time.Now().Add(-window), time.Time{}, "", action, "", 0, limit, 0, orderBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"whales": whales,
+		"count":  len(whales),
+		"window": windowStr,
+		"by":     by,
+	})
+}
+
+// handleGetWhaleFlow returns time-bucketed net whale value for a symbol, for a cumulative flow line alongside price
+func (s *Server) handleGetWhaleFlow(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	symbol := strings.ToUpper(query.Get("symbol"))
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	bucketStr := query.Get("bucket")
+	if bucketStr == "" {
+		bucketStr = "15m"
+	}
+	bucket, err := time.ParseDuration(bucketStr)
+	if err != nil || bucket < time.Minute || bucket > 24*time.Hour || bucket%time.Minute != 0 {
+		http.Error(w, "bucket must be whole minutes between 1m and 24h (e.g. 5m, 15m, 1h)", http.StatusBadRequest)
+		return
+	}
+
+	minHours, maxHours := 1, 168
+	hours := getIntParam(r, "hours", 8, &minHours, &maxHours)
+
+	endTime := time.Now()
+	startTime := endTime.Add(-time.Duration(hours) * time.Hour)
+	interval := fmt.Sprintf("%d minutes", int(bucket.Minutes()))
+
+	flow, err := s.repo.GetWhaleFlowBuckets(symbol, interval, startTime, endTime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	netValue := 0.0
+	if len(flow) > 0 {
+		netValue = flow[len(flow)-1].CumulativeNet
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"symbol":    symbol,
+		"bucket":    bucketStr,
+		"hours":     hours,
+		"flow":      flow,
+		"count":     len(flow),
+		"net_value": netValue,
+	})
+}
+
 func (s *Server) handleGetWhaleStats(w http.ResponseWriter, r *http.Request) {
 	// Parse query params
 	query := r.URL.Query()
@@ -160,22 +324,219 @@ func (s *Server) handleGetCandles(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	candles, err := s.repo.GetCandlesByTimeframe(timeframe, symbol, limit)
+	// Optional indicator overlays, e.g. indicators=sma20,ema50,rsi14
+	specs, err := indicators.ParseSpecs(query.Get("indicators"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch extra history so the overlays are warmed up at the oldest returned candle
+	warmup := 0
+	for _, spec := range specs {
+		warmup = max(warmup, spec.Warmup())
+	}
+
+	candles, err := s.repo.GetCandlesByTimeframe(timeframe, symbol, limit+warmup)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	var overlays map[string][]*float64
+	if len(specs) > 0 {
+		overlays = candleOverlays(candles, specs, limit)
+	}
+	if len(candles) > limit {
+		candles = candles[:limit]
+	}
+
+	// Optional buy/sell split per candle for volume-by-direction charts
+	if query.Get("include_flow") == "true" && len(candles) > 0 {
+		if err := s.attachCandleFlow(candles, timeframe, symbol); err != nil {
+			log.Printf("⚠️ Failed to attach order flow to %s candles for %s: %v", timeframe, symbol, err)
+		}
+	}
+
 	// Calculate technical indicators
 	analysis := calculateTechnicalAnalysis(candles)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	response := map[string]interface{}{
 		"candles":    candles,
 		"symbol":     symbol,
 		"timeframe":  timeframe,
 		"count":      len(candles),
 		"indicators": analysis,
+	}
+	if overlays != nil {
+		response["overlays"] = overlays
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// candleOverlays computes each indicator over the candle closes (candles are newest first)
+// and returns series aligned with the first limit candles; null where not yet defined
+func candleOverlays(candles []map[string]interface{}, specs []indicators.Spec, limit int) map[string][]*float64 {
+	n := len(candles)
+	closes := make([]float64, n)
+	for i, c := range candles {
+		if close, ok := c["close"].(float64); ok {
+			closes[n-1-i] = close // Oldest first
+		}
+	}
+
+	overlays := make(map[string][]*float64, len(specs))
+	for _, spec := range specs {
+		series := spec.Compute(closes)
+		out := make([]*float64, min(n, limit))
+		for i := range out {
+			if v := series[n-1-i]; !math.IsNaN(v) {
+				out[i] = &v
+			}
+		}
+		overlays[spec.Name] = out
+	}
+	return overlays
+}
+
+// attachCandleFlow sets buy_volume, sell_volume and delta_volume on each candle (newest first),
+// leaving them null where no order flow was recorded for the bucket
+func (s *Server) attachCandleFlow(candles []map[string]interface{}, timeframe, symbol string) error {
+	oldest, ok := candles[len(candles)-1]["time"].(time.Time)
+	if !ok {
+		return fmt.Errorf("unexpected candle time type %T", candles[len(candles)-1]["time"])
+	}
+
+	flows, err := s.repo.GetCandleFlow(timeframe, symbol, oldest, time.Now())
+	if err != nil {
+		return err
+	}
+
+	byBucket := make(map[int64]types.CandleFlow, len(flows))
+	for _, f := range flows {
+		byBucket[f.Bucket.Unix()] = f
+	}
+
+	for _, c := range candles {
+		c["buy_volume"], c["sell_volume"], c["delta_volume"] = nil, nil, nil
+		t, ok := c["time"].(time.Time)
+		if !ok {
+			continue
+		}
+		if f, found := byBucket[t.Unix()]; found {
+			c["buy_volume"], c["sell_volume"], c["delta_volume"] = f.BuyVolumeLots, f.SellVolumeLots, f.DeltaVolume
+		}
+	}
+	return nil
+}
+
+// handleGetMarketBreadth returns advancers/decliners and whale BUY vs SELL value over a lookback
+func (s *Server) handleGetMarketBreadth(w http.ResponseWriter, r *http.Request) {
+	minLookback, maxLookback := 1, 1440
+	lookback := getIntParam(r, "lookback", 60, &minLookback, &maxLookback) // minutes
+
+	since := time.Now().Add(-time.Duration(lookback) * time.Minute)
+	breadth, err := s.repo.GetMarketBreadth(since)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to calculate market breadth", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"breadth":          breadth,
+		"lookback_minutes": lookback,
+		"since":            since,
+	})
+}
+
+// handleGetPreOpenImbalance lists symbols with a strong buy or sell surplus in the pre-opening auction
+func (s *Server) handleGetPreOpenImbalance(w http.ResponseWriter, r *http.Request) {
+	loc, err := time.LoadLocation(marketTimeZone)
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+
+	query := r.URL.Query()
+	day := time.Now().In(loc)
+	if d := query.Get("date"); d != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", d, loc)
+		if err != nil {
+			http.Error(w, "Invalid date (want YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		day = parsed
+	}
+
+	side := strings.ToUpper(query.Get("side"))
+	if side != "" && side != "BUY" && side != "SELL" {
+		http.Error(w, "side must be BUY or SELL", http.StatusBadRequest)
+		return
+	}
+
+	threshold := math.Max(getFloatParam(r, "min_pct", 20), 0)
+	minLimit, maxLimit := 1, 200
+	limit := getIntParam(r, "limit", 50, &minLimit, &maxLimit)
+
+	// Whale alerts are counted over the auction itself, PRE_OPENING up to the SESSION_1 open
+	from, okFrom := s.sessions.SessionStart(day, "PRE_OPENING")
+	to, okTo := s.sessions.SessionStart(day, "SESSION_1")
+	if !okFrom || !okTo {
+		from, to = time.Time{}, time.Time{}
+	}
+
+	date := day.Format("2006-01-02")
+	imbalances, err := s.repo.GetPreOpenImbalances(date, threshold, side, from, to, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch pre-opening imbalances", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"imbalances": imbalances,
+		"date":       date,
+		"min_pct":    threshold,
+		"count":      len(imbalances),
+	})
+}
+
+// handleGetOrderFlowSummary returns the symbols with the strongest buy and sell pressure across
+// the market over the last N minutes of order flow buckets
+func (s *Server) handleGetOrderFlowSummary(w http.ResponseWriter, r *http.Request) {
+	minMinutes, maxMinutes := 1, 240
+	minutes := getIntParam(r, "minutes", 15, &minMinutes, &maxMinutes)
+	minLimit, maxLimit := 1, 50
+	limit := getIntParam(r, "limit", 10, &minLimit, &maxLimit)
+	minVolume := math.Max(getFloatParam(r, "min_volume", 1000), 0)
+
+	rows, err := s.repo.GetOrderFlowSummary(minutes, minVolume, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch order flow summary", err)
+		return
+	}
+
+	// Rows arrive sorted by imbalance ratio descending; a symbol may rank on both sides when few qualify
+	buyPressure := make([]types.OrderFlowSummary, 0, limit)
+	sellPressure := make([]types.OrderFlowSummary, 0, limit)
+	for _, row := range rows {
+		if row.BuyRank <= limit && row.ImbalanceRatio > 0 {
+			buyPressure = append(buyPressure, row)
+		}
+	}
+	for i := len(rows) - 1; i >= 0; i-- {
+		if rows[i].SellRank <= limit && rows[i].ImbalanceRatio < 0 {
+			sellPressure = append(sellPressure, rows[i])
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"buy_pressure":  buyPressure,
+		"sell_pressure": sellPressure,
+		"minutes":       minutes,
+		"min_volume":    minVolume,
 	})
 }
 
@@ -192,12 +553,13 @@ func calculateTechnicalAnalysis(candles []map[string]interface{}) map[string]int
 		}
 	}
 
-	// Extract closing prices
-	closes := make([]float64, len(candles))
-	volumes := make([]float64, len(candles))
+	// Extract closing prices; candles are newest first, the indicators package expects oldest first
+	n := len(candles)
+	closes := make([]float64, n)
+	volumes := make([]float64, n)
 	for i, c := range candles {
 		if close, ok := c["close"].(float64); ok {
-			closes[i] = close
+			closes[n-1-i] = close
 		}
 		if vol, ok := c["volume"].(float64); ok {
 			volumes[i] = vol
@@ -250,46 +612,17 @@ func calculateTechnicalAnalysis(candles []map[string]interface{}) map[string]int
 }
 
 func calculateSMA(data []float64, period int) *float64 {
-	if len(data) < period {
-		return nil
-	}
-	sum := 0.0
-	for i := len(data) - period; i < len(data); i++ {
-		sum += data[i]
+	if val, ok := indicators.Last(indicators.SMA(data, period)); ok {
+		return &val
 	}
-	val := sum / float64(period)
-	return &val
+	return nil
 }
 
 func calculateRSI(data []float64, period int) *float64 {
-	if len(data) < period+1 {
-		return nil
-	}
-
-	gains := 0.0
-	losses := 0.0
-
-	// Calculate initial average gain/loss
-	for i := len(data) - period; i < len(data)-1; i++ {
-		change := data[i+1] - data[i]
-		if change > 0 {
-			gains += change
-		} else {
-			losses += -change
-		}
-	}
-
-	avgGain := gains / float64(period)
-	avgLoss := losses / float64(period)
-
-	if avgLoss == 0 {
-		val := 100.0
+	if val, ok := indicators.Last(indicators.RSI(data, period)); ok {
 		return &val
 	}
-
-	rs := avgGain / avgLoss
-	rsi := 100 - (100 / (1 + rs))
-	return &rsi
+	return nil
 }
 
 func calculateAvgVolume(volumes []float64, period int) float64 {
@@ -411,3 +744,29 @@ func (s *Server) handleGetWhaleFollowups(w http.ResponseWriter, r *http.Request)
 		"count":     len(followups),
 	})
 }
+
+// handleGetWhaleAlertQuality reports how well each alert type and symbol predicted the following move
+func (s *Server) handleGetWhaleAlertQuality(w http.ResponseWriter, r *http.Request) {
+	minDays, maxDays := 1, 365
+	days := getIntParam(r, "days", 30, &minDays, &maxDays)
+	minAlerts, maxAlerts := 1, 1000
+	symbolMinAlerts := getIntParam(r, "min_alerts", 5, &minAlerts, &maxAlerts)
+
+	byType, err := s.repo.GetAlertQuality(days, "alert_type", 1)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to calculate alert quality", err)
+		return
+	}
+	bySymbol, err := s.repo.GetAlertQuality(days, "stock_symbol", symbolMinAlerts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to calculate alert quality", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"by_alert_type": byType,
+		"by_symbol":     bySymbol,
+		"days":          days,
+	})
+}
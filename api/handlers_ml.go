@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"stockbit-haka-haki/helpers"
 )
 
 // handleMLDataStats returns statistics about ML training data availability
@@ -20,14 +22,33 @@ func (s *Server) handleMLDataStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
-// handleExportMLData returns a CSV of training data
+// handleExportMLData returns training data as CSV (default) or JSON (?format=json)
+// Rows without a feature vector are skipped unless ?include_incomplete=true
+// Rupiah P&L assumes a fixed notional per trade (whole lots only), overridable with ?notional=
 func (s *Server) handleExportMLData(w http.ResponseWriter, r *http.Request) {
-	data, err := s.repo.GetMLTrainingData()
+	includeIncomplete := r.URL.Query().Get("include_incomplete") == "true"
+	data, err := s.repo.GetMLTrainingData(includeIncomplete)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Size each trade in whole lots at its entry price, as it could actually have been bought
+	notional := getFloatParam(r, "notional", s.apiCfg.MLExportNotional)
+	for i := range data {
+		positionValue := notional
+		if data[i].EntryPrice > 0 {
+			positionValue = float64(helpers.WholeLots(notional, data[i].EntryPrice)*int64(helpers.LotSize())) * data[i].EntryPrice
+		}
+		data[i].ProfitLossIDR = positionValue * data[i].ProfitLossPct / 100
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=training_data_%d.csv", time.Now().Unix()))
 
@@ -35,7 +56,8 @@ func (s *Server) handleExportMLData(w http.ResponseWriter, r *http.Request) {
 	defer writer.Flush()
 
 	// Header
-	writer.Write([]string{"generated_at", "symbol", "strategy", "confidence", "outcome", "profit_pct", "feature_vector"})
+	writer.Write([]string{"generated_at", "symbol", "strategy", "confidence", "outcome", "profit_pct",
+		"profit_idr", "holding_minutes", "exit_reason", "mae", "mfe", "feature_vector"})
 
 	// Rows
 	for _, row := range data {
@@ -46,7 +68,35 @@ func (s *Server) handleExportMLData(w http.ResponseWriter, r *http.Request) {
 			fmt.Sprintf("%.2f", row.Confidence),
 			row.OutcomeResult,
 			fmt.Sprintf("%.2f", row.ProfitLossPct),
+			fmt.Sprintf("%.0f", row.ProfitLossIDR),
+			fmt.Sprintf("%d", row.HoldingMinutes),
+			row.ExitReason,
+			fmt.Sprintf("%.4f", row.MAE),
+			fmt.Sprintf("%.4f", row.MFE),
 			row.AnalysisData,
 		})
 	}
 }
+
+// handleBackfillMLFeatures recomputes scorecards for tracked signals that have no feature vector
+func (s *Server) handleBackfillMLFeatures(w http.ResponseWriter, r *http.Request) {
+	if s.signalTracker == nil {
+		http.Error(w, "Signal tracker not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	minLimit, maxLimit := 1, 1000
+	limit := getIntParam(r, "limit", 200, &minLimit, &maxLimit)
+
+	filled, err := s.signalTracker.BackfillScorecards(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"backfilled": filled,
+		"limit":      limit,
+	})
+}
@@ -3,8 +3,14 @@ package api
 import (
 	"encoding/json"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/indicators"
 )
 
 // handleGetStockCorrelations returns correlations for a symbol
@@ -108,6 +114,116 @@ func (s *Server) handleGetTimeEffectiveness(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// handleGetSignalLatency returns the distribution of signal-to-outcome tracking latency
+func (s *Server) handleGetSignalLatency(w http.ResponseWriter, r *http.Request) {
+	daysBack := 30
+	if d := r.URL.Query().Get("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 {
+			daysBack = parsed
+		}
+	}
+	strategy := r.URL.Query().Get("strategy")
+
+	latency, err := s.repo.GetSignalLatency(daysBack, strategy)
+	if err != nil {
+		log.Printf("❌ Failed to get signal latency: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"latency":   latency,
+		"days_back": daysBack,
+		"count":     len(latency),
+	})
+}
+
+// handleGetEVHeatmap returns expected value and win rate by WIB hour of day for heatmap rendering
+func (s *Server) handleGetEVHeatmap(w http.ResponseWriter, r *http.Request) {
+	strategy := r.URL.Query().Get("strategy")
+	minDays, maxDays := 1, 365
+	daysBack := getIntParam(r, "days", 30, &minDays, &maxDays)
+	minSamples := 1
+	minSignals := getIntParam(r, "min_signals", 3, &minSamples, nil)
+
+	cells, err := s.repo.GetHourlyExpectedValue(strategy, daysBack)
+	if err != nil {
+		log.Printf("❌ Failed to get EV heatmap: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Thin hours are kept so the grid stays complete, but flagged so they can be greyed out
+	type heatmapCell struct {
+		types.HourlyExpectedValue
+		Sufficient bool `json:"sufficient"`
+	}
+	heatmap := make([]heatmapCell, 0, len(cells))
+	for _, cell := range cells {
+		heatmap = append(heatmap, heatmapCell{cell, cell.ClosedSignals >= int64(minSignals)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"heatmap":     heatmap,
+		"strategy":    strategy,
+		"days_back":   daysBack,
+		"min_signals": minSignals,
+		"count":       len(heatmap),
+	})
+}
+
+// handleGetExitReasons returns closed outcome counts, win rate and P/L per exit reason, plus category totals
+func (s *Server) handleGetExitReasons(w http.ResponseWriter, r *http.Request) {
+	minDays, maxDays := 1, 365
+	daysBack := getIntParam(r, "days", 30, &minDays, &maxDays)
+	strategy := r.URL.Query().Get("strategy")
+
+	reasons, err := s.repo.GetExitReasonStats(daysBack, strategy)
+	if err != nil {
+		log.Printf("❌ Failed to get exit reason stats: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Roll reasons up into categories, weighting averages by outcome count
+	type categoryStats struct {
+		Category     string  `json:"category"`
+		Count        int64   `json:"count"`
+		Wins         int64   `json:"wins"`
+		WinRate      float64 `json:"win_rate"`
+		AvgProfitPct float64 `json:"avg_profit_pct"`
+	}
+	categories := make([]*categoryStats, 0)
+	byCategory := make(map[string]*categoryStats)
+	var total int64
+	for _, reason := range reasons {
+		cat, ok := byCategory[reason.Category]
+		if !ok {
+			cat = &categoryStats{Category: reason.Category}
+			byCategory[reason.Category] = cat
+			categories = append(categories, cat)
+		}
+		cat.Count += reason.Count
+		cat.Wins += reason.Wins
+		cat.AvgProfitPct += reason.AvgProfitPct * float64(reason.Count)
+		total += reason.Count
+	}
+	for _, cat := range categories {
+		cat.WinRate = math.Round(float64(cat.Wins)/float64(cat.Count)*10000) / 100
+		cat.AvgProfitPct = math.Round(cat.AvgProfitPct/float64(cat.Count)*10000) / 10000
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"exit_reasons":   reasons,
+		"categories":     categories,
+		"total_outcomes": total,
+		"days_back":      daysBack,
+	})
+}
+
 // handleGetExpectedValues returns expected value calculations for strategies
 func (s *Server) handleGetExpectedValues(w http.ResponseWriter, r *http.Request) {
 	daysBack := 30
@@ -130,3 +246,113 @@ func (s *Server) handleGetExpectedValues(w http.ResponseWriter, r *http.Request)
 		"days_back":       daysBack,
 	})
 }
+
+// tradingDaysPerYear annualizes daily volatility; intraday timeframes scale by bars per trading day
+const tradingDaysPerYear = 252
+
+// defaultTradingMinutesPerDay is the IDX continuous trading time (SESSION_1 + SESSION_2) used when
+// the session schedule is unavailable
+const defaultTradingMinutesPerDay = 260
+
+// handleGetVolatility returns realized volatility (std of log close returns, annualized) for a symbol
+// next to the ATR-based volatility used by the exit calculator
+func (s *Server) handleGetVolatility(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	timeframe := r.URL.Query().Get("timeframe")
+	if timeframe == "" {
+		timeframe = "1day"
+	}
+	periodsPerYear, ok := s.periodsPerYear(timeframe)
+	if !ok {
+		http.Error(w, "timeframe must be one of 1min, 5min, 15min, 1hour, 1day", http.StatusBadRequest)
+		return
+	}
+	minWindow, maxWindow := 5, 500
+	window := getIntParam(r, "window", 20, &minWindow, &maxWindow)
+
+	// window returns need window+1 closes
+	closes, err := s.repo.GetRecentCandleCloses(timeframe, symbol, window+1)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch candles", err)
+		return
+	}
+	prices := make([]float64, len(closes))
+	for i, c := range closes {
+		prices[i] = c.Close
+	}
+
+	result := map[string]interface{}{
+		"symbol":           symbol,
+		"timeframe":        timeframe,
+		"window":           window,
+		"periods_per_year": periodsPerYear,
+	}
+
+	vol, returns, ok := indicators.RealizedVolatility(prices)
+	result["returns"] = returns
+	if ok {
+		result["period_volatility_pct"] = vol * 100
+		result["annualized_volatility_pct"] = vol * math.Sqrt(periodsPerYear) * 100
+		result["as_of"] = closes[len(closes)-1].Bucket
+	} else {
+		result["period_volatility_pct"] = nil
+		result["annualized_volatility_pct"] = nil
+	}
+
+	// ATR% from the exit calculator (cached ATR snapshot), for comparison
+	result["atr_volatility_pct"] = nil
+	if s.signalTracker != nil {
+		if atrPct, err := s.signalTracker.GetVolatilityPercent(symbol); err == nil {
+			result["atr_volatility_pct"] = atrPct
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// periodsPerYear returns how many bars of timeframe make up a trading year
+func (s *Server) periodsPerYear(timeframe string) (float64, bool) {
+	var barMinutes float64
+	switch timeframe {
+	case "1day", "1d", "daily":
+		return tradingDaysPerYear, true
+	case "1hour", "1h", "60min", "60m":
+		barMinutes = 60
+	case "15min", "15m":
+		barMinutes = 15
+	case "5min", "5m":
+		barMinutes = 5
+	case "1min", "1m":
+		barMinutes = 1
+	default:
+		return 0, false
+	}
+	return tradingDaysPerYear * s.tradingMinutesPerDay() / barMinutes, true
+}
+
+// tradingMinutesPerDay sums today's SESSION_1 and SESSION_2 durations from the session schedule
+func (s *Server) tradingMinutesPerDay() float64 {
+	loc, err := time.LoadLocation(marketTimeZone)
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+	today := time.Now().In(loc)
+
+	total := 0.0
+	for _, session := range [][2]string{{"SESSION_1", "LUNCH_BREAK"}, {"SESSION_2", "PRE_CLOSING"}} {
+		start, okStart := s.sessions.SessionStart(today, session[0])
+		end, okEnd := s.sessions.SessionStart(today, session[1])
+		if okStart && okEnd && end.After(start) {
+			total += end.Sub(start).Minutes()
+		}
+	}
+	if total == 0 {
+		return defaultTradingMinutesPerDay
+	}
+	return total
+}
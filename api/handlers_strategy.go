@@ -2,14 +2,22 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"sort"
 	"stockbit-haka-haki/database"
+	"stockbit-haka-haki/database/types"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// knownStrategies are the strategies evaluated by the signal generator
+var knownStrategies = []string{"VOLUME_BREAKOUT", "MEAN_REVERSION", "FAKEOUT_FILTER"}
+
 // handleGetStrategySignals returns recent strategy signals in JSON format
 func (s *Server) handleGetStrategySignals(w http.ResponseWriter, r *http.Request) {
 	// Parse query params
@@ -204,6 +212,40 @@ func (s *Server) handleGetSignalPerformance(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(stats)
 }
 
+// handleCompareStrategies returns side-by-side stats for every strategy, sorted by expectancy
+func (s *Server) handleCompareStrategies(w http.ResponseWriter, r *http.Request) {
+	minDays, maxDays := 1, 365
+	daysBack := getIntParam(r, "days", 30, &minDays, &maxDays)
+
+	comparison, err := s.repo.GetStrategyComparison(daysBack)
+	if err != nil {
+		log.Printf("❌ Failed to compare strategies: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Include strategies with no closed outcomes so the UI always shows all three
+	seen := make(map[string]bool, len(comparison))
+	for _, c := range comparison {
+		seen[c.Strategy] = true
+	}
+	for _, strategy := range knownStrategies {
+		if !seen[strategy] {
+			comparison = append(comparison, types.StrategyComparison{Strategy: strategy})
+		}
+	}
+	sort.SliceStable(comparison, func(i, j int) bool {
+		return comparison[i].Expectancy > comparison[j].Expectancy
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"strategies": comparison,
+		"days_back":  daysBack,
+		"count":      len(comparison),
+	})
+}
+
 // handleGetSignalOutcome returns outcome for a specific signal
 func (s *Server) handleGetSignalOutcome(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -228,6 +270,108 @@ func (s *Server) handleGetSignalOutcome(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(outcome)
 }
 
+// handleGetSignalScorecard returns the filter-by-filter evaluation of a signal
+func (s *Server) handleGetSignalScorecard(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid signal ID", http.StatusBadRequest)
+		return
+	}
+
+	if s.signalTracker == nil {
+		http.Error(w, "Signal tracker not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	scorecard, err := s.signalTracker.GetSignalScorecard(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if scorecard == nil {
+		http.Error(w, "No scorecard recorded for signal", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scorecard)
+}
+
+// handleGetSignalSkipReasons returns why the tracker declined to open a position for a signal
+func (s *Server) handleGetSignalSkipReasons(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid signal ID", http.StatusBadRequest)
+		return
+	}
+
+	if s.signalTracker == nil {
+		http.Error(w, "Signal tracker not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	audit, err := s.signalTracker.GetSkipAudit(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if audit == nil {
+		http.Error(w, "No skip recorded for signal", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(audit)
+}
+
+// handleGetExitSignals returns how recent SELL signals were used to exit open longs
+func (s *Server) handleGetExitSignals(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+
+	minHours, maxHours := 1, 720
+	hours := getIntParam(r, "hours", 24, &minHours, &maxHours)
+	minLimit, maxLimit := 1, 500
+	limit := getIntParam(r, "limit", 100, &minLimit, &maxLimit)
+
+	records, err := s.repo.GetExitSignals(symbol, time.Now().Add(-time.Duration(hours)*time.Hour), limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch exit signals", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"exit_signals": records,
+		"count":        len(records),
+	})
+}
+
+// handleGetQuarantinedSymbols lists symbols skipped by signal generation after repeated data anomalies
+func (s *Server) handleGetQuarantinedSymbols(w http.ResponseWriter, r *http.Request) {
+	quarantined := s.repo.GetQuarantinedSymbols()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"quarantined": quarantined,
+		"count":       len(quarantined),
+	})
+}
+
+// handleReleaseQuarantinedSymbol ends a symbol's quarantine before it expires
+func (s *Server) handleReleaseQuarantinedSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	if !s.repo.ReleaseQuarantinedSymbol(symbol) {
+		http.Error(w, "Symbol is not quarantined", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // handleGetDailyPerformance returns daily strategy performance analytics
 func (s *Server) handleGetDailyPerformance(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -252,12 +396,45 @@ func (s *Server) handleGetDailyPerformance(w http.ResponseWriter, r *http.Reques
 
 	log.Printf("✅ Returning %d performance records", len(performance))
 
+	// The view is refreshed by the background PerformanceRefresher; null until its first run
+	var lastRefreshed *time.Time
+	if refreshedAt := s.repo.StrategyPerformanceRefreshedAt(); !refreshedAt.IsZero() {
+		lastRefreshed = &refreshedAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"performance":    performance,
+		"strategy":       strategy,
+		"symbol":         symbol,
+		"count":          len(performance),
+		"last_refreshed": lastRefreshed,
+	})
+}
+
+// handleGetExitLevels returns ATR-based stop/TP levels for a symbol and entry price
+func (s *Server) handleGetExitLevels(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	entryPrice := getFloatParam(r, "entry", 0)
+
+	if symbol == "" || entryPrice <= 0 {
+		http.Error(w, "symbol and a positive entry price are required", http.StatusBadRequest)
+		return
+	}
+
+	if s.signalTracker == nil {
+		http.Error(w, "Signal tracker not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	levels := s.signalTracker.GetExitLevels(symbol, entryPrice)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"performance": performance,
-		"strategy":    strategy,
 		"symbol":      symbol,
-		"count":       len(performance),
+		"entry_price": entryPrice,
+		"day":         levels["day"],
+		"swing":       levels["swing"],
 	})
 }
 
@@ -350,6 +527,8 @@ func (s *Server) handleGetOpenPositions(w http.ResponseWriter, r *http.Request)
 			"max_adverse_excursion":   pos.MaxAdverseExcursion,
 			"confidence":              signal.Confidence,
 			"outcome_status":          pos.OutcomeStatus,
+			"position_units":          pos.PositionUnits,
+			"scale_ins":               pos.ScaleIns,
 		}
 
 		enrichedPositions = append(enrichedPositions, enrichedPos)
@@ -364,6 +543,107 @@ func (s *Server) handleGetOpenPositions(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// handlePositionsStream pushes open-position updates (current price and P&L) over SSE
+// Sends the current open positions first, then each "position_update" from the signal tracker
+func (s *Server) handlePositionsStream(w http.ResponseWriter, r *http.Request) {
+	if s.broker == nil {
+		http.Error(w, "Realtime broker not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before the snapshot so no update falls in between
+	updates, err := s.broker.Subscribe("position_update")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.broker.Unsubscribe(updates)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"connected\"}\n\n")
+
+	if s.signalTracker != nil {
+		positions, err := s.signalTracker.GetOpenPositions("", "", 100)
+		if err != nil {
+			log.Printf("⚠️ Failed to load open positions for stream: %v", err)
+		} else if snapshot, err := json.Marshal(positions); err == nil {
+			fmt.Fprintf(w, "event: positions\ndata: %s\n\n", snapshot)
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: position_update\ndata: %s\n\n", update); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// handleClosePosition force-closes an open position at a supplied price or the latest candle close
+func (s *Server) handleClosePosition(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid position ID", http.StatusBadRequest)
+		return
+	}
+
+	if s.signalTracker == nil {
+		http.Error(w, "Signal tracker not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Body is optional: {"price": 1234}
+	var req struct {
+		Price float64 `json:"price"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Price < 0 {
+		http.Error(w, "price must be positive", http.StatusBadRequest)
+		return
+	}
+
+	outcome, err := s.signalTracker.ClosePosition(id, req.Price)
+	if err != nil {
+		var notFound *database.NotFoundError
+		var invalid *database.ValidationError
+		switch {
+		case errors.As(err, &notFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.As(err, &invalid):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Printf("❌ Failed to close position %d: %v", id, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outcome)
+}
+
 // handleGetProfitLossHistory returns profit/loss history with status
 func (s *Server) handleGetProfitLossHistory(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a single client's bucket
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter is a per-IP token bucket limiter
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	rps     float64
+	burst   float64
+}
+
+// newRateLimiter creates a per-IP limiter allowing rps sustained requests with the given burst
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	rl := &rateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rps:     rps,
+		burst:   float64(burst),
+	}
+	go rl.cleanupLoop()
+	return rl
+}
+
+// allow consumes a token for key; when empty it returns the wait until the next token
+func (rl *rateLimiter) allow(key string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rl.burst, lastSeen: now}
+		rl.buckets[key] = b
+	}
+
+	// Refill based on elapsed time
+	b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*rl.rps)
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / rl.rps * float64(time.Second))
+	return false, wait
+}
+
+// cleanupLoop drops buckets for clients idle longer than 10 minutes
+func (rl *rateLimiter) cleanupLoop() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		rl.mu.Lock()
+		for key, b := range rl.buckets {
+			if time.Since(b.lastSeen) > 10*time.Minute {
+				delete(rl.buckets, key)
+			}
+		}
+		rl.mu.Unlock()
+	}
+}
+
+// parseTrustedProxies converts API_TRUSTED_PROXIES entries (IPs or CIDRs) to prefixes, skipping invalid ones
+func parseTrustedProxies(entries []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		} else {
+			log.Printf("⚠️ Ignoring invalid trusted proxy %q", entry)
+		}
+	}
+	return prefixes
+}
+
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP extracts the caller IP. X-Forwarded-For is client-controlled, so it's only honoured
+// when the connection comes from a trusted proxy; the client is then the rightmost entry that
+// isn't itself a trusted proxy
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(host, trusted) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedProxy(hop, trusted) {
+			return hop
+		}
+	}
+	return host
+}
+
+// isExpensiveEndpoint reports whether a path is LLM-backed or a long-lived stream
+func isExpensiveEndpoint(path string) bool {
+	return strings.HasPrefix(path, "/api/ai/") || path == "/api/custom-prompt" || strings.Contains(path, "/stream")
+}
+
+// rateLimitMiddleware applies per-IP limits to /api/ routes
+// LLM and streaming endpoints use the stricter limiter to cap token costs
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	if !s.apiCfg.RateLimitEnabled || s.apiCfg.RateLimitRPS <= 0 {
+		return next
+	}
+
+	general := newRateLimiter(s.apiCfg.RateLimitRPS, s.apiCfg.RateLimitBurst)
+	expensive := general
+	if s.apiCfg.ExpensiveRateLimitRPS > 0 {
+		expensive = newRateLimiter(s.apiCfg.ExpensiveRateLimitRPS, s.apiCfg.ExpensiveRateLimitBurst)
+	}
+	trusted := parseTrustedProxies(s.apiCfg.TrustedProxies)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The SSE event bus is a single long-lived connection; don't count it
+		if !strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/api/events" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limiter := general
+		if isExpensiveEndpoint(r.URL.Path) {
+			limiter = expensive
+		}
+
+		if ok, wait := limiter.allow(clientIP(r, trusted)); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -10,7 +10,10 @@ import (
 	"strings"
 	"time"
 
+	"stockbit-haka-haki/cache"
+	"stockbit-haka-haki/config"
 	"stockbit-haka-haki/database"
+	"stockbit-haka-haki/database/types"
 	"stockbit-haka-haki/llm"
 	"stockbit-haka-haki/notifications"
 	"stockbit-haka-haki/realtime"
@@ -24,11 +27,43 @@ type Server struct {
 	llmClient     *llm.Client
 	llmEnabled    bool
 	signalTracker SignalTrackerInterface // Use case for signal tracking
+	apiCfg        config.APIConfig
+	symbolFilter  SymbolFilterInterface
+	baselineCalc  BaselineCalculatorInterface
+	redis         *cache.RedisClient   // Optional; only probed by /health
+	ingest        IngestStatsInterface // Optional; trade handler counters
+	sessions      config.SessionConfig // Trading sessions (candle freshness probe, pre-opening window)
+	cfg           *config.Config       // Effective configuration, served sanitized by /api/config
+
+	llmMaxContextTokens int
+	llmCache            *insightCache // nil when caching is disabled
 }
 
 // SignalTrackerInterface defines the interface for signal tracking operations
 type SignalTrackerInterface interface {
 	GetOpenPositions(symbol, strategy string, limit int) ([]database.SignalOutcome, error)
+	GetExitLevels(symbol string, entryPrice float64) map[string]interface{}
+	GetSignalScorecard(signalID int64) (*types.SignalScorecard, error)
+	BackfillScorecards(limit int) (int, error)
+	ClosePosition(outcomeID int64, exitPrice float64) (*database.SignalOutcome, error)
+	GetSkipAudit(signalID int64) (*types.SkipAudit, error)
+	GetVolatilityPercent(symbol string) (float64, error)
+}
+
+// SymbolFilterInterface exposes the symbol whitelist/blacklist for management endpoints
+type SymbolFilterInterface interface {
+	Refresh() error
+	Lists() (whitelist, blacklist []string)
+}
+
+// IngestStatsInterface exposes the running trade handler's message and validation counters
+type IngestStatsInterface interface {
+	IngestStats() types.IngestStats
+}
+
+// BaselineCalculatorInterface recomputes statistical baselines on demand
+type BaselineCalculatorInterface interface {
+	RecomputeSymbol(symbol string) (*database.StatisticalBaseline, error)
 }
 
 // NewServer creates a new API server instance
@@ -47,6 +82,51 @@ func (s *Server) SetSignalTracker(tracker SignalTrackerInterface) {
 	s.signalTracker = tracker
 }
 
+// SetSymbolFilter sets the symbol whitelist/blacklist service
+func (s *Server) SetSymbolFilter(filter SymbolFilterInterface) {
+	s.symbolFilter = filter
+}
+
+// SetBaselineCalculator sets the baseline calculator used by the recompute endpoint
+func (s *Server) SetBaselineCalculator(calc BaselineCalculatorInterface) {
+	s.baselineCalc = calc
+}
+
+// SetIngestStats sets the source of the ingestion counters
+func (s *Server) SetIngestStats(ingest IngestStatsInterface) {
+	s.ingest = ingest
+}
+
+// SetHealthDependencies sets the Redis client and trading sessions probed by /health
+func (s *Server) SetHealthDependencies(redis *cache.RedisClient, sessions config.SessionConfig) {
+	s.redis = redis
+	s.sessions = sessions
+}
+
+// SetConfig sets the loaded configuration reported by GET /api/config
+func (s *Server) SetConfig(cfg *config.Config) {
+	s.cfg = cfg
+}
+
+// SetAPIConfig sets rate limiting and authentication settings for the HTTP API
+func (s *Server) SetAPIConfig(cfg config.APIConfig) {
+	s.apiCfg = cfg
+}
+
+// SetLLMContextLimit caps the approximate tokens of database context sent with custom prompts
+func (s *Server) SetLLMContextLimit(maxTokens int) {
+	s.llmMaxContextTokens = maxTokens
+}
+
+// SetLLMCacheTTL enables caching of non-streaming LLM insights for identical prompts
+func (s *Server) SetLLMCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		s.llmCache = nil
+		return
+	}
+	s.llmCache = newInsightCache(ttl)
+}
+
 // Start starts the HTTP server on the specified port
 func (s *Server) Start(port int) error {
 	mux := http.NewServeMux()
@@ -59,6 +139,7 @@ func (s *Server) Start(port int) error {
 	s.registerAnalyticsRoutes(mux)
 
 	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /api/ingest/stats", s.handleGetIngestStats)
 
 	// Serve Static Files (Public UI) with Cache Busting for index.html
 	fs := http.FileServer(http.Dir("./public"))
@@ -84,10 +165,14 @@ func (s *Server) Start(port int) error {
 		fs.ServeHTTP(w, r)
 	})
 
-	// Add middleware (gzip -> cors -> logging)
-	handler := s.gzipMiddleware(s.corsMiddleware(s.loggingMiddleware(mux)))
+	// Add middleware (gzip -> cors -> logging -> rate limit -> auth)
+	// Rate limiting runs before auth so failed API key attempts are throttled too
+	handler := s.gzipMiddleware(s.corsMiddleware(s.loggingMiddleware(s.rateLimitMiddleware(s.authMiddleware(mux)))))
 
 	serverAddr := fmt.Sprintf("0.0.0.0:%d", port)
+	if s.apiCfg.APIKey == "" {
+		log.Println("⚠️ API_KEY not set: write and AI endpoints are unauthenticated")
+	}
 	log.Printf("🚀 API Server starting on %s", serverAddr)
 	return http.ListenAndServe(serverAddr, handler)
 }
@@ -97,7 +182,7 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -165,22 +250,69 @@ func (s *Server) registerMarketRoutes(mux *http.ServeMux) {
 	mux.Handle("GET /api/events", s.broker) // SSE Endpoint
 	mux.HandleFunc("GET /api/whales", s.handleGetWhales)
 	mux.HandleFunc("GET /api/whales/stats", s.handleGetWhaleStats)
+	mux.HandleFunc("GET /api/whales/top", s.handleGetTopWhales)
+	mux.HandleFunc("GET /api/whales/flow", s.handleGetWhaleFlow)
 	mux.HandleFunc("GET /api/whales/{id}/followup", s.handleGetWhaleFollowup)
 	mux.HandleFunc("GET /api/whales/followups", s.handleGetWhaleFollowups)
+	mux.HandleFunc("GET /api/whales/quality", s.handleGetWhaleAlertQuality)
 
+	mux.HandleFunc("GET /api/trades", s.handleGetTrades)
 	mux.HandleFunc("GET /api/candles", s.handleGetCandles)
+	mux.HandleFunc("GET /api/market/breadth", s.handleGetMarketBreadth)
+	mux.HandleFunc("GET /api/market/preopen", s.handleGetPreOpenImbalance)
+	mux.HandleFunc("GET /api/orderflow/summary", s.handleGetOrderFlowSummary)
 }
 
 func (s *Server) registerWebhookRoutes(mux *http.ServeMux) {
+	// Effective runtime configuration (secrets masked)
+	mux.HandleFunc("GET /api/config", s.handleGetConfig)
+
 	mux.HandleFunc("GET /api/config/webhooks", s.handleGetWebhooks)
 	mux.HandleFunc("POST /api/config/webhooks", s.handleCreateWebhook)
 	mux.HandleFunc("PUT /api/config/webhooks/{id}", s.handleUpdateWebhook)
 	mux.HandleFunc("DELETE /api/config/webhooks/{id}", s.handleDeleteWebhook)
+	mux.HandleFunc("POST /api/config/webhooks/{id}/test", s.handleTestWebhook)
+
+	// Corporate actions (splits etc.) - excluded from baseline price continuity
+	mux.HandleFunc("GET /api/config/corporate-actions", s.handleGetCorporateActions)
+	mux.HandleFunc("POST /api/config/corporate-actions", s.handleCreateCorporateAction)
+
+	// Symbol size data (free float / market cap) for whale impact
+	mux.HandleFunc("GET /api/config/symbol-meta", s.handleGetSymbolMeta)
+	mux.HandleFunc("PUT /api/config/symbol-meta", s.handleUpdateSymbolMeta)
+
+	// Symbol Whitelist/Blacklist
+	mux.HandleFunc("GET /api/config/symbols", s.handleGetSymbolFilters)
+	mux.HandleFunc("POST /api/config/symbols", s.handleCreateSymbolFilter)
+	mux.HandleFunc("DELETE /api/config/symbols/{list}/{symbol}", s.handleDeleteSymbolFilter)
+
+	// Scheduled Events (news blackout windows)
+	mux.HandleFunc("GET /api/config/events", s.handleGetScheduledEvents)
+	mux.HandleFunc("POST /api/config/events", s.handleCreateScheduledEvent)
+	mux.HandleFunc("PUT /api/config/events/{id}", s.handleUpdateScheduledEvent)
+	mux.HandleFunc("DELETE /api/config/events/{id}", s.handleDeleteScheduledEvent)
+
+	// Alert suppression (mute whale alert delivery for a symbol/alert type until a deadline)
+	mux.HandleFunc("GET /api/config/suppressions", s.handleGetSuppressionRules)
+	mux.HandleFunc("POST /api/config/suppressions", s.handleCreateSuppressionRule)
+	mux.HandleFunc("PUT /api/config/suppressions/{id}", s.handleUpdateSuppressionRule)
+	mux.HandleFunc("DELETE /api/config/suppressions/{id}", s.handleDeleteSuppressionRule)
+
+	// Strategy enable/disable switches
+	mux.HandleFunc("GET /api/config/strategies", s.handleGetStrategyConfigs)
+	mux.HandleFunc("PUT /api/config/strategies", s.handleUpdateStrategyConfig)
+
+	// Per-symbol strategy preferences (PREFER / AVOID)
+	mux.HandleFunc("GET /api/config/symbol-strategies", s.handleGetSymbolStrategyPreferences)
+	mux.HandleFunc("PUT /api/config/symbol-strategies", s.handleUpdateSymbolStrategyPreference)
+	mux.HandleFunc("DELETE /api/config/symbol-strategies/{symbol}/{strategy}", s.handleDeleteSymbolStrategyPreference)
 }
 
 func (s *Server) registerPatternRoutes(mux *http.ServeMux) {
 	// Standard Endpoints
 	mux.HandleFunc("GET /api/accumulation-summary", s.handleAccumulationSummary)
+	mux.HandleFunc("GET /api/patterns", s.handleGetPatterns)
+	mux.HandleFunc("GET /api/patterns/sector-sweep", s.handleGetSectorSweeps)
 
 	// Streaming Endpoints
 
@@ -189,13 +321,22 @@ func (s *Server) registerPatternRoutes(mux *http.ServeMux) {
 func (s *Server) registerStrategyRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/strategies/signals", s.handleGetStrategySignals)
 	mux.HandleFunc("GET /api/strategies/signals/stream", s.handleStrategySignalsStream)
+	mux.HandleFunc("GET /api/strategies/compare", s.handleCompareStrategies)
 
 	// Signal History & Outcomes
 	mux.HandleFunc("GET /api/signals/history", s.handleGetSignalHistory)
 	mux.HandleFunc("GET /api/signals/performance", s.handleGetSignalPerformance)
 	mux.HandleFunc("GET /api/signals/{id}/outcome", s.handleGetSignalOutcome)
+	mux.HandleFunc("GET /api/signals/{id}/scorecard", s.handleGetSignalScorecard)
+	mux.HandleFunc("GET /api/signals/{id}/skip-reasons", s.handleGetSignalSkipReasons)
+	mux.HandleFunc("GET /api/signals/exits", s.handleGetExitSignals)
+	mux.HandleFunc("GET /api/signals/quarantine", s.handleGetQuarantinedSymbols)
+	mux.HandleFunc("DELETE /api/signals/quarantine/{symbol}", s.handleReleaseQuarantinedSymbol)
 	mux.HandleFunc("GET /api/positions/open", s.handleGetOpenPositions)
+	mux.HandleFunc("GET /api/positions/stream", s.handlePositionsStream)
+	mux.HandleFunc("POST /api/positions/{id}/close", s.handleClosePosition)
 	mux.HandleFunc("GET /api/positions/history", s.handleGetProfitLossHistory)
+	mux.HandleFunc("GET /api/exit-levels", s.handleGetExitLevels)
 
 	// Signal Statistics for Debugging
 	mux.HandleFunc("GET /api/signals/stats", s.handleGetSignalStats)
@@ -208,14 +349,26 @@ func (s *Server) registerAnalyticsRoutes(mux *http.ServeMux) {
 	// ML Data & Stats
 	mux.HandleFunc("GET /api/analytics/export/ml-data", s.handleExportMLData)
 	mux.HandleFunc("GET /api/analytics/ml-data/stats", s.handleMLDataStats)
+	mux.HandleFunc("POST /api/analytics/ml-data/backfill", s.handleBackfillMLFeatures)
 
 	// Effectiveness & Optimization
 	mux.HandleFunc("GET /api/analytics/strategy-effectiveness", s.handleGetStrategyEffectiveness)
 	mux.HandleFunc("GET /api/analytics/optimal-thresholds", s.handleGetOptimalThresholds)
 	mux.HandleFunc("GET /api/analytics/time-effectiveness", s.handleGetTimeEffectiveness)
+	mux.HandleFunc("GET /api/analytics/ev-heatmap", s.handleGetEVHeatmap)
+	mux.HandleFunc("GET /api/analytics/exit-reasons", s.handleGetExitReasons)
+	mux.HandleFunc("GET /api/analytics/volatility", s.handleGetVolatility)
 	mux.HandleFunc("GET /api/analytics/expected-values", s.handleGetExpectedValues)
+	mux.HandleFunc("GET /api/analytics/signal-latency", s.handleGetSignalLatency)
+
+	// Market Regimes
+	mux.HandleFunc("GET /api/regimes/history", s.handleGetRegimeHistory)
+
+	// Statistical Baselines
+	mux.HandleFunc("POST /api/baselines/recompute", s.handleRecomputeBaseline)
 
 	// AI Analysis Endpoints
 	mux.HandleFunc("GET /api/ai/analysis/symbol", s.handleSymbolAnalysisStream)
 	mux.HandleFunc("POST /api/ai/analysis/custom", s.handleCustomPromptStream)
+	mux.HandleFunc("POST /api/custom-prompt", s.handleCustomPrompt)
 }
@@ -16,7 +16,9 @@ import (
 	"stockbit-haka-haki/cache"
 	"stockbit-haka-haki/config"
 	"stockbit-haka-haki/database"
+	"stockbit-haka-haki/database/types"
 	"stockbit-haka-haki/handlers"
+	"stockbit-haka-haki/helpers"
 	"stockbit-haka-haki/llm"
 	"stockbit-haka-haki/notifications"
 	"stockbit-haka-haki/realtime"
@@ -35,11 +37,16 @@ type App struct {
 	tradeRepo       *database.TradeRepository
 	webhookManager  *notifications.WebhookManager
 	broker          *realtime.Broker
-	signalTracker   *SignalTracker        // Phase 1: Signal outcome tracking
-	whaleFollowup   *WhaleFollowupTracker // Phase 1: Whale alert followup
-	baselineCalc    *BaselineCalculator   // Phase 2: Statistical baselines
-	correlationAnal *CorrelationAnalyzer  // Phase 3: Stock correlations
-	perfRefresher   *PerformanceRefresher // Phase 3: Performance view refresher
+	signalTracker   *SignalTracker                // Phase 1: Signal outcome tracking
+	symbolFilter    *SymbolFilterService          // Symbol whitelist/blacklist
+	whaleFollowup   *WhaleFollowupTracker         // Phase 1: Whale alert followup
+	baselineCalc    *BaselineCalculator           // Phase 2: Statistical baselines
+	correlationAnal *CorrelationAnalyzer          // Phase 3: Stock correlations
+	perfRefresher   *PerformanceRefresher         // Phase 3: Performance view refresher
+	sectorSweep     *SectorSweepDetector          // Phase 3: Correlated whale buying
+	gapDetector     *GapDetector                  // Opening auction gaps
+	squeezeDetector *SqueezeDetector              // Bollinger band squeezes
+	tradeHandler    *handlers.RunningTradeHandler // Live trade ingestion (exposes ingest counters)
 }
 
 // New creates a new application instance
@@ -56,6 +63,14 @@ func New(cfg *config.Config) *App {
 	// Initialize WebSocket Manager
 	wsManager := websocket.NewConnectionManager(cfg.TradingWSURL, authManager)
 
+	// IDX price fractions and lot size for exit levels, fills and sizing
+	ticks, err := helpers.ParseTickSchedule(cfg.Trading.TickSchedule)
+	if err != nil {
+		log.Printf("⚠️ Invalid TRADING_TICK_SCHEDULE, using the IDX default: %v", err)
+	}
+	helpers.SetTickSchedule(ticks)
+	helpers.SetLotSize(cfg.Trading.LotSize)
+
 	return &App{
 		config:         cfg,
 		authManager:    authManager,
@@ -73,51 +88,19 @@ func (a *App) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 1. Database Connection
-	fmt.Println("🗄️  Connecting to database...")
-
-	dbPort, err := strconv.Atoi(a.config.DatabasePort)
-	if err != nil {
-		return fmt.Errorf("invalid database port: %w", err)
-	}
-
-	db, err := database.Connect(
-		a.config.DatabaseHost,
-		dbPort,
-		a.config.DatabaseName,
-		a.config.DatabaseUser,
-		a.config.DatabasePassword,
-	)
-	if err != nil {
-		return fmt.Errorf("database connection failed: %w", err)
-	}
-	a.db = db
-
-	// 2. Redis Connection
-	fmt.Println("🧠 Connecting to Redis...")
-	redisClient := cache.NewRedisClient(
-		a.config.RedisHost,
-		a.config.RedisPort,
-		a.config.RedisPassword,
-	)
-
-	if redisClient == nil {
-		fmt.Println("⚠️  Redis connection failed. Caching disabled.")
-	} else {
-		a.redis = redisClient
-	}
-
-	// Initialize schema (AutoMigrate + TimescaleDB setup)
-	a.tradeRepo = database.NewTradeRepository(a.db)
-	if err := a.tradeRepo.InitSchema(); err != nil {
-		return fmt.Errorf("schema initialization failed: %w", err)
+	// 1-2. Database, Redis and schema
+	if err := a.initStorage(); err != nil {
+		return err
 	}
 
 	// Initialize Webhook Manager (with Redis)
-	a.webhookManager = notifications.NewWebhookManager(a.tradeRepo, a.redis)
+	a.webhookManager = notifications.NewWebhookManager(a.tradeRepo, a.redis, a.config.WebhookMinConfidence)
+
+	// Initialize Symbol Filter (whitelist/blacklist)
+	a.symbolFilter = NewSymbolFilterService(a.tradeRepo, a.config)
 
 	// Initialize Realtime Broker
-	a.broker = realtime.NewBroker()
+	a.broker = realtime.NewBroker(a.config.API.SSEMaxClients, a.config.API.SSEClientBuffer, a.config.API.SSEReplayBuffer)
 	go a.broker.Run()
 
 	// 3. Authentication
@@ -151,7 +134,8 @@ func (a *App) Start() error {
 
 	// Signal Outcome Tracker
 	// Signal Outcome Tracker
-	a.signalTracker = NewSignalTracker(a.tradeRepo, a.redis, a.config)
+	a.signalTracker = NewSignalTracker(a.tradeRepo, a.redis, a.config, a.symbolFilter)
+	a.signalTracker.SetBroker(a.broker)
 	go a.signalTracker.Start()
 
 	// 9. Start API Server (AFTER signal tracker is initialized)
@@ -159,6 +143,17 @@ func (a *App) Start() error {
 
 	// Inject signal tracker into API server BEFORE starting the server
 	apiServer.SetSignalTracker(a.signalTracker)
+	apiServer.SetAPIConfig(a.config.API)
+	apiServer.SetConfig(a.config)
+	apiServer.SetLLMContextLimit(a.config.LLM.MaxContextTokens)
+	apiServer.SetLLMCacheTTL(time.Duration(a.config.LLM.CacheTTLSeconds) * time.Second)
+	apiServer.SetSymbolFilter(a.symbolFilter)
+	apiServer.SetHealthDependencies(a.redis, a.config.Sessions)
+	apiServer.SetIngestStats(a.tradeHandler)
+
+	// Baseline calculator is created here so the API can trigger on-demand recomputes; it starts in phase 2
+	a.baselineCalc = NewBaselineCalculator(a.tradeRepo)
+	apiServer.SetBaselineCalculator(a.baselineCalc)
 
 	// Start API Server after dependencies are initialized
 	go func() {
@@ -175,7 +170,6 @@ func (a *App) Start() error {
 	log.Println("🚀 Starting Phase 2 enhancement calculators...")
 
 	// Statistical Baseline Calculator
-	a.baselineCalc = NewBaselineCalculator(a.tradeRepo)
 	go a.baselineCalc.Start()
 
 	// Pattern Detector removed - 100% loss rate on Range Breakout patterns
@@ -188,9 +182,25 @@ func (a *App) Start() error {
 	go a.correlationAnal.Start()
 
 	// Performance Refresher
-	a.perfRefresher = NewPerformanceRefresher(a.tradeRepo)
+	a.perfRefresher = NewPerformanceRefresher(a.tradeRepo, time.Duration(a.config.PerformanceRefreshMinutes)*time.Minute)
 	go a.perfRefresher.Start()
 
+	// Sector Sweep Detector
+	a.sectorSweep = NewSectorSweepDetector(a.tradeRepo, a.broker)
+	go a.sectorSweep.Start()
+
+	// Opening Gap Detector (GAP_MIN_PCT=0 disables)
+	if a.config.GapMinPct > 0 {
+		a.gapDetector = NewGapDetector(a.tradeRepo, a.broker, a.config)
+		go a.gapDetector.Start()
+	}
+
+	// Bollinger Squeeze Detector (SQUEEZE_LOOKBACK_BARS=0 disables)
+	if a.config.SqueezeLookbackBars > 0 {
+		a.squeezeDetector = NewSqueezeDetector(a.tradeRepo, a.broker, a.config)
+		go a.squeezeDetector.Start()
+	}
+
 	// Setup WaitGroup for goroutines
 	var wg sync.WaitGroup
 
@@ -219,11 +229,76 @@ func (a *App) Start() error {
 	}()
 
 	// 15. Wait for interrupt and perform graceful shutdown
-	err = a.gracefulShutdown(cancel)
+	err := a.gracefulShutdown(cancel)
 	wg.Wait()
 	return err
 }
 
+// initStorage connects to the database and Redis and prepares the trade repository and schema
+func (a *App) initStorage() error {
+	// 1. Database Connection
+	fmt.Println("🗄️  Connecting to database...")
+
+	dbPort, err := strconv.Atoi(a.config.DatabasePort)
+	if err != nil {
+		return fmt.Errorf("invalid database port: %w", err)
+	}
+
+	db, err := database.Connect(
+		a.config.DatabaseHost,
+		dbPort,
+		a.config.DatabaseName,
+		a.config.DatabaseUser,
+		a.config.DatabasePassword,
+	)
+	if err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
+	a.db = db
+
+	// 2. Redis Connection
+	fmt.Println("🧠 Connecting to Redis...")
+	redisClient := cache.NewRedisClient(
+		a.config.RedisHost,
+		a.config.RedisPort,
+		a.config.RedisPassword,
+	)
+
+	if redisClient == nil {
+		fmt.Println("⚠️  Redis connection failed. Caching disabled.")
+	} else {
+		a.redis = redisClient
+	}
+
+	// Initialize schema (AutoMigrate + TimescaleDB setup)
+	a.tradeRepo = database.NewTradeRepository(a.db)
+	a.tradeRepo.SetZScoreLimits(a.config.Trading.ZScoreClamp, a.config.Trading.MinBaselineStdDevPct)
+	a.tradeRepo.SetBaselineDecay(a.config.Trading.BaselineDecayAfterMinutes, a.config.Trading.BaselineDecayPerHour)
+	a.tradeRepo.SetRGOnlySignals(a.config.Trading.SignalRGOnly)
+	a.tradeRepo.SetSignalWorkers(a.config.SignalWorkerPoolSize)
+	a.tradeRepo.SetSqueezeBreakoutBoost(a.config.SqueezeBreakoutBoost)
+	a.tradeRepo.SetSymbolQuarantine(a.config.Trading.QuarantineAnomalies, a.config.Trading.QuarantineWindowMinutes, a.config.Trading.QuarantineMinutes)
+	a.tradeRepo.SetMeanReversionRSI(a.config.Trading.MeanReversionRSITimeframe, a.config.Trading.MeanReversionRSIPeriod,
+		a.config.Trading.MeanReversionRSIOverbought, a.config.Trading.MeanReversionRSIOversold)
+	a.tradeRepo.SetRetention(database.RetentionPolicy{
+		RunningTradesDays:  a.config.Retention.TradesDays,
+		WhaleAlertsDays:    a.config.Retention.WhaleAlertsDays,
+		TradingSignalsDays: a.config.Retention.SignalsDays,
+		SignalOutcomesDays: a.config.Retention.OutcomesDays,
+	})
+	for _, warning := range a.config.Retention.Warnings(a.config.Trading.MaxHoldingDays()) {
+		fmt.Printf("⚠️  Retention: %s\n", warning)
+	}
+	if a.config.Trading.ConfidenceMode == "logistic" {
+		a.tradeRepo.SetConfidenceModels(confidenceModels(a.config.Trading.ConfidenceModels))
+	}
+	if err := a.tradeRepo.InitSchema(); err != nil {
+		return fmt.Errorf("schema initialization failed: %w", err)
+	}
+
+	return nil
+}
+
 // gracefulShutdown handles graceful shutdown with timeout
 func (a *App) gracefulShutdown(cancel context.CancelFunc) error {
 	// Setup signal handling
@@ -266,6 +341,18 @@ func (a *App) gracefulShutdown(cancel context.CancelFunc) error {
 			fmt.Println("🔄 Stopping performance refresher...")
 			a.perfRefresher.Stop()
 		}
+		if a.sectorSweep != nil {
+			fmt.Println("🌊 Stopping sector sweep detector...")
+			a.sectorSweep.Stop()
+		}
+		if a.gapDetector != nil {
+			fmt.Println("🕳️ Stopping gap detector...")
+			a.gapDetector.Stop()
+		}
+		if a.squeezeDetector != nil {
+			fmt.Println("🗜️ Stopping squeeze detector...")
+			a.squeezeDetector.Stop()
+		}
 
 		// Close WebSocket connection
 		fmt.Println("📡 Closing trading WebSocket connection...")
@@ -373,7 +460,42 @@ func (a *App) setupHandlers() {
 	// 4. Register Message Handlers
 	// Running Trade Handler
 	// Initialize Volatility Provider (ExitStrategyCalculator) for Adaptive Thresholds
-	volatilityProv := NewExitStrategyCalculator(a.tradeRepo, a.config)
-	runningTradeHandler := handlers.NewRunningTradeHandler(a.tradeRepo, a.webhookManager, a.redis, a.broker, volatilityProv)
+	volatilityProv := NewExitStrategyCalculator(a.tradeRepo, a.redis, a.config)
+	runningTradeHandler := handlers.NewRunningTradeHandler(a.tradeRepo, a.webhookManager, a.redis, a.broker, volatilityProv, a.symbolFilter, handlers.ProcessingOptions{
+		WorkerPoolSize:      a.config.TradeWorkerPoolSize,
+		BatchSize:           a.config.TradeBatchSize,
+		BatchFlushInterval:  time.Duration(a.config.TradeBatchFlushMs) * time.Millisecond,
+		DedupTTL:            time.Duration(a.config.TradeDedupTTLMinutes) * time.Minute,
+		SeverityAlertZ:      a.config.WhaleSeverityAlertZ,
+		SeverityCriticalZ:   a.config.WhaleSeverityCriticalZ,
+		IncludeOddLots:      a.config.TradeIncludeOddLots,
+		StatsLookback:       a.config.WhaleStatsLookbackMinutes,
+		StatsMinSamples:     a.config.WhaleStatsMinSamples,
+		PreOpenCapture:      a.config.PreOpenCaptureEnabled,
+		Sessions:            a.config.Sessions,
+		AcceptInvalidTrades: !a.config.TradeRejectInvalid,
+	})
+	a.tradeHandler = runningTradeHandler
 	a.handlerManager.RegisterHandler("running_trade", runningTradeHandler)
 }
+
+// confidenceModels converts configured logistic coefficients into per-strategy models
+// Strategies with the wrong number of coefficients keep linear confidence
+func confidenceModels(coefficients map[string][]float64) map[string]types.ConfidenceModel {
+	result := make(map[string]types.ConfidenceModel, len(coefficients))
+	for strategy, c := range coefficients {
+		if len(c) != 5 {
+			log.Printf("⚠️ Ignoring confidence model for %s: expected 5 coefficients, got %d", strategy, len(c))
+			continue
+		}
+		result[strategy] = types.ConfidenceModel{
+			Intercept:     c[0],
+			PriceZ:        c[1],
+			VolumeZ:       c[2],
+			AggressiveBuy: c[3],
+			PriceChange:   c[4],
+		}
+		log.Printf("🎯 Calibrated confidence enabled for %s", strategy)
+	}
+	return result
+}
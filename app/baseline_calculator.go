@@ -8,6 +8,22 @@ import (
 	models "stockbit-haka-haki/database/models_pkg"
 )
 
+// splitGapThresholdPct is the overnight gap treated as a corporate action
+// IDX auto-rejection caps daily moves at 35%, so a larger gap implies a split or similar event
+const splitGapThresholdPct = 40.0
+
+// baselineLookbacks are tried in order so fresh deployments and newly active symbols still get a baseline
+var baselineLookbacks = []struct {
+	duration  time.Duration
+	minutes   int
+	minTrades int
+}{
+	{24 * time.Hour, 24 * 60, 2}, // Primary: 24 hours with 2 trades minimum (lowered from 3)
+	{2 * time.Hour, 2 * 60, 2},   // Fallback 1: 2 hours with 2 trades
+	{30 * time.Minute, 30, 1},    // Fallback 2: 30 minutes with 1 trade minimum
+	{15 * time.Minute, 15, 1},    // Fallback 3: 15 minutes with 1 trade minimum (new)
+}
+
 // BaselineCalculator periodically calculates statistical baselines for stocks
 type BaselineCalculator struct {
 	repo *database.TradeRepository
@@ -53,17 +69,8 @@ func (bc *BaselineCalculator) Stop() {
 func (bc *BaselineCalculator) calculateBaselines() {
 	log.Println("📊 Calculating statistical baselines (DB-optimized)...")
 
-	// Try multiple lookback periods to handle fresh deployments
-	lookbackPeriods := []struct {
-		duration  time.Duration
-		minutes   int
-		minTrades int
-	}{
-		{24 * time.Hour, 24 * 60, 2}, // Primary: 24 hours with 2 trades minimum (lowered from 3)
-		{2 * time.Hour, 2 * 60, 2},   // Fallback 1: 2 hours with 2 trades
-		{30 * time.Minute, 30, 1},    // Fallback 2: 30 minutes with 1 trade minimum
-		{15 * time.Minute, 15, 1},    // Fallback 3: 15 minutes with 1 trade minimum (new)
-	}
+	// Detect splits/corporate actions first so affected baselines are rebuilt from post-event data
+	bc.detectCorporateActions()
 
 	calculated := 0
 	// Track verified symbols to avoid overwriting good data with fallback data
@@ -72,7 +79,7 @@ func (bc *BaselineCalculator) calculateBaselines() {
 	// OPTIMIZATION: Collect all baselines for batch save
 	batchToSave := make([]models.StatisticalBaseline, 0, 100)
 
-	for _, period := range lookbackPeriods {
+	for _, period := range baselineLookbacks {
 		log.Printf("📊 Aggregating baselines for lookback %v...", period.duration)
 
 		// Calculate baselines directly in database
@@ -113,3 +120,46 @@ func (bc *BaselineCalculator) calculateBaselines() {
 
 	log.Printf("✅ Baseline calculation complete: %d symbols updated", calculated)
 }
+
+// RecomputeSymbol calculates and saves one symbol's baseline immediately, using the same lookback
+// fallbacks as the scheduled run. Returns nil without error when the symbol has too few recent candles.
+func (bc *BaselineCalculator) RecomputeSymbol(symbol string) (*models.StatisticalBaseline, error) {
+	for _, period := range baselineLookbacks {
+		baseline, err := bc.repo.CalculateSymbolBaselineDB(symbol, period.minutes, period.minTrades)
+		if err != nil {
+			return nil, err
+		}
+		if baseline == nil || baseline.MeanPrice <= 0 || baseline.SampleSize < period.minTrades {
+			continue
+		}
+
+		if err := bc.repo.SaveStatisticalBaseline(baseline); err != nil {
+			return nil, err
+		}
+		log.Printf("📊 Baseline recomputed for %s (lookback %v, %d samples)", symbol, period.duration, baseline.SampleSize)
+		return baseline, nil
+	}
+	return nil, nil
+}
+
+// detectCorporateActions records overnight price gaps that exceed the IDX daily limit
+// and invalidates the affected baselines so they recompute across the new price level
+func (bc *BaselineCalculator) detectCorporateActions() {
+	gaps, err := bc.repo.DetectOvernightGaps(splitGapThresholdPct)
+	if err != nil {
+		log.Printf("⚠️  Failed to detect overnight gaps: %v", err)
+		return
+	}
+
+	for i := range gaps {
+		gap := &gaps[i]
+		if err := bc.repo.SaveCorporateAction(gap); err != nil {
+			log.Printf("⚠️  Failed to record corporate action for %s: %v", gap.StockSymbol, err)
+			continue
+		}
+		if err := bc.repo.InvalidateBaselines(gap.StockSymbol); err != nil {
+			log.Printf("⚠️  Failed to invalidate baselines for %s: %v", gap.StockSymbol, err)
+		}
+		log.Printf("✂️ Corporate action detected for %s: %s - baseline reset", gap.StockSymbol, gap.Notes)
+	}
+}
@@ -0,0 +1,14 @@
+package app
+
+import "time"
+
+// Clock is the time source of the signal tracker and exit calculator, so session boundaries,
+// market-close exits and holding periods can be exercised at fixed times
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default Clock, backed by time.Now
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"stockbit-haka-haki/config"
+	"stockbit-haka-haki/database"
+	models "stockbit-haka-haki/database/models_pkg"
+)
+
+// fixedClock is a Clock stopped at a given time
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+// newLiveTracker builds a tracker on the default configuration with live-market session rules.
+// Reverse-signal exits are off because they look up SELL signals in the database.
+func newLiveTracker(t *testing.T) *SignalTracker {
+	t.Helper()
+	cfg := config.LoadFromEnv()
+	cfg.Trading.MockTradingMode = false
+	cfg.Trading.PaperTradingMode = false
+	cfg.Trading.ReverseSignalMinConfidence = 0
+	return NewSignalTracker(nil, nil, cfg, nil)
+}
+
+// markAt marks a fresh day-trade outcome entered at entry with the tracker clock set to now
+func markAt(t *testing.T, entry, now time.Time, price float64) *database.SignalOutcome {
+	t.Helper()
+	st := newLiveTracker(t)
+	st.SetClock(fixedClock{now: now})
+
+	signal := &database.TradingSignalDB{ID: 1, StockSymbol: "BBCA", Strategy: "VOLUME_BREAKOUT", Decision: "BUY"}
+	outcome := &database.SignalOutcome{
+		SignalID:      1,
+		StockSymbol:   "BBCA",
+		EntryTime:     entry,
+		EntryPrice:    1000,
+		EntryDecision: "BUY",
+		OutcomeStatus: "OPEN",
+	}
+	levels := &ExitLevels{InitialStopPct: 2, TrailingStopPct: 1.4, TakeProfit1Pct: 4, TakeProfit2Pct: 8}
+
+	st.markOutcome(signal, outcome, price, levels, nil, false)
+	return outcome
+}
+
+func exitReasonOf(outcome *database.SignalOutcome) string {
+	if outcome.ExitReason == nil {
+		return ""
+	}
+	return *outcome.ExitReason
+}
+
+func TestSessionExitsFollowClock(t *testing.T) {
+	entry := time.Date(2026, 3, 2, 14, 30, 0, 0, wib) // Monday, SESSION_2
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		price      float64
+		wantReason string
+	}{
+		{"last minute of session 2", time.Date(2026, 3, 2, 14, 49, 0, 0, wib), 1020, ""},
+		{"pre-closing in profit", time.Date(2026, 3, 2, 14, 50, 0, 0, wib), 1020, models.ExitReasonPreCloseProfitTaking},
+		{"pre-closing below 1% profit", time.Date(2026, 3, 2, 14, 50, 0, 0, wib), 1005, ""},
+		{"last minute before market close", time.Date(2026, 3, 2, 15, 59, 0, 0, wib), 1005, ""},
+		{"market close", time.Date(2026, 3, 2, 16, 0, 0, 0, wib), 1005, models.ExitReasonMarketClose},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := markAt(t, entry, tt.now, tt.price)
+
+			if got := exitReasonOf(outcome); got != tt.wantReason {
+				t.Errorf("exit reason = %q, want %q", got, tt.wantReason)
+			}
+			if wantOpen := tt.wantReason == ""; (outcome.OutcomeStatus == "OPEN") != wantOpen {
+				t.Errorf("status = %s, want open=%v", outcome.OutcomeStatus, wantOpen)
+			}
+			if tt.wantReason != "" && (outcome.ExitTime == nil || !outcome.ExitTime.Equal(tt.now)) {
+				t.Errorf("exit time = %v, want %v", outcome.ExitTime, tt.now)
+			}
+		})
+	}
+}
+
+func TestMaxHoldingExitFollowsClock(t *testing.T) {
+	entry := time.Date(2026, 3, 2, 9, 30, 0, 0, wib) // Default max holding is 240 minutes
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantReason string
+	}{
+		{"one minute before max holding", entry.Add(239 * time.Minute), ""},
+		{"at max holding", entry.Add(240 * time.Minute), models.ExitReasonMaxHoldingProfit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := markAt(t, entry, tt.now, 1005)
+
+			if got := exitReasonOf(outcome); got != tt.wantReason {
+				t.Errorf("exit reason = %q, want %q", got, tt.wantReason)
+			}
+			if outcome.HoldingPeriodMinutes == nil || *outcome.HoldingPeriodMinutes != int(tt.now.Sub(entry).Minutes()) {
+				t.Errorf("holding minutes = %v, want %d", outcome.HoldingPeriodMinutes, int(tt.now.Sub(entry).Minutes()))
+			}
+		})
+	}
+}
+
+func TestSetClockReachesFilters(t *testing.T) {
+	clock := fixedClock{now: time.Date(2026, 3, 2, 14, 50, 0, 0, wib)}
+	st := newLiveTracker(t)
+	st.SetClock(clock)
+
+	if st.filterService.clock != clock || st.exitCalc.clock != clock {
+		t.Fatal("filter service or exit calculator kept its own clock")
+	}
+	for _, filter := range st.filterService.filters {
+		var got Clock
+		switch f := filter.(type) {
+		case *StrategyPerformanceFilter:
+			got = f.clock
+		case *OrderFlowFilter:
+			got = f.clock
+		default:
+			continue
+		}
+		if got != clock {
+			t.Errorf("%s kept its own clock", filter.Name())
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
 	"time"
 
+	"stockbit-haka-haki/cache"
 	"stockbit-haka-haki/config"
 	"stockbit-haka-haki/database"
+	models "stockbit-haka-haki/database/models_pkg"
+	"stockbit-haka-haki/helpers"
 )
 
 // ATR Calculation Constants
@@ -19,48 +23,109 @@ const (
 	FallbackStopLossPct    = 2.0 // -2% default stop loss
 	FallbackTakeProfit1Pct = 4.0 // +4% default TP1
 	FallbackTakeProfit2Pct = 8.0 // +8% default TP2
+
+	// During the post-entry grace period only a loss this many times the initial stop exits
+	CatastrophicStopMultiple = 2.0
+
+	// Intraday take-profit bounds (% from entry), also applied after regime scaling
+	minTakeProfit1Pct, maxTakeProfit1Pct = 1.5, 12.0
+	minTakeProfit2Pct, maxTakeProfit2Pct = 3.0, 20.0
 )
 
 // ExitLevels contains calculated exit levels for a position
 type ExitLevels struct {
-	ATR              float64 // ATR value at calculation time
-	ATRPercent       float64 // ATR as percentage of price
-	InitialStopPct   float64 // Stop loss percentage (negative)
-	TrailingStopPct  float64 // Trailing stop offset percentage
-	TakeProfit1Pct   float64 // First take profit percentage
-	TakeProfit2Pct   float64 // Final take profit percentage
-	StopLossPrice    float64 // Absolute stop loss price
-	TakeProfit1Price float64 // Absolute TP1 price
-	TakeProfit2Price float64 // Absolute TP2 price
-	CalculatedAt     time.Time
+	ATR              float64   `json:"atr"`                 // ATR value at calculation time
+	ATRPercent       float64   `json:"atr_percent"`         // ATR as percentage of price
+	InitialStopPct   float64   `json:"initial_stop_pct"`    // Stop loss percentage (negative)
+	TrailingStopPct  float64   `json:"trailing_stop_pct"`   // Trailing stop offset percentage
+	TakeProfit1Pct   float64   `json:"take_profit_1_pct"`   // First take profit percentage
+	TakeProfit2Pct   float64   `json:"take_profit_2_pct"`   // Final take profit percentage
+	StopLossPrice    float64   `json:"stop_loss_price"`     // Absolute stop loss price
+	TakeProfit1Price float64   `json:"take_profit_1_price"` // Absolute TP1 price
+	TakeProfit2Price float64   `json:"take_profit_2_price"` // Absolute TP2 price
+	Regime           string    `json:"regime,omitempty"`    // Regime that scaled the take-profit targets, if any
+	CalculatedAt     time.Time `json:"calculated_at"`
 }
 
 // ExitStrategyCalculator calculates dynamic exit levels based on ATR
 type ExitStrategyCalculator struct {
-	repo *database.TradeRepository
-	cfg  *config.Config
+	repo  *database.TradeRepository
+	redis *cache.RedisClient
+	cfg   *config.Config
+	clock Clock
+}
+
+// atrSnapshot is the cached result of an intraday ATR calculation
+type atrSnapshot struct {
+	ATR        float64 `json:"atr"`
+	LastClose  float64 `json:"last_close"`
+	Sufficient bool    `json:"sufficient"` // false when there were not enough candles
 }
 
 // NewExitStrategyCalculator creates a new exit strategy calculator
-func NewExitStrategyCalculator(repo *database.TradeRepository, cfg *config.Config) *ExitStrategyCalculator {
+func NewExitStrategyCalculator(repo *database.TradeRepository, redis *cache.RedisClient, cfg *config.Config) *ExitStrategyCalculator {
 	return &ExitStrategyCalculator{
-		repo: repo,
-		cfg:  cfg,
+		repo:  repo,
+		redis: redis,
+		cfg:   cfg,
+		clock: realClock{},
 	}
 }
 
 // CalculateATR calculates the Average True Range for a symbol
 // Uses 5-minute candles for better intraday precision
 func (esc *ExitStrategyCalculator) CalculateATR(symbol string) (float64, error) {
+	snapshot, err := esc.getATRSnapshot(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if !snapshot.Sufficient {
+		// Not enough data, return 0 (will trigger fallback)
+		return 0, nil
+	}
+	return snapshot.ATR, nil
+}
+
+// getATRSnapshot returns the intraday ATR and latest close, cached per 5-minute bucket
+// The cache key rolls over when a new 5-minute candle opens, so ATR is only recomputed once per candle
+func (esc *ExitStrategyCalculator) getATRSnapshot(symbol string) (*atrSnapshot, error) {
+	ctx := context.Background()
+	bucket := esc.clock.Now().Truncate(5 * time.Minute)
+	cacheKey := fmt.Sprintf("atr:5min:%s:%d", symbol, bucket.Unix())
+
+	if esc.redis != nil {
+		var cached atrSnapshot
+		if err := esc.redis.Get(ctx, cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	snapshot, err := esc.computeATRSnapshot(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if esc.redis != nil {
+		_ = esc.redis.Set(ctx, cacheKey, snapshot, 5*time.Minute)
+	}
+	return snapshot, nil
+}
+
+// computeATRSnapshot calculates ATR from scratch using Wilder's smoothing
+func (esc *ExitStrategyCalculator) computeATRSnapshot(symbol string) (*atrSnapshot, error) {
 	// Get recent candles (need ATRPeriod + 1 for TR calculation)
 	candles, err := esc.repo.GetCandlesByTimeframe("5min", symbol, ATRPeriod+5)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	snapshot := &atrSnapshot{}
+	if len(candles) > 0 {
+		snapshot.LastClose = getFloat(candles[0], "close") // Candles are ordered newest first
 	}
 
 	if len(candles) < ATRPeriod+1 {
-		// Not enough data, return 0 (will trigger fallback)
-		return 0, nil
+		return snapshot, nil
 	}
 
 	// Calculate True Range for each candle
@@ -80,7 +145,7 @@ func (esc *ExitStrategyCalculator) CalculateATR(symbol string) (float64, error)
 	}
 
 	if len(trueRanges) < ATRPeriod {
-		return 0, nil
+		return snapshot, nil
 	}
 
 	// Calculate ATR using Wilder's smoothing (exponential)
@@ -97,37 +162,32 @@ func (esc *ExitStrategyCalculator) CalculateATR(symbol string) (float64, error)
 		atr = (atr*float64(ATRPeriod-1) + trueRanges[i]) / float64(ATRPeriod)
 	}
 
-	return atr, nil
+	snapshot.ATR = atr
+	snapshot.Sufficient = true
+	return snapshot, nil
 }
 
 // GetVolatilityPercent returns the current ATR as a percentage of price
 // Implements VolatilityProvider interface
 func (esc *ExitStrategyCalculator) GetVolatilityPercent(symbol string) (float64, error) {
-	// Optimization: This should ideally be cached
-	atr, err := esc.CalculateATR(symbol)
+	// Shares the cached ATR snapshot with CalculateATR
+	snapshot, err := esc.getATRSnapshot(symbol)
 	if err != nil {
 		return 0, err
 	}
 
-	// Get latest price to normalize ATR
-	candles, err := esc.repo.GetCandlesByTimeframe("5min", symbol, 1)
-	if err != nil || len(candles) == 0 {
+	if snapshot.LastClose == 0 {
 		return 0, fmt.Errorf("no price data for %s", symbol)
 	}
 
-	closePrice := getFloat(candles[0], "close")
-	if closePrice == 0 {
-		return 0, fmt.Errorf("zero price for %s", symbol)
-	}
-
-	return (atr / closePrice) * 100, nil
+	return (snapshot.ATR / snapshot.LastClose) * 100, nil
 }
 
 // GetExitLevels calculates exit levels for a given entry price and symbol
 // This is for DAY TRADING (intraday)
 func (esc *ExitStrategyCalculator) GetExitLevels(symbol string, entryPrice float64) *ExitLevels {
 	levels := &ExitLevels{
-		CalculatedAt: time.Now(),
+		CalculatedAt: esc.clock.Now(),
 	}
 
 	// Calculate ATR
@@ -156,14 +216,16 @@ func (esc *ExitStrategyCalculator) GetExitLevels(symbol string, entryPrice float
 		// Apply reasonable boundaries
 		levels.InitialStopPct = clamp(levels.InitialStopPct, 0.5, 5.0)   // 0.5% - 5% max
 		levels.TrailingStopPct = clamp(levels.TrailingStopPct, 0.5, 4.0) // 0.5% - 4% max
-		levels.TakeProfit1Pct = clamp(levels.TakeProfit1Pct, 1.5, 12.0)  // 1.5% - 12% max
-		levels.TakeProfit2Pct = clamp(levels.TakeProfit2Pct, 3.0, 20.0)  // 3% - 20% max
+		levels.TakeProfit1Pct = clamp(levels.TakeProfit1Pct, minTakeProfit1Pct, maxTakeProfit1Pct)
+		levels.TakeProfit2Pct = clamp(levels.TakeProfit2Pct, minTakeProfit2Pct, maxTakeProfit2Pct)
 	}
 
-	// Calculate absolute price levels
-	levels.StopLossPrice = entryPrice * (1 - levels.InitialStopPct/100)
-	levels.TakeProfit1Price = entryPrice * (1 + levels.TakeProfit1Pct/100)
-	levels.TakeProfit2Price = entryPrice * (1 + levels.TakeProfit2Pct/100)
+	esc.applyRegimeTakeProfit(symbol, levels)
+
+	// Calculate absolute price levels, on valid IDX ticks
+	levels.StopLossPrice = helpers.RoundToTick(entryPrice * (1 - levels.InitialStopPct/100))
+	levels.TakeProfit1Price = helpers.RoundToTick(entryPrice * (1 + levels.TakeProfit1Pct/100))
+	levels.TakeProfit2Price = helpers.RoundToTick(entryPrice * (1 + levels.TakeProfit2Pct/100))
 
 	log.Printf("📊 Exit levels for %s @ %.0f: SL=%.1f%% (%.0f), TP1=%.1f%% (%.0f), TP2=%.1f%% (%.0f), ATR=%.2f",
 		symbol, entryPrice,
@@ -175,6 +237,62 @@ func (esc *ExitStrategyCalculator) GetExitLevels(symbol string, entryPrice float
 	return levels
 }
 
+// applyRegimeTakeProfit scales the take-profit percentages by the multiplier configured for the
+// symbol's latest regime: wider targets let trend trades run, tighter ones bank range trades early.
+// The scaled targets stay within the intraday take-profit bounds.
+func (esc *ExitStrategyCalculator) applyRegimeTakeProfit(symbol string, levels *ExitLevels) {
+	if len(esc.cfg.Trading.TakeProfitRegimeMultipliers) == 0 || esc.repo == nil {
+		return
+	}
+	regime := esc.getLatestRegime(symbol)
+	if regime == "" {
+		return
+	}
+	multiplier, ok := esc.cfg.Trading.TakeProfitRegimeMultipliers[regime]
+	if !ok || multiplier <= 0 || multiplier == 1 {
+		return
+	}
+
+	levels.TakeProfit1Pct = clamp(levels.TakeProfit1Pct*multiplier, minTakeProfit1Pct, maxTakeProfit1Pct)
+	levels.TakeProfit2Pct = clamp(levels.TakeProfit2Pct*multiplier, minTakeProfit2Pct, maxTakeProfit2Pct)
+	levels.Regime = regime
+	log.Printf("🧭 %s regime %s: take-profit targets scaled %.2fx", symbol, regime, multiplier)
+}
+
+// regimeSnapshot is the cached latest regime of a symbol; Regime is empty when none is stored
+type regimeSnapshot struct {
+	Regime string `json:"regime"`
+}
+
+// getLatestRegime returns the symbol's latest regime, cached per 5-minute bucket like the ATR
+// snapshot so open positions don't query market_regimes on every update
+func (esc *ExitStrategyCalculator) getLatestRegime(symbol string) string {
+	ctx := context.Background()
+	bucket := esc.clock.Now().Truncate(5 * time.Minute)
+	cacheKey := fmt.Sprintf("regime:5min:%s:%d", symbol, bucket.Unix())
+
+	if esc.redis != nil {
+		var cached regimeSnapshot
+		if err := esc.redis.Get(ctx, cacheKey, &cached); err == nil {
+			return cached.Regime
+		}
+	}
+
+	regime, err := esc.repo.GetLatestRegime(symbol)
+	if err != nil {
+		return ""
+	}
+	var snapshot regimeSnapshot
+	if regime != nil {
+		snapshot.Regime = regime.Regime
+	}
+
+	if esc.redis != nil {
+		_ = esc.redis.Set(ctx, cacheKey, snapshot, 5*time.Minute)
+	}
+	return snapshot.Regime
+}
+
 // GetSwingExitLevels calculates exit levels for SWING TRADING (multi-day)
 // Uses daily candles and more lenient exit parameters
 func (esc *ExitStrategyCalculator) GetSwingExitLevels(symbol string, entryPrice float64) *ExitLevels {
@@ -184,7 +302,7 @@ func (esc *ExitStrategyCalculator) GetSwingExitLevels(symbol string, entryPrice
 	}
 
 	levels := &ExitLevels{
-		CalculatedAt: time.Now(),
+		CalculatedAt: esc.clock.Now(),
 	}
 
 	// Calculate ATR using daily candles for swing trading
@@ -223,10 +341,10 @@ func (esc *ExitStrategyCalculator) GetSwingExitLevels(symbol string, entryPrice
 		levels.TakeProfit2Pct = clamp(levels.TakeProfit2Pct, 15.0, 50.0) // 15% - 50%
 	}
 
-	// Calculate absolute price levels
-	levels.StopLossPrice = entryPrice * (1 - levels.InitialStopPct/100)
-	levels.TakeProfit1Price = entryPrice * (1 + levels.TakeProfit1Pct/100)
-	levels.TakeProfit2Price = entryPrice * (1 + levels.TakeProfit2Pct/100)
+	// Calculate absolute price levels, on valid IDX ticks
+	levels.StopLossPrice = helpers.RoundToTick(entryPrice * (1 - levels.InitialStopPct/100))
+	levels.TakeProfit1Price = helpers.RoundToTick(entryPrice * (1 + levels.TakeProfit1Pct/100))
+	levels.TakeProfit2Price = helpers.RoundToTick(entryPrice * (1 + levels.TakeProfit2Pct/100))
 
 	log.Printf("📊 SWING Exit levels for %s @ %.0f: SL=%.1f%% (%.0f), TP1=%.1f%% (%.0f), TP2=%.1f%% (%.0f), ATR=%.2f [SWING MODE]",
 		symbol, entryPrice,
@@ -292,7 +410,7 @@ func (esc *ExitStrategyCalculator) CalculateTrailingStop(
 	trailingStopPct float64,
 ) float64 {
 	// Calculate new trailing stop based on current price
-	newStopPrice := currentPrice * (1 - trailingStopPct/100)
+	newStopPrice := helpers.RoundToTick(currentPrice * (1 - trailingStopPct/100))
 
 	// Only move stop up, never down (for long positions)
 	if newStopPrice > currentStopPrice {
@@ -310,77 +428,104 @@ func (esc *ExitStrategyCalculator) ShouldExitPosition(
 	currentTrailingStop float64,
 	profitLossPct float64,
 	holdingMinutes int,
+	strategy string,
+	isSwing bool,
 ) (shouldExit bool, reason string, newTrailingStop float64) {
 	// Update trailing stop first
-	if profitLossPct > 0 {
+	// Trailing only starts once profit clears the activation threshold; below it the initial stop applies
+	newTrailingStop = currentTrailingStop
+	if profitLossPct > 0 && profitLossPct > esc.cfg.Trading.TrailActivationPct {
 		newTrailingStop = esc.CalculateTrailingStop(
 			entryPrice,
 			currentPrice,
 			currentTrailingStop,
 			levels.TrailingStopPct,
 		)
+	}
 
-		// 0. AUTO-BREAKEVEN CHECK - Using configurable thresholds
-		// If profit reaches trigger threshold, move Stop Loss to Entry Price + buffer
+	// 0. AUTO-BREAKEVEN CHECK - Using configurable thresholds
+	// If profit reaches trigger threshold, move Stop Loss to Entry Price + buffer
+	if profitLossPct > 0 {
 		breakevenTrigger := esc.cfg.Trading.BreakevenTriggerPct
 		breakevenBuffer := esc.cfg.Trading.BreakevenBufferPct
 
 		if profitLossPct >= breakevenTrigger {
-			breakevenPrice := entryPrice * (1 + breakevenBuffer/100)
+			breakevenPrice := helpers.RoundToTick(entryPrice * (1 + breakevenBuffer/100))
 			if newTrailingStop < breakevenPrice {
 				newTrailingStop = breakevenPrice
 				log.Printf("🛡️ Breakeven activated for position: P/L %.2f%% >= %.2f%%",
 					profitLossPct, breakevenTrigger)
 			}
 		}
-	} else {
-		newTrailingStop = currentTrailingStop
+	}
+
+	// Right after entry, stops below entry ignore entry-candle noise; a gap through the stop still exits
+	inStopGrace := holdingMinutes < esc.cfg.Trading.MinHoldBeforeStopMinutes
+	if inStopGrace && profitLossPct <= -levels.InitialStopPct*CatastrophicStopMultiple {
+		return true, models.ExitReasonCatastrophicStop, newTrailingStop
 	}
 
 	// 1. Check initial stop loss (hard stop)
-	if profitLossPct <= -levels.InitialStopPct {
-		return true, "ATR_STOP_LOSS", newTrailingStop
+	if !inStopGrace && profitLossPct <= -levels.InitialStopPct {
+		return true, models.ExitReasonATRStopLoss, newTrailingStop
 	}
 
-	// 2. Check trailing stop hit
-	if newTrailingStop > 0 && currentPrice <= newTrailingStop {
-		return true, "TRAILING_STOP_HIT", newTrailingStop
+	// 2. Check trailing stop hit (a stop already raised to breakeven or above applies during the grace period)
+	if newTrailingStop > 0 && currentPrice <= newTrailingStop && (!inStopGrace || newTrailingStop >= entryPrice) {
+		return true, models.ExitReasonTrailingStopHit, newTrailingStop
 	}
 
 	// 3. Check Take Profit 2 (full exit)
 	if profitLossPct >= levels.TakeProfit2Pct {
-		return true, "TAKE_PROFIT_FULL", newTrailingStop
+		return true, models.ExitReasonTakeProfitFull, newTrailingStop
 	}
 
 	// 4. Check Take Profit 1 with time consideration
 	// If we hit TP1 and have been holding for > 60 mins, consider exit
 	if profitLossPct >= levels.TakeProfit1Pct && holdingMinutes > 60 {
-		return true, "TAKE_PROFIT_TIME_BASED", newTrailingStop
+		return true, models.ExitReasonTakeProfitTimeBased, newTrailingStop
 	}
 
-	// 5. Maximum holding period (4 hours) - exit even with small profit
-	if holdingMinutes >= 240 {
+	// 5. Maximum holding period (per strategy) - exit even with small profit
+	maxHolding := esc.maxHoldingMinutes(strategy, isSwing)
+	if holdingMinutes >= maxHolding {
 		if profitLossPct > 0.15 { // Reduced from 0.2 for faster turnover
-			return true, "MAX_HOLDING_PROFIT", newTrailingStop
+			return true, models.ExitReasonMaxHoldingProfit, newTrailingStop
 		} else if profitLossPct > -0.5 {
 			// Small profit or near breakeven - exit to free up capital
-			return true, "MAX_HOLDING_SMALL_PROFIT", newTrailingStop
+			return true, models.ExitReasonMaxHoldingSmallProfit, newTrailingStop
 		}
 		// If in loss, let stop loss handle it
 	}
 
 	// 6. Time-decay profit taking - reduce profit target as time passes
-	if holdingMinutes > 120 && holdingMinutes < 240 { // 2-4 hours
-		// Gradually reduce TP1 requirement by 20% per hour after 2 hours
-		adjustedTP1 := levels.TakeProfit1Pct * (1.0 - float64(holdingMinutes-120)/120.0*0.4)
+	// Window runs from half the max holding period up to the max
+	decayStart := maxHolding / 2
+	if holdingMinutes > decayStart && holdingMinutes < maxHolding {
+		// Gradually reduce TP1 requirement by up to 40% across the window
+		adjustedTP1 := levels.TakeProfit1Pct * (1.0 - float64(holdingMinutes-decayStart)/float64(maxHolding-decayStart)*0.4)
 		if profitLossPct >= adjustedTP1 && adjustedTP1 > 1.0 {
-			return true, "TIME_DECAY_PROFIT", newTrailingStop
+			return true, models.ExitReasonTimeDecayProfit, newTrailingStop
 		}
 	}
 
 	return false, "", newTrailingStop
 }
 
+// maxHoldingMinutes resolves the max holding period for a position
+// Swing positions always use a day-scale limit derived from SwingMaxHoldingDays
+func (esc *ExitStrategyCalculator) maxHoldingMinutes(strategy string, isSwing bool) int {
+	if isSwing {
+		return esc.cfg.Trading.SwingMaxHoldingDays * 24 * 60 // At least one day, enforced at config load
+	}
+
+	minutes := esc.cfg.Trading.MaxHoldingMinutesFor(strategy)
+	if minutes <= 0 {
+		minutes = 240
+	}
+	return minutes
+}
+
 // Helper function to clamp value between min and max
 func clamp(value, min, max float64) float64 {
 	if value < min {
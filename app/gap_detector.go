@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"stockbit-haka-haki/config"
+	"stockbit-haka-haki/database"
+	models "stockbit-haka-haki/database/models_pkg"
+	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/realtime"
+)
+
+// gapCheckInterval is how often opening gaps are re-checked while the window is open
+const gapCheckInterval = 1 * time.Minute
+
+// GapDetector emits GAP_UP/GAP_DOWN patterns when a symbol opens far from its previous close
+// and the opening trade confirms it with volume
+type GapDetector struct {
+	repo   *database.TradeRepository
+	broker *realtime.Broker
+	cfg    *config.Config
+	done   chan bool
+
+	// Symbols already announced today (symbol -> WIB date), so each gap is emitted once
+	announced map[string]string
+}
+
+// NewGapDetector creates a new opening gap detector
+func NewGapDetector(repo *database.TradeRepository, broker *realtime.Broker, cfg *config.Config) *GapDetector {
+	return &GapDetector{
+		repo:      repo,
+		broker:    broker,
+		cfg:       cfg,
+		done:      make(chan bool),
+		announced: make(map[string]string),
+	}
+}
+
+// Start begins the detection loop
+func (gd *GapDetector) Start() {
+	log.Printf("🕳️ Gap Detector started (min gap %.1f%%, min volume %.0f%% of avg, first %d min of SESSION_1)",
+		gd.cfg.GapMinPct, gd.cfg.GapMinVolumePct, gd.cfg.GapWindowMinutes)
+
+	ticker := time.NewTicker(gapCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			gd.detect(time.Now())
+		case <-gd.done:
+			log.Println("🕳️ Gap Detector stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the detection loop
+func (gd *GapDetector) Stop() {
+	gd.done <- true
+}
+
+// detect checks for opening gaps while within the window after the SESSION_1 open
+func (gd *GapDetector) detect(now time.Time) {
+	local := now.In(marketLocation())
+	if weekday := local.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return
+	}
+	open, ok := gd.cfg.Sessions.SessionStart(local, "SESSION_1")
+	if !ok || local.Before(open) || local.Sub(open) > time.Duration(gd.cfg.GapWindowMinutes)*time.Minute {
+		return
+	}
+
+	day := local.Format("2006-01-02")
+	for symbol, announcedOn := range gd.announced {
+		if announcedOn != day {
+			delete(gd.announced, symbol)
+		}
+	}
+
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	gaps, err := gd.repo.GetOpeningGaps(dayStart, gd.cfg.GapMinPct)
+	if err != nil {
+		log.Printf("⚠️  Gap detection failed: %v", err)
+		return
+	}
+
+	for _, gap := range gaps {
+		if _, seen := gd.announced[gap.StockSymbol]; seen {
+			continue
+		}
+		// Without a volume history there is nothing to confirm against
+		if gap.AvgDailyVolume <= 0 {
+			continue
+		}
+		volumePct := gap.VolumeLots / gap.AvgDailyVolume * 100
+		if volumePct < gd.cfg.GapMinVolumePct {
+			continue // May still confirm later in the window
+		}
+
+		pattern := gd.newGapPattern(gap, volumePct, now)
+		if err := gd.repo.SaveDetectedPattern(pattern); err != nil {
+			log.Printf("⚠️  Failed to save %s pattern for %s: %v", pattern.PatternType, gap.StockSymbol, err)
+			continue
+		}
+		gd.announced[gap.StockSymbol] = day
+
+		log.Printf("🕳️ %s %s %+.2f%% | open %.0f vs prev close %.0f | volume %.0f%% of avg day",
+			pattern.PatternType, gap.StockSymbol, gap.GapPct, gap.Open, gap.PrevClose, volumePct)
+
+		if gd.broker != nil {
+			gd.broker.Broadcast("gap_pattern", pattern)
+		}
+	}
+}
+
+// newGapPattern builds the pattern: direction follows the gap, the previous close is the
+// gap-fill level, and confidence grows with the gap size and opening volume
+func (gd *GapDetector) newGapPattern(gap types.OpeningGap, volumePct float64, now time.Time) *models.DetectedPattern {
+	patternType, direction := "GAP_UP", "BUY"
+	if gap.GapPct < 0 {
+		patternType, direction = "GAP_DOWN", "SELL"
+	}
+
+	gapScore := math.Min(math.Abs(gap.GapPct)/(gd.cfg.GapMinPct*3), 1.0)
+	volumeScore := math.Min(volumePct/(gd.cfg.GapMinVolumePct*4), 1.0)
+	confidence := math.Min(0.5+gapScore*0.3+volumeScore*0.2, 0.9999) // decimal(5,4)
+
+	priceRange := math.Abs(gap.Open - gap.PrevClose)
+	fillLevel := gap.PrevClose
+	profile := fmt.Sprintf("%.0f lots since open (%.0f%% of 20d avg day)", gap.VolumeLots, volumePct)
+
+	return &models.DetectedPattern{
+		StockSymbol:      gap.StockSymbol,
+		DetectedAt:       now,
+		PatternType:      patternType,
+		PatternDirection: &direction,
+		Confidence:       confidence,
+		PriceRange:       &priceRange,
+		VolumeProfile:    &profile,
+		BreakoutLevel:    &fillLevel,
+	}
+}
@@ -9,24 +9,29 @@ import (
 
 // PerformanceRefresher periodically refreshes the performance materialized view
 type PerformanceRefresher struct {
-	repo *database.TradeRepository
-	done chan bool
+	repo     *database.TradeRepository
+	interval time.Duration
+	done     chan bool
 }
 
-// NewPerformanceRefresher creates a new performance refresher
-func NewPerformanceRefresher(repo *database.TradeRepository) *PerformanceRefresher {
+// NewPerformanceRefresher creates a new performance refresher running every interval (default 5 minutes)
+func NewPerformanceRefresher(repo *database.TradeRepository, interval time.Duration) *PerformanceRefresher {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
 	return &PerformanceRefresher{
-		repo: repo,
-		done: make(chan bool),
+		repo:     repo,
+		interval: interval,
+		done:     make(chan bool),
 	}
 }
 
 // Start begins the refresh loop
 func (pr *PerformanceRefresher) Start() {
-	log.Println("🔄 Performance Refresher started")
+	log.Printf("🔄 Performance Refresher started (every %v)", pr.interval)
 
-	// Run every 5 minutes to keep performance data fresh
-	ticker := time.NewTicker(5 * time.Minute)
+	// API reads only see the view as of the last refresh
+	ticker := time.NewTicker(pr.interval)
 	defer ticker.Stop()
 
 	// Initial run
@@ -48,16 +53,18 @@ func (pr *PerformanceRefresher) Stop() {
 	pr.done <- true
 }
 
-// refreshView refreshes the materialized view
+// refreshView refreshes the performance and regime effectiveness materialized views
 func (pr *PerformanceRefresher) refreshView() {
 	log.Println("🔄 Refreshing strategy_performance_daily materialized view...")
 
-	// Use CONCURRENTLY to avoid blocking reads
-	_, err := pr.repo.GetDailyStrategyPerformance("", "", 1)
-	if err != nil {
+	if err := pr.repo.RefreshStrategyPerformanceDaily(); err != nil {
 		log.Printf("⚠️ Failed to refresh performance view: %v", err)
 		return
 	}
 
 	log.Println("✅ Performance view refreshed successfully")
+
+	if err := pr.repo.RefreshStrategyRegimeEffectiveness(); err != nil {
+		log.Printf("⚠️ Failed to refresh regime effectiveness view: %v", err)
+	}
 }
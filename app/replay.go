@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"stockbit-haka-haki/handlers"
+	"stockbit-haka-haki/notifications"
+)
+
+// webhookDrainDelay gives async webhook deliveries time to finish before a replay exits
+const webhookDrainDelay = 10 * time.Second
+
+// Replay imports a historical trade CSV without connecting to the live feed.
+// Whale alerts are only sent to webhooks when notify is set; there is no SSE broker in this mode.
+func (a *App) Replay(path string, opts handlers.ReplayOptions, notify bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open replay file: %w", err)
+	}
+	defer file.Close()
+
+	if err := a.initStorage(); err != nil {
+		return err
+	}
+	defer a.db.Close()
+	if a.redis != nil {
+		defer a.redis.Close()
+	}
+
+	if notify {
+		a.webhookManager = notifications.NewWebhookManager(a.tradeRepo, a.redis, a.config.WebhookMinConfidence)
+	}
+	a.symbolFilter = NewSymbolFilterService(a.tradeRepo, a.config)
+
+	volatilityProv := NewExitStrategyCalculator(a.tradeRepo, a.redis, a.config)
+	handler := handlers.NewRunningTradeHandler(a.tradeRepo, a.webhookManager, a.redis, nil, volatilityProv, a.symbolFilter, handlers.ProcessingOptions{
+		WorkerPoolSize:  1, // Replay runs detection inline; the live workers stay idle
+		IncludeOddLots:  a.config.TradeIncludeOddLots,
+		StatsLookback:   a.config.WhaleStatsLookbackMinutes,
+		StatsMinSamples: a.config.WhaleStatsMinSamples,
+	})
+	defer handler.Close()
+
+	log.Printf("📼 Replaying trades from %s (detection only: %v, webhooks: %v)", path, opts.DetectionOnly, notify)
+	start := time.Now()
+
+	result, err := handler.ReplayCSV(file, opts)
+	if err != nil {
+		return fmt.Errorf("replay failed: %w", err)
+	}
+
+	log.Printf("✅ Replay done in %v | rows %d (skipped %d, already imported %d) | imported %d | whale alerts %d | %s → %s",
+		time.Since(start).Round(time.Second), result.Rows, result.Skipped, result.Duplicates, result.Imported, result.Alerts,
+		result.From.In(marketLocation()).Format("2006-01-02 15:04"), result.To.In(marketLocation()).Format("2006-01-02 15:04"))
+
+	if notify && result.Alerts > 0 {
+		time.Sleep(webhookDrainDelay)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"stockbit-haka-haki/database"
+	"stockbit-haka-haki/helpers"
+	"stockbit-haka-haki/realtime"
+)
+
+// Sector sweep detection settings
+const (
+	sectorSweepInterval       = 1 * time.Minute
+	sectorSweepWindow         = 15 * time.Minute // Whale BUY alerts considered "simultaneous"
+	sectorSweepMinSymbols     = 3
+	sectorSweepMinCorrelation = 0.6
+)
+
+// SectorSweepDetector emits SECTOR_SWEEP meta-alerts when whales accumulate several correlated symbols at once
+type SectorSweepDetector struct {
+	repo   *database.TradeRepository
+	broker *realtime.Broker
+	done   chan bool
+
+	// Clusters already broadcast (sorted symbols joined), so each sweep is announced once per window
+	announced map[string]time.Time
+}
+
+// NewSectorSweepDetector creates a new sector sweep detector
+func NewSectorSweepDetector(repo *database.TradeRepository, broker *realtime.Broker) *SectorSweepDetector {
+	return &SectorSweepDetector{
+		repo:      repo,
+		broker:    broker,
+		done:      make(chan bool),
+		announced: make(map[string]time.Time),
+	}
+}
+
+// Start begins the detection loop
+func (sd *SectorSweepDetector) Start() {
+	log.Println("🌊 Sector Sweep Detector started")
+
+	ticker := time.NewTicker(sectorSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sd.detect()
+		case <-sd.done:
+			log.Println("🌊 Sector Sweep Detector stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the detection loop
+func (sd *SectorSweepDetector) Stop() {
+	sd.done <- true
+}
+
+// detect looks for correlated clusters of whale buying and broadcasts new ones
+func (sd *SectorSweepDetector) detect() {
+	now := time.Now()
+	sweeps, err := sd.repo.GetSectorSweeps(now.Add(-sectorSweepWindow), sectorSweepMinSymbols, sectorSweepMinCorrelation)
+	if err != nil {
+		log.Printf("⚠️  Sector sweep detection failed: %v", err)
+		return
+	}
+
+	for key, at := range sd.announced {
+		if now.Sub(at) > sectorSweepWindow {
+			delete(sd.announced, key)
+		}
+	}
+
+	for _, sweep := range sweeps {
+		key := strings.Join(sweep.Symbols, ",")
+		if _, seen := sd.announced[key]; seen {
+			continue
+		}
+		sd.announced[key] = now
+
+		log.Printf("🌊 SECTOR_SWEEP! %s | %d whale buys, %s, avg corr %.2f",
+			key, sweep.AlertCount, helpers.FormatRupiah(sweep.TotalValue), sweep.AvgCorrelation)
+
+		if sd.broker != nil {
+			sd.broker.Broadcast("sector_sweep", sweep)
+		}
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"strings"
 	"time"
 
 	"stockbit-haka-haki/cache"
 	"stockbit-haka-haki/config"
 	"stockbit-haka-haki/database"
 	models "stockbit-haka-haki/database/models_pkg"
+	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/helpers"
 )
 
 // SignalFilter is an interface for individual signal filtering logic
@@ -19,12 +22,18 @@ type SignalFilter interface {
 	Evaluate(ctx context.Context, signal *database.TradingSignalDB) (shouldPass bool, reason string, multiplier float64)
 }
 
+// clockedFilter is implemented by filters whose verdict depends on the current time
+type clockedFilter interface {
+	setClock(clock Clock)
+}
+
 // SignalFilterService handles the complex decision logic using a pipeline of filters
 type SignalFilterService struct {
 	repo    *database.TradeRepository
 	redis   *cache.RedisClient
 	cfg     *config.Config
 	filters []SignalFilter
+	clock   Clock
 }
 
 // NewSignalFilterService creates a new signal filter service
@@ -33,17 +42,61 @@ func NewSignalFilterService(repo *database.TradeRepository, redis *cache.RedisCl
 		repo:  repo,
 		redis: redis,
 		cfg:   cfg,
+		clock: realClock{},
+	}
+
+	// Register filters in order, keyed for TRADING_FILTER_TOGGLES
+	registered := []struct {
+		key    string
+		filter SignalFilter
+	}{
+		{"strategy_performance", &StrategyPerformanceFilter{repo: repo, redis: redis, cfg: cfg, clock: realClock{}}},
+		{"dynamic_confidence", &DynamicConfidenceFilter{repo: repo, redis: redis, cfg: cfg}},
+		{"multi_timeframe", &MultiTimeframeFilter{repo: repo, cfg: cfg}},
+		{"order_flow", &OrderFlowFilter{repo: repo, cfg: cfg, clock: realClock{}}},
+		{"regime_effectiveness", &RegimeEffectivenessFilter{repo: repo, redis: redis, cfg: cfg}},
+		{"liquidity", &LiquidityFilter{repo: repo, redis: redis, cfg: cfg}},
+		{"followup_reliability", &FollowupReliabilityFilter{repo: repo, redis: redis, cfg: cfg}},
+		{"opening_volatility", &OpeningVolatilityFilter{repo: repo, redis: redis, cfg: cfg}},
+		{"spread", &SpreadFilter{redis: redis, cfg: cfg}},
+	}
+
+	toggles := cfg.Trading.FilterToggles
+	known := make(map[string]bool, len(registered))
+	var active, disabled []string
+	for _, r := range registered {
+		known[r.key] = true
+		if enabled, ok := toggles[r.key]; ok && !enabled {
+			disabled = append(disabled, r.key)
+			continue
+		}
+		service.filters = append(service.filters, r.filter)
+		active = append(active, r.key)
+	}
+	for key := range toggles {
+		if !known[key] {
+			log.Printf("⚠️ Unknown filter %q in TRADING_FILTER_TOGGLES (ignored)", key)
+		}
 	}
 
-	// Register filters in order
-	service.filters = []SignalFilter{
-		&StrategyPerformanceFilter{repo: repo, redis: redis, cfg: cfg},
-		&DynamicConfidenceFilter{repo: repo, redis: redis, cfg: cfg},
+	log.Printf("🧪 Signal filters active: %s", strings.Join(active, ", "))
+	if len(disabled) > 0 {
+		log.Printf("🧪 Signal filters disabled: %s", strings.Join(disabled, ", "))
 	}
 
 	return service
 }
 
+// SetClock replaces the time source of the service and of every time-dependent filter
+func (s *SignalFilterService) SetClock(clock Clock) {
+	s.clock = clock
+	for _, filter := range s.filters {
+		if clocked, ok := filter.(clockedFilter); ok {
+			clocked.setClock(clock)
+		}
+	}
+}
+
 // Evaluate determines if a signal should be traded by running it through the filter pipeline
 // Also determines if signal is suitable for swing trading
 func (s *SignalFilterService) Evaluate(signal *database.TradingSignalDB) (bool, string, float64) {
@@ -75,6 +128,40 @@ func (s *SignalFilterService) Evaluate(signal *database.TradingSignalDB) (bool,
 	return true, "", overallMultiplier
 }
 
+// Scorecard runs every filter without short-circuiting and records each component's verdict
+func (s *SignalFilterService) Scorecard(signal *database.TradingSignalDB) *types.SignalScorecard {
+	ctx := context.Background()
+	card := &types.SignalScorecard{
+		SignalID:    signal.ID,
+		Multiplier:  1.0,
+		Passed:      true,
+		EvaluatedAt: s.clock.Now(),
+	}
+
+	for _, filter := range s.filters {
+		passed, reason, multiplier := filter.Evaluate(ctx, signal)
+		card.Components = append(card.Components, types.ScorecardComponent{
+			Name:       filter.Name(),
+			Passed:     passed,
+			Reason:     reason,
+			Multiplier: multiplier,
+		})
+
+		if !passed {
+			card.Passed = false
+			continue
+		}
+		if multiplier != 0.0 {
+			card.Multiplier *= multiplier
+		}
+	}
+
+	if !card.Passed {
+		card.Multiplier = 0.0
+	}
+	return card
+}
+
 // GetRegimeAdaptiveLimit returns max positions based on market regime
 // Kept as a separate public method for external usage
 func (s *SignalFilterService) GetRegimeAdaptiveLimit(symbol string) int {
@@ -90,10 +177,13 @@ type StrategyPerformanceFilter struct {
 	repo  *database.TradeRepository
 	redis *cache.RedisClient
 	cfg   *config.Config
+	clock Clock
 }
 
 func (f *StrategyPerformanceFilter) Name() string { return "Strategy & Baseline Performance" }
 
+func (f *StrategyPerformanceFilter) setClock(clock Clock) { f.clock = clock }
+
 func (f *StrategyPerformanceFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
 	strategy := signal.Strategy
 
@@ -158,7 +248,8 @@ func (f *StrategyPerformanceFilter) calculate(strategy string, symbol string) (f
 	}
 
 	// Check baseline recency (must be calculated within last 2 hours)
-	if time.Since(baseline.CalculatedAt) > 2*time.Hour {
+	now := f.clock.Now()
+	if now.Sub(baseline.CalculatedAt) > 2*time.Hour {
 		baselineMultiplier *= 0.9
 		if baselineReason != "" {
 			baselineReason += "; Stale baseline (>2h old)"
@@ -168,15 +259,22 @@ func (f *StrategyPerformanceFilter) calculate(strategy string, symbol string) (f
 	}
 
 	// Get strategy performance data
-	outcomes, err := f.repo.GetSignalOutcomes(symbol, "", time.Now().Add(-24*time.Hour), time.Time{}, 0, 0)
+	outcomes, err := f.repo.GetSignalOutcomes(symbol, "", now.Add(-24*time.Hour), time.Time{}, 0, 0)
+	if err != nil {
+		return baselineMultiplier, baselineReason
+	}
+
+	// Batch fetch signals for both outcome sets to avoid N+1 queries
+	recentOutcomes, _ := f.repo.GetSignalOutcomes("", "", now.Add(-24*time.Hour), time.Time{}, 20, 0)
+	signalsMap, err := f.repo.GetSignalsByIDs(outcomeSignalIDs(append(outcomes, recentOutcomes...)))
 	if err != nil {
 		return baselineMultiplier, baselineReason
 	}
 
 	var totalSignals, wins int
 	for _, outcome := range outcomes {
-		signal, err := f.repo.GetSignalByID(outcome.SignalID)
-		if err == nil && signal != nil && signal.Strategy == strategy {
+		signal := signalsMap[outcome.SignalID]
+		if signal != nil && signal.Strategy == strategy {
 			if outcome.OutcomeStatus == "WIN" || outcome.OutcomeStatus == "LOSS" || outcome.OutcomeStatus == "BREAKEVEN" {
 				totalSignals++
 				if outcome.OutcomeStatus == "WIN" {
@@ -199,11 +297,10 @@ func (f *StrategyPerformanceFilter) calculate(strategy string, symbol string) (f
 	}
 
 	// Check for consecutive losses (circuit breaker logic)
-	recentOutcomes, _ := f.repo.GetSignalOutcomes("", "", time.Now().Add(-24*time.Hour), time.Time{}, 20, 0)
 	consecutiveLosses := 0
 	for _, outcome := range recentOutcomes {
-		signal, err := f.repo.GetSignalByID(outcome.SignalID)
-		if err == nil && signal != nil && signal.Strategy == strategy {
+		signal := signalsMap[outcome.SignalID]
+		if signal != nil && signal.Strategy == strategy {
 			if outcome.OutcomeStatus == "LOSS" {
 				consecutiveLosses++
 			} else if outcome.OutcomeStatus == "WIN" {
@@ -345,6 +442,388 @@ func (f *DynamicConfidenceFilter) getOptimalThreshold(ctx context.Context, strat
 	return optThreshold, reason
 }
 
+// 3. Multi-Timeframe Confirmation Filter
+// Requires higher-timeframe trends to agree with a BUY before a position is opened
+type MultiTimeframeFilter struct {
+	repo *database.TradeRepository
+	cfg  *config.Config
+}
+
+// mtfTrendPeriod is the SMA length used to classify a timeframe's trend
+const mtfTrendPeriod = 20
+
+func (f *MultiTimeframeFilter) Name() string { return "Multi-Timeframe Confirmation" }
+
+func (f *MultiTimeframeFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
+	required := f.cfg.Trading.MTFMinAlignedFor(signal.Strategy)
+	if required <= 0 || signal.Decision != "BUY" {
+		return true, "", 1.0
+	}
+
+	aligned, known := 0, 0
+	verdicts := make([]string, 0, len(f.cfg.Trading.MTFTimeframes))
+	for _, tf := range f.cfg.Trading.MTFTimeframes {
+		trend := f.trend(signal.StockSymbol, tf)
+		verdicts = append(verdicts, fmt.Sprintf("%s:%s", tf, trend))
+		if trend == "UNKNOWN" {
+			continue
+		}
+		known++
+		if trend == "UP" {
+			aligned++
+		}
+	}
+
+	// Don't block symbols that lack history on a timeframe; require only what can be measured
+	if known == 0 {
+		return true, fmt.Sprintf("MTF skipped, insufficient candles (%s)", strings.Join(verdicts, " ")), 1.0
+	}
+	if required > known {
+		required = known
+	}
+
+	verdict := fmt.Sprintf("MTF %d/%d aligned (%s)", aligned, required, strings.Join(verdicts, " "))
+	if aligned < required {
+		return false, "MTF not aligned: " + verdict, 0.0
+	}
+	return true, verdict, 1.0
+}
+
+// trend classifies a timeframe as UP, DOWN or UNKNOWN by the latest close vs its SMA
+func (f *MultiTimeframeFilter) trend(symbol, timeframe string) string {
+	candles, err := f.repo.GetCandlesByTimeframe(timeframe, symbol, mtfTrendPeriod)
+	if err != nil || len(candles) < mtfTrendPeriod {
+		return "UNKNOWN"
+	}
+
+	sum := 0.0
+	for _, c := range candles {
+		sum += getFloat(c, "close")
+	}
+	sma := sum / float64(len(candles))
+
+	// Candles are ordered newest first
+	if getFloat(candles[0], "close") > sma {
+		return "UP"
+	}
+	return "DOWN"
+}
+
+// 4. Order Flow Trend Filter
+// Sustained delta over several minutes is more predictive than a single snapshot
+type OrderFlowFilter struct {
+	repo  *database.TradeRepository
+	cfg   *config.Config
+	clock Clock
+}
+
+func (f *OrderFlowFilter) setClock(clock Clock) { f.clock = clock }
+
+// orderFlowTrendMinutes is the lookback used to judge delta volume direction
+const orderFlowTrendMinutes = 15
+
+func (f *OrderFlowFilter) Name() string { return "Order Flow Trend" }
+
+func (f *OrderFlowFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
+	if signal.Decision != "BUY" {
+		return true, "", 1.0
+	}
+
+	// A stalled aggregator must not let an old favorable reading approve the entry
+	latest, err := f.repo.GetLatestOrderFlow(signal.StockSymbol)
+	if err != nil || latest == nil {
+		if f.cfg.Trading.RequireOrderFlow {
+			return false, "No order flow data", 0.0
+		}
+		return true, "", 1.0
+	}
+	age := f.clock.Now().Sub(latest.Bucket)
+	maxAge := time.Duration(f.cfg.Trading.OrderFlowMaxAgeMinutes) * time.Minute
+	if age > maxAge {
+		reason := fmt.Sprintf("Stale order flow (age %s > %s)", age.Truncate(time.Second), maxAge)
+		if f.cfg.Trading.RequireOrderFlow {
+			return false, reason, 0.0
+		}
+		return true, reason + ", ignored", 1.0
+	}
+
+	trend, err := f.repo.GetOrderFlowTrend(signal.StockSymbol, orderFlowTrendMinutes)
+	if err != nil || trend == nil {
+		return true, "", 1.0
+	}
+
+	summary := fmt.Sprintf("%dm delta %s (cum %.0f, prior %.0f → recent %.0f lots, age %s)",
+		trend.Minutes, trend.Direction, trend.CumulativeDelta, trend.PriorDelta, trend.RecentDelta, age.Truncate(time.Second))
+
+	switch trend.Direction {
+	case "RISING":
+		return true, summary, 1.1
+	case "FALLING":
+		return true, "Deteriorating order flow: " + summary, 0.7
+	case "MIXED":
+		// Net buying that is fading, or selling that is easing
+		if trend.RecentDelta < trend.PriorDelta {
+			return true, "Fading order flow: " + summary, 0.85
+		}
+		return true, summary, 1.0
+	}
+	return true, "", 1.0
+}
+
+// 5. Regime Effectiveness Filter
+// Blocks strategies that historically lose in the symbol's current market regime
+type RegimeEffectivenessFilter struct {
+	repo  *database.TradeRepository
+	redis *cache.RedisClient
+	cfg   *config.Config
+}
+
+// A strategy whose win rate (%) in the current regime is below regimeMinWinRate trades at
+// regimeWeakMultiplier of normal size rather than being blocked outright
+const (
+	regimeMinWinRate     = 40.0
+	regimeWeakMultiplier = 0.5
+)
+
+func (f *RegimeEffectivenessFilter) Name() string { return "Regime Effectiveness" }
+
+func (f *RegimeEffectivenessFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
+	regime, err := f.repo.GetLatestRegime(signal.StockSymbol)
+	if err != nil || regime == nil {
+		return true, "", 1.0
+	}
+
+	winRate, reason := f.checkRegimeEffectiveness(ctx, signal.Strategy, regime.Regime)
+	if winRate < regimeMinWinRate {
+		return true, reason, regimeWeakMultiplier
+	}
+	return true, reason, 1.0
+}
+
+// checkRegimeEffectiveness returns the strategy's win rate in a regime, read from the
+// pre-aggregated strategy_regime_effectiveness view. Without history, or with fewer than
+// RegimeMinSamples closed trades, it reports a neutral 50% so thin data can't shrink a position.
+func (f *RegimeEffectivenessFilter) checkRegimeEffectiveness(ctx context.Context, strategy, regime string) (float64, string) {
+	cacheKey := fmt.Sprintf("strategy:regime:%s:%s", strategy, regime)
+	type CachedRegime struct {
+		WinRate float64
+		Reason  string
+	}
+	if f.redis != nil {
+		var cached CachedRegime
+		if err := f.redis.Get(ctx, cacheKey, &cached); err == nil {
+			return cached.WinRate, cached.Reason
+		}
+	}
+
+	winRate := 50.0
+	reason := fmt.Sprintf("No %s history in %s regime", strategy, regime)
+	eff, err := f.repo.GetStrategyEffectivenessByRegime(strategy, regime)
+	if err != nil {
+		log.Printf("⚠️ Regime effectiveness lookup failed for %s/%s: %v", strategy, regime, err)
+	} else if eff != nil && eff.TotalSignals < int64(f.cfg.Trading.RegimeMinSamples) {
+		reason = fmt.Sprintf("%s in %s regime: WR %.1f%% ignored, only %d/%d samples",
+			strategy, regime, eff.WinRate, eff.TotalSignals, f.cfg.Trading.RegimeMinSamples)
+	} else if eff != nil {
+		winRate = eff.WinRate
+		reason = fmt.Sprintf("%s in %s regime: WR %.1f%% (%d samples)", strategy, regime, winRate, eff.TotalSignals)
+		if winRate < regimeMinWinRate {
+			reason = fmt.Sprintf("Weak in regime: %s (< %.0f%%)", reason, regimeMinWinRate)
+		}
+	}
+
+	if f.redis != nil {
+		_ = f.redis.Set(ctx, cacheKey, CachedRegime{WinRate: winRate, Reason: reason}, 10*time.Minute)
+	}
+	return winRate, reason
+}
+
+// 6. Liquidity Filter
+// Rejects symbols whose average daily traded value is too thin to enter and exit cleanly
+type LiquidityFilter struct {
+	repo  *database.TradeRepository
+	redis *cache.RedisClient
+	cfg   *config.Config
+}
+
+func (f *LiquidityFilter) Name() string { return "Liquidity" }
+
+func (f *LiquidityFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
+	minValue := f.cfg.Trading.MinAvgDailyValue
+	if minValue <= 0 {
+		return true, "", 1.0
+	}
+
+	// Daily value only changes once per session, so cache it for a while
+	cacheKey := fmt.Sprintf("liquidity:adv:%s", signal.StockSymbol)
+	type CachedLiquidity struct {
+		AvgValue float64
+		Days     int64
+	}
+	var liq CachedLiquidity
+	if f.redis == nil || f.redis.Get(ctx, cacheKey, &liq) != nil {
+		avgValue, days, err := f.repo.GetAverageDailyValue(signal.StockSymbol, f.cfg.Trading.LiquidityLookbackDays)
+		if err != nil {
+			log.Printf("⚠️ Liquidity lookup failed for %s: %v", signal.StockSymbol, err)
+			return true, "", 1.0
+		}
+		liq = CachedLiquidity{AvgValue: avgValue, Days: days}
+		if f.redis != nil {
+			_ = f.redis.Set(ctx, cacheKey, liq, 30*time.Minute)
+		}
+	}
+
+	// No completed sessions yet (new symbol or fresh database)
+	if liq.Days == 0 {
+		return true, "", 1.0
+	}
+
+	summary := fmt.Sprintf("avg daily value %s over %dd", helpers.FormatRupiah(liq.AvgValue), liq.Days)
+	if liq.AvgValue < minValue {
+		return false, fmt.Sprintf("Illiquid: %s < %s", summary, helpers.FormatRupiah(minValue)), 0.0
+	}
+	return true, summary, 1.0
+}
+
+// 7. Followup Reliability Filter
+// Scales the position multiplier by how often the symbol's whale alerts actually followed through,
+// so symbols whose alerts tend to be fakeouts are down-weighted. Never rejects on its own.
+type FollowupReliabilityFilter struct {
+	repo  *database.TradeRepository
+	redis *cache.RedisClient
+	cfg   *config.Config
+}
+
+func (f *FollowupReliabilityFilter) Name() string { return "Followup Reliability" }
+
+func (f *FollowupReliabilityFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
+	weight := f.cfg.Trading.FollowupReliabilityWeight
+	if weight <= 0 {
+		return true, "", 1.0
+	}
+
+	// Followups complete an hour after the alert, so the hit rate moves slowly
+	cacheKey := fmt.Sprintf("followup:reliability:%s:%s", signal.StockSymbol, signal.Decision)
+	var rel types.FollowupReliability
+	if f.redis == nil || f.redis.Get(ctx, cacheKey, &rel) != nil {
+		stats, err := f.repo.GetFollowupReliability(signal.StockSymbol, signal.Decision, f.cfg.Trading.FollowupLookbackDays)
+		if err != nil {
+			log.Printf("⚠️ Followup reliability lookup failed for %s: %v", signal.StockSymbol, err)
+			return true, "", 1.0
+		}
+		rel = *stats
+		if f.redis != nil {
+			_ = f.redis.Set(ctx, cacheKey, rel, 15*time.Minute)
+		}
+	}
+
+	if rel.Samples < int64(f.cfg.Trading.FollowupMinSamples) {
+		return true, "", 1.0
+	}
+
+	// Linear in the hit rate: 50% is neutral, 0% and 100% reach the full penalty/boost
+	multiplier := 1.0 + weight*(rel.HitRate-50.0)/50.0
+	multiplier = math.Max(multiplier, 0.1)
+	return true, fmt.Sprintf("%s alerts hit %.0f%% of %d (avg %+.2f%% after 60m)",
+		signal.Decision, rel.HitRate, rel.Samples, rel.AvgChangePct), multiplier
+}
+
+// 8. Opening Volatility Filter
+// Blocks entries in the first minutes after the SESSION_1 open, when spreads are wide and prices
+// gap around. The window depends on the symbol's tier (symbol_meta) so liquid names can trade from the bell.
+type OpeningVolatilityFilter struct {
+	repo  *database.TradeRepository
+	redis *cache.RedisClient
+	cfg   *config.Config
+}
+
+func (f *OpeningVolatilityFilter) Name() string { return "Opening Volatility" }
+
+func (f *OpeningVolatilityFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
+	local := signal.GeneratedAt.In(marketLocation())
+	open, ok := f.cfg.Sessions.SessionStart(local, "SESSION_1")
+	if !ok || local.Before(open) {
+		return true, "", 1.0
+	}
+
+	tier := f.symbolTier(ctx, signal.StockSymbol)
+	minutes, label := f.cfg.Trading.OpeningBlackoutMinutes, "default"
+	if override, ok := f.cfg.Trading.OpeningBlackoutTierMinutes[tier]; ok && tier != "" {
+		minutes, label = override, tier+" tier"
+	}
+	if minutes <= 0 {
+		return true, "", 1.0
+	}
+
+	until := open.Add(time.Duration(minutes) * time.Minute)
+	if local.Before(until) {
+		return false, fmt.Sprintf("Opening volatility window: %s-%s WIB (%d min, %s)",
+			open.Format("15:04"), until.Format("15:04"), minutes, label), 0.0
+	}
+	return true, "", 1.0
+}
+
+// symbolTier returns the symbol's tier from symbol_meta, or "" when it has none
+func (f *OpeningVolatilityFilter) symbolTier(ctx context.Context, symbol string) string {
+	cacheKey := fmt.Sprintf("symbol:tier:%s", symbol)
+	var tier string
+	if f.redis != nil && f.redis.Get(ctx, cacheKey, &tier) == nil {
+		return tier
+	}
+
+	meta, err := f.repo.GetSymbolMeta(symbol)
+	if err != nil {
+		log.Printf("⚠️ Symbol tier lookup failed for %s: %v", symbol, err)
+		return ""
+	}
+	if meta != nil {
+		tier = meta.Tier
+	}
+	if f.redis != nil {
+		_ = f.redis.Set(ctx, cacheKey, tier, 10*time.Minute)
+	}
+	return tier
+}
+
+// 9. Spread Filter
+// Rejects signals when the latest quote shows a spread too wide or too little size at the touch,
+// since slippage on entry and exit would eat the expected edge. Passes when no recent quote is cached.
+type SpreadFilter struct {
+	redis *cache.RedisClient
+	cfg   *config.Config
+}
+
+func (f *SpreadFilter) Name() string { return "Spread & Depth" }
+
+func (f *SpreadFilter) Evaluate(ctx context.Context, signal *database.TradingSignalDB) (bool, string, float64) {
+	maxSpread, minValue := f.cfg.Trading.MaxSpreadBps, f.cfg.Trading.MinTopBookValue
+	if f.redis == nil || (maxSpread <= 0 && minValue <= 0) {
+		return true, "", 1.0
+	}
+
+	var top types.TopOfBook
+	if err := f.redis.Get(ctx, types.TopOfBookCachePrefix+signal.StockSymbol, &top); err != nil {
+		return true, "", 1.0
+	}
+
+	// A BUY lifts the offer; a SELL hits the bid
+	price, lots, side := top.Ask, top.AskLots, "offer"
+	if signal.Decision == "SELL" {
+		price, lots, side = top.Bid, top.BidLots, "bid"
+	}
+	spread := top.SpreadBps()
+	depthValue := price * lots * float64(helpers.LotSize())
+	summary := fmt.Sprintf("spread %.0f bps (%.0f/%.0f), %s depth %s", spread, top.Bid, top.Ask, side, helpers.FormatRupiah(depthValue))
+
+	if maxSpread > 0 && spread > maxSpread {
+		return false, fmt.Sprintf("Spread too wide: %s > %.0f bps max", summary, maxSpread), 0.0
+	}
+	if minValue > 0 && price > 0 && depthValue < minValue {
+		return false, fmt.Sprintf("Book too thin: %s < %s", summary, helpers.FormatRupiah(minValue)), 0.0
+	}
+	return true, summary, 1.0
+}
+
 // SwingTradingEvaluator evaluates if a signal is suitable for swing trading
 // This is not a filter but an evaluator that adds metadata to the signal
 type SwingTradingEvaluator struct {
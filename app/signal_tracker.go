@@ -2,21 +2,24 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"stockbit-haka-haki/cache"
 	"stockbit-haka-haki/config"
 	"stockbit-haka-haki/database"
+	models "stockbit-haka-haki/database/models_pkg"
+	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/helpers"
+	"stockbit-haka-haki/realtime"
 )
 
-// TradingHours defines Indonesian stock market trading hours (WIB/UTC+7)
-const (
-	MarketOpenHour  = 9  // 09:00 WIB
-	MarketCloseHour = 16 // 16:00 WIB (close at 16:00, but last trade acceptance ~15:50)
-	MarketTimeZone  = "Asia/Jakarta"
-)
+// MarketTimeZone is the exchange timezone (WIB/UTC+7); session boundaries come from config.SessionConfig
+const MarketTimeZone = "Asia/Jakarta"
 
 // Position Management Constants
 const (
@@ -25,72 +28,32 @@ const (
 	AfternoonCautionHour = 14 // After 14:00 WIB = increased caution
 )
 
-// isTradingTime checks if the given time is within Indonesian market trading hours
-func isTradingTime(t time.Time) bool {
-	// Convert to Jakarta timezone
+// marketLocation loads the WIB timezone, falling back to a fixed UTC+7 offset
+func marketLocation() *time.Location {
 	loc, err := time.LoadLocation(MarketTimeZone)
 	if err != nil {
-		log.Printf("⚠️ Failed to load timezone %s: %v", MarketTimeZone, err)
-		// Fallback: assume UTC+7 offset
-		loc = time.FixedZone("WIB", 7*60*60)
+		return time.FixedZone("WIB", 7*60*60)
 	}
+	return loc
+}
 
-	localTime := t.In(loc)
-	hour := localTime.Hour()
-	weekday := localTime.Weekday()
+// isTradingTime checks if the given time is within Indonesian market trading hours
+// (a weekday, from the start of SESSION_1 until AFTER_HOURS)
+func isTradingTime(sessions config.SessionConfig, t time.Time) bool {
+	localTime := t.In(marketLocation())
 
 	// Market is closed on weekends
-	if weekday == time.Saturday || weekday == time.Sunday {
+	if weekday := localTime.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
 		return false
 	}
 
-	// Market hours: 09:00 - 16:00 WIB
-	return hour >= MarketOpenHour && hour < MarketCloseHour
+	session := sessions.SessionAt(localTime)
+	return session != "PRE_OPENING" && session != "AFTER_HOURS"
 }
 
-// getTradingSession returns the current trading session name
-func getTradingSession(t time.Time) string {
-	loc, err := time.LoadLocation(MarketTimeZone)
-	if err != nil {
-		loc = time.FixedZone("WIB", 7*60*60)
-	}
-
-	localTime := t.In(loc)
-	hour := localTime.Hour()
-	minute := localTime.Minute()
-
-	// Pre-opening (08:45-09:00)
-	if hour == 8 && minute >= 45 {
-		return "PRE_OPENING"
-	}
-
-	// Session 1 (09:00-12:00)
-	if hour >= 9 && hour < 12 {
-		return "SESSION_1"
-	}
-
-	// Lunch break (12:00-13:30)
-	if (hour == 12) || (hour == 13 && minute < 30) {
-		return "LUNCH_BREAK"
-	}
-
-	// Session 2 (13:30-14:50)
-	if (hour == 13 && minute >= 30) || (hour == 14 && minute < 50) {
-		return "SESSION_2"
-	}
-
-	// Pre-closing (14:50-15:00)
-	if hour == 14 && minute >= 50 {
-		return "PRE_CLOSING"
-	}
-
-	// Post-market (15:00-16:00) - trades still settle but limited
-	if hour >= 15 && hour < 16 {
-		return "POST_MARKET"
-	}
-
-	// After hours
-	return "AFTER_HOURS"
+// getTradingSession returns the trading session name (SESSION_1, LUNCH_BREAK, ...) at t
+func getTradingSession(sessions config.SessionConfig, t time.Time) string {
+	return sessions.SessionAt(t.In(marketLocation()))
 }
 
 // SignalTracker monitors trading signals and tracks their outcomes
@@ -102,13 +65,27 @@ type SignalTracker struct {
 
 	exitCalc      *ExitStrategyCalculator // ATR-based exit strategy calculator
 	filterService *SignalFilterService    // Dedicated service for signal filtering logic
+	symbolFilter  *SymbolFilterService    // Symbol whitelist/blacklist (optional)
+	broker        *realtime.Broker        // Position updates over SSE (optional)
+	clock         Clock                   // Time source; realClock outside tests
+
+	// Dry run never saves signals, so the duplicate filter can't see them; remember them here instead
+	dryRunMu   sync.Mutex
+	dryRunSeen map[string]time.Time // symbol:strategy:decision:alert time -> first seen
+}
+
+// PositionUpdate is the "position_update" SSE payload: the saved outcome plus the price it was marked at
+type PositionUpdate struct {
+	*database.SignalOutcome
+	Strategy     string  `json:"strategy,omitempty"`
+	CurrentPrice float64 `json:"current_price"`
 }
 
 // NewSignalTracker creates a new signal outcome tracker
-func NewSignalTracker(repo *database.TradeRepository, redis *cache.RedisClient, cfg *config.Config) *SignalTracker {
+func NewSignalTracker(repo *database.TradeRepository, redis *cache.RedisClient, cfg *config.Config, symbolFilter *SymbolFilterService) *SignalTracker {
 
 	// Initialize Exit Strategy Calculator
-	exitCalc := NewExitStrategyCalculator(repo, cfg)
+	exitCalc := NewExitStrategyCalculator(repo, redis, cfg)
 	// Initialize Signal Filter Service
 	filterService := NewSignalFilterService(repo, redis, cfg)
 
@@ -120,12 +97,29 @@ func NewSignalTracker(repo *database.TradeRepository, redis *cache.RedisClient,
 
 		exitCalc:      exitCalc,
 		filterService: filterService,
+		symbolFilter:  symbolFilter,
+		clock:         realClock{},
 	}
 }
 
+// SetClock replaces the time source of the tracker, its exit calculator and its filters
+func (st *SignalTracker) SetClock(clock Clock) {
+	st.clock = clock
+	st.exitCalc.clock = clock
+	st.filterService.SetClock(clock)
+}
+
+// holdingMinutes is how long a position entered at entryTime has been held, per the tracker clock
+func (st *SignalTracker) holdingMinutes(entryTime time.Time) int {
+	return int(st.clock.Now().Sub(entryTime).Minutes())
+}
+
 // Start begins the signal tracking loop
 func (st *SignalTracker) Start() {
 	log.Println("📊 Signal Outcome Tracker started")
+	if st.cfg.Trading.DryRun {
+		log.Println("🧪 DRY RUN: new signals and positions are logged, not saved")
+	}
 
 	// Ticker for signal generation (Reduced frequency to minimize LLM calls)
 	// Changed from 30s to 3 minutes to reduce API costs while maintaining responsiveness
@@ -138,6 +132,9 @@ func (st *SignalTracker) Start() {
 	defer signalTicker.Stop()
 	defer outcomeTicker.Stop()
 
+	// Close outcomes left OPEN by deleted signals before they take up position slots
+	st.reconcileOrphanedOutcomes()
+
 	// Run tasks immediately on start (concurrently)
 	go st.generateSignals()
 	go st.trackSignalOutcomes()
@@ -195,6 +192,10 @@ func (st *SignalTracker) trackSignalOutcomes() {
 		}
 	}
 
+	// PART 1b: SELL signals can't open positions (no short selling) but flag open longs for exit;
+	// the reverse-signal check in PART 2 closes them in this same cycle
+	st.processExitSignals()
+
 	// PART 2: Update existing OPEN outcomes (the critical part!)
 	openOutcomes, err := st.repo.GetSignalOutcomes("", "OPEN", time.Time{}, time.Time{}, 100, 0)
 	if err != nil {
@@ -223,11 +224,13 @@ func (st *SignalTracker) trackSignalOutcomes() {
 		return
 	}
 
+	orphaned := 0
 	for _, outcome := range openOutcomes {
 		// Get the signal from the bulk-fetched map
 		signal := signalsMap[outcome.SignalID]
 		if signal == nil {
 			log.Printf("⚠️ Signal %d not found for outcome %d", outcome.SignalID, outcome.ID)
+			orphaned++
 			continue
 		}
 
@@ -238,7 +241,7 @@ func (st *SignalTracker) trackSignalOutcomes() {
 		} else {
 			updated++
 			// Check if outcome was closed in this update
-			if !wasClosed && outcome.OutcomeStatus != "OPEN" {
+			if !wasClosed && outcome.OutcomeStatus != "OPEN" && !st.cfg.Trading.DryRun {
 				closed++
 				log.Printf("✅ Closed outcome for signal %d (%s): %s with %.2f%%",
 					signal.ID, signal.StockSymbol, outcome.OutcomeStatus, *outcome.ProfitLossPct)
@@ -246,39 +249,88 @@ func (st *SignalTracker) trackSignalOutcomes() {
 		}
 	}
 
+	if orphaned > 0 && !st.cfg.Trading.DryRun {
+		st.reconcileOrphanedOutcomes()
+	}
+
 	if created > 0 || updated > 0 {
 		log.Printf("✅ Signal tracking completed: %d created, %d updated, %d closed", created, updated, closed)
 	}
 }
 
+// reconcileOrphanedOutcomes closes OPEN outcomes whose signal was deleted; they can't be
+// priced against their signal again and would otherwise hold open-position slots forever
+func (st *SignalTracker) reconcileOrphanedOutcomes() {
+	count, err := st.repo.CloseOrphanedOutcomes()
+	if err != nil {
+		log.Printf("❌ Error closing orphaned outcomes: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("🧹 Closed %d orphaned outcome(s) as ORPHANED (signal deleted)", count)
+	}
+}
+
 // shouldCreateOutcome checks if we should create an outcome for this signal
-// Returns: (shouldCreate bool, reason string, multiplier float64)
-func (st *SignalTracker) shouldCreateOutcome(signal *database.TradingSignalDB) (bool, string, float64) {
+// Returns: (shouldCreate bool, skip skipReason, multiplier float64); skip carries the filter
+// verdicts whenever the pipeline ran, including for signals that pass
+func (st *SignalTracker) shouldCreateOutcome(signal *database.TradingSignalDB) (bool, skipReason, float64) {
 	ctx := context.Background()
 
+	// Checks after the filter pipeline carry its verdicts so the skip audit shows the whole gauntlet
+	var verdicts []types.ScorecardComponent
+	reject := func(code, text string) (bool, skipReason, float64) {
+		return false, skipReason{Code: code, Text: text, Verdicts: verdicts}, 0.0
+	}
+
+	// 0. Operator whitelist/blacklist
+	if st.symbolFilter != nil && !st.symbolFilter.IsAllowed(signal.StockSymbol) {
+		return reject("symbol_filter", fmt.Sprintf("Symbol %s excluded by whitelist/blacklist", signal.StockSymbol))
+	}
+
+	// 0b. Scheduled event blackout (FOMC, BI rate, rebalancing)
+	blackoutFactor, blackoutReason := st.checkEventBlackout(signal)
+	if blackoutFactor == 0 {
+		return reject("event_blackout", blackoutReason)
+	}
+
 	// 1. Evaluate signal using SignalFilterService (Consolidated Logic)
-	shouldTrade, reason, multiplier := st.filterService.Evaluate(signal)
-	if !shouldTrade {
+	card := st.filterService.Scorecard(signal)
+	verdicts = card.Components
+	st.recordScorecard(signal, card)
+	if !card.Passed {
 		// DEBUG: Log detailed rejection reason
+		name, reason := rejection(card)
 		log.Printf("🔍 FILTER REJECTED signal %d (%s): %s", signal.ID, signal.StockSymbol, reason)
-		return false, reason, 0.0
+		return reject(name, reason)
+	}
+	multiplier := card.Multiplier
+
+	// 1b. Post-loss cooldown: no re-entry right after the symbol stopped out
+	if ok, reason := st.checkLossCooldown(signal); !ok {
+		return reject("loss_cooldown", reason)
 	}
 
 	// 2. Redis Optimizations: Check cooldowns (fastest)
-	if st.redis != nil {
+	// Falls back to a DB-backed cooldown when Redis is unavailable
+	if st.redis == nil || !st.redis.Available() {
+		if ok, reason := st.checkDBCooldown(signal); !ok {
+			return reject("cooldown", reason)
+		}
+	} else {
 		// Check cooldown key: signal:cooldown:{symbol}:{strategy}
 		cooldownKey := fmt.Sprintf("signal:cooldown:%s:%s", signal.StockSymbol, signal.Strategy)
 		var cooldownSignalID int64
 		// Verify if key exists AND is not the current signal
 		if err := st.redis.Get(ctx, cooldownKey, &cooldownSignalID); err == nil && cooldownSignalID != 0 && cooldownSignalID != signal.ID {
-			return false, fmt.Sprintf("In cooldown period for %s (Signal %d)", signal.Strategy, cooldownSignalID), 0.0
+			return reject("cooldown", fmt.Sprintf("In cooldown period for %s (Signal %d)", signal.Strategy, cooldownSignalID))
 		}
 
 		// Check recent duplicate key: signal:recent:{symbol}
 		recentKey := fmt.Sprintf("signal:recent:%s", signal.StockSymbol)
 		var recentSignalID int64
 		if err := st.redis.Get(ctx, recentKey, &recentSignalID); err == nil && recentSignalID != 0 && recentSignalID != signal.ID {
-			return false, fmt.Sprintf("Recent signal %d exists for %s (too soon)", recentSignalID, signal.StockSymbol), 0.0
+			return reject("recent_signal", fmt.Sprintf("Recent signal %d exists for %s (too soon)", recentSignalID, signal.StockSymbol))
 		}
 	}
 
@@ -286,36 +338,58 @@ func (st *SignalTracker) shouldCreateOutcome(signal *database.TradingSignalDB) (
 	// Check if too many open positions globally
 	openOutcomes, err := st.repo.GetSignalOutcomes("", "OPEN", time.Time{}, time.Time{}, 0, 0)
 	if err == nil && len(openOutcomes) >= st.cfg.Trading.MaxOpenPositions {
-		return false, fmt.Sprintf("Max open positions reached (%d/%d)", len(openOutcomes), st.cfg.Trading.MaxOpenPositions), 0.0
+		return reject("max_open_positions", fmt.Sprintf("Global max open positions reached (%d/%d)", len(openOutcomes), st.cfg.Trading.MaxOpenPositions))
+	}
+
+	// Check the strategy's own slot allocation so one strategy can't take every global slot
+	if limit := st.cfg.Trading.StrategyMaxOpenPositions[signal.Strategy]; err == nil && limit > 0 && len(openOutcomes) >= limit {
+		if signalsMap, err := st.repo.GetSignalsByIDs(outcomeSignalIDs(openOutcomes)); err == nil {
+			strategyOpen := 0
+			for _, outcome := range openOutcomes {
+				if s, ok := signalsMap[outcome.SignalID]; ok && s != nil && s.Strategy == signal.Strategy {
+					strategyOpen++
+				}
+			}
+			if strategyOpen >= limit {
+				return reject("strategy_max_open_positions", fmt.Sprintf("Max open positions for %s reached (%d/%d)", signal.Strategy, strategyOpen, limit))
+			}
+		}
 	}
 
 	// Check if symbol already has open position
 	symbolOutcomes, err := st.repo.GetSignalOutcomes(signal.StockSymbol, "OPEN", time.Time{}, time.Time{}, 0, 0)
 	if err == nil && len(symbolOutcomes) >= st.cfg.Trading.MaxPositionsPerSymbol {
-		return false, fmt.Sprintf("Symbol %s already has %d open position(s)", signal.StockSymbol, len(symbolOutcomes)), 0.0
+		return reject("symbol_open_position", fmt.Sprintf("Symbol %s already has %d open position(s)", signal.StockSymbol, len(symbolOutcomes)))
 	}
 
 	// Check for recent signals within time window (duplicate prevention)
 	recentSignalTime := signal.GeneratedAt.Add(-time.Duration(st.cfg.Trading.SignalTimeWindowMinutes) * time.Minute)
 	recentSignals, err := st.repo.GetTradingSignals(signal.StockSymbol, signal.Strategy, "BUY", recentSignalTime, signal.GeneratedAt, 10, 0)
 	if err == nil && len(recentSignals) > 1 {
-		return false, fmt.Sprintf("Duplicate signal within %d minute window", st.cfg.Trading.SignalTimeWindowMinutes), 0.0
+		return reject("duplicate_window", fmt.Sprintf("Duplicate signal within %d minute window", st.cfg.Trading.SignalTimeWindowMinutes))
 	}
 
-	// Check minimum interval since last signal for this symbol
-	lastSignalTime := signal.GeneratedAt.Add(-time.Duration(st.cfg.Trading.MinSignalIntervalMinutes) * time.Minute)
-	lastSignals, err := st.repo.GetTradingSignals(signal.StockSymbol, "", "BUY", lastSignalTime, time.Time{}, 1, 0)
-	if err == nil && len(lastSignals) > 0 {
-		if lastSignals[0].ID != signal.ID {
-			timeSince := signal.GeneratedAt.Sub(lastSignals[0].GeneratedAt).Minutes()
-			if timeSince < float64(st.cfg.Trading.MinSignalIntervalMinutes) {
-				return false, fmt.Sprintf("Signal too soon (%.1f min < %d min required)", timeSince, st.cfg.Trading.MinSignalIntervalMinutes), 0.0
+	// Check minimum interval since last signal for this symbol; a per-strategy override only
+	// counts that strategy's signals, matching the signal:cooldown:{symbol}:{strategy} key
+	if interval := st.cfg.Trading.SignalIntervalFor(signal.Strategy); interval > 0 {
+		strategy := ""
+		if _, ok := st.cfg.Trading.StrategySignalIntervals[signal.Strategy]; ok {
+			strategy = signal.Strategy
+		}
+		lastSignalTime := signal.GeneratedAt.Add(-time.Duration(interval) * time.Minute)
+		lastSignals, err := st.repo.GetTradingSignals(signal.StockSymbol, strategy, "BUY", lastSignalTime, time.Time{}, 1, 0)
+		if err == nil && len(lastSignals) > 0 {
+			if lastSignals[0].ID != signal.ID {
+				timeSince := signal.GeneratedAt.Sub(lastSignals[0].GeneratedAt).Minutes()
+				if timeSince < float64(interval) {
+					return reject("min_interval", fmt.Sprintf("Signal too soon (%.1f min < %d min required)", timeSince, interval))
+				}
 			}
 		}
 	}
 
 	// NEW: Check daily loss limit (circuit breaker)
-	todayStart := time.Now().Truncate(24 * time.Hour)
+	todayStart := st.clock.Now().Truncate(24 * time.Hour)
 	todayOutcomes, err := st.repo.GetSignalOutcomes("", "", todayStart, time.Time{}, 0, 0)
 	if err == nil {
 		dailyLoss := 0.0
@@ -325,11 +399,125 @@ func (st *SignalTracker) shouldCreateOutcome(signal *database.TradingSignalDB) (
 			}
 		}
 		if dailyLoss <= -st.cfg.Trading.MaxDailyLossPct {
-			return false, fmt.Sprintf("Daily loss limit reached (%.2f%% >= %.2f%%)", dailyLoss, st.cfg.Trading.MaxDailyLossPct), 0.0
+			return reject("daily_loss_limit", fmt.Sprintf("Daily loss limit reached (%.2f%% >= %.2f%%)", dailyLoss, st.cfg.Trading.MaxDailyLossPct))
+		}
+	}
+
+	if blackoutFactor < 1.0 {
+		log.Printf("   └─ Event blackout modifier: %.2fx (%s)", blackoutFactor, blackoutReason)
+		multiplier *= blackoutFactor
+	}
+
+	return true, skipReason{Verdicts: verdicts}, multiplier
+}
+
+// checkEventBlackout returns a multiplier for active scheduled events: 0 blocks the entry
+// HIGH severity blocks, MEDIUM halves and LOW trims the position multiplier
+func (st *SignalTracker) checkEventBlackout(signal *database.TradingSignalDB) (float64, string) {
+	events, err := st.repo.GetActiveScheduledEvents(signal.GeneratedAt, signal.StockSymbol)
+	if err != nil {
+		log.Printf("⚠️ Scheduled event check failed for %s: %v", signal.StockSymbol, err)
+		return 1.0, ""
+	}
+
+	factor, reason := 1.0, ""
+	for _, event := range events {
+		eventFactor := 1.0
+		switch event.Severity {
+		case "HIGH":
+			eventFactor = 0.0
+		case "MEDIUM":
+			eventFactor = 0.5
+		case "LOW":
+			eventFactor = 0.8
+		}
+		if eventFactor < factor {
+			factor = eventFactor
+			reason = fmt.Sprintf("Event blackout: %s (%s)", event.Description, event.Severity)
+		}
+	}
+	return factor, reason
+}
+
+// checkDBCooldown enforces the per-strategy signal interval using the latest outcome entry time
+// Returns: (allowed bool, reason string)
+func (st *SignalTracker) checkDBCooldown(signal *database.TradingSignalDB) (bool, string) {
+	interval := st.cfg.Trading.SignalIntervalFor(signal.Strategy)
+	if interval <= 0 {
+		return true, ""
+	}
+
+	lastEntry, err := st.repo.GetLastOutcomeEntryTime(signal.StockSymbol, signal.Strategy)
+	if err != nil {
+		log.Printf("⚠️ DB cooldown check failed for %s: %v", signal.StockSymbol, err)
+		return true, ""
+	}
+	if lastEntry == nil {
+		return true, ""
+	}
+
+	elapsed := signal.GeneratedAt.Sub(*lastEntry).Minutes()
+	if elapsed < float64(interval) {
+		return false, fmt.Sprintf("In cooldown period for %s (%.1f min < %d min, DB fallback)", signal.Strategy, elapsed, interval)
+	}
+	return true, ""
+}
+
+// lossCooldownKey stores the exit time of a symbol's last LOSS: signal:loss_cooldown:{symbol}
+func lossCooldownKey(symbol string) string {
+	return fmt.Sprintf("signal:loss_cooldown:%s", symbol)
+}
+
+// checkLossCooldown blocks a symbol for LossCooldownMinutes after its last LOSS exit.
+// Reads the Redis key set by startLossCooldown, or the outcomes table when Redis is unavailable.
+// Returns: (allowed bool, reason string)
+func (st *SignalTracker) checkLossCooldown(signal *database.TradingSignalDB) (bool, string) {
+	cooldown := time.Duration(st.cfg.Trading.LossCooldownMinutes) * time.Minute
+	if cooldown <= 0 {
+		return true, ""
+	}
+
+	ctx := context.Background()
+	var lastLoss *time.Time
+	source := ""
+	if st.redis.Available() {
+		var exitTime time.Time
+		if err := st.redis.Get(ctx, lossCooldownKey(signal.StockSymbol), &exitTime); err == nil {
+			lastLoss = &exitTime
+		}
+	} else {
+		exitTime, err := st.repo.GetLastLossExitTime(signal.StockSymbol)
+		if err != nil {
+			log.Printf("⚠️ Loss cooldown check failed for %s: %v", signal.StockSymbol, err)
+			return true, ""
 		}
+		lastLoss = exitTime
+		source = ", DB fallback"
+	}
+	if lastLoss == nil {
+		return true, ""
 	}
 
-	return true, "", multiplier
+	remaining := cooldown - signal.GeneratedAt.Sub(*lastLoss)
+	if remaining > 0 {
+		return false, fmt.Sprintf("Post-loss cooldown for %s (%.1f min remaining of %d min%s)",
+			signal.StockSymbol, remaining.Minutes(), st.cfg.Trading.LossCooldownMinutes, source)
+	}
+	return true, ""
+}
+
+// startLossCooldown records a LOSS exit so checkLossCooldown can block re-entry into the symbol
+func (st *SignalTracker) startLossCooldown(outcome *database.SignalOutcome) {
+	cooldown := time.Duration(st.cfg.Trading.LossCooldownMinutes) * time.Minute
+	if cooldown <= 0 || outcome.OutcomeStatus != "LOSS" || outcome.ExitTime == nil || st.redis == nil {
+		return
+	}
+
+	if err := st.redis.Set(context.Background(), lossCooldownKey(outcome.StockSymbol), *outcome.ExitTime, cooldown); err != nil {
+		log.Printf("⚠️ Failed to set loss cooldown for %s: %v", outcome.StockSymbol, err)
+		return
+	}
+	log.Printf("🧊 %s in post-loss cooldown for %d min", outcome.StockSymbol, st.cfg.Trading.LossCooldownMinutes)
 }
 
 // createSignalOutcome creates a new outcome record for a signal
@@ -340,37 +528,55 @@ func (st *SignalTracker) createSignalOutcome(signal *database.TradingSignalDB) (
 		return false, nil
 	}
 
-	// Exclude NG (Negotiated Trading) signals
+	// Exclude NG (Negotiated Trading) signals, and TN (Cash) too when restricted to the regular board
 	if signal.WhaleAlertID != nil {
 		alert, err := st.repo.GetWhaleAlertByID(*signal.WhaleAlertID)
-		if err == nil && alert != nil && alert.MarketBoard == "NG" {
-			reason := "NG (Negotiated Trading) excluded"
-			log.Printf("⏭️ Skipping signal %d (%s): %s", signal.ID, signal.StockSymbol, reason)
-			return false, nil
+		if err == nil && alert != nil {
+			var reason string
+			switch {
+			case alert.MarketBoard == "NG":
+				reason = "NG (Negotiated Trading) excluded"
+			case st.cfg.Trading.SignalRGOnly && alert.MarketBoard != "RG":
+				reason = fmt.Sprintf("%s board excluded (RG only)", alert.MarketBoard)
+			}
+			if reason != "" {
+				log.Printf("⏭️ Skipping signal %d (%s): %s", signal.ID, signal.StockSymbol, reason)
+				st.recordSkip(signal, skipReason{Code: "board", Text: reason})
+				return false, nil
+			}
 		}
 	}
 
 	// Validate trading time
 	if !st.cfg.Trading.MockTradingMode {
-		if !isTradingTime(signal.GeneratedAt) {
-			session := getTradingSession(signal.GeneratedAt)
+		if !isTradingTime(st.cfg.Sessions, signal.GeneratedAt) {
+			session := getTradingSession(st.cfg.Sessions, signal.GeneratedAt)
 			reason := fmt.Sprintf("Generated outside trading hours (session: %s)", session)
 			log.Printf("⏰ Skipping signal %d (%s): %s", signal.ID, signal.StockSymbol, reason)
+			st.recordSkip(signal, skipReason{Code: "trading_hours", Text: reason})
 			return false, nil
 		}
-	} else if !isTradingTime(signal.GeneratedAt) {
-		session := getTradingSession(signal.GeneratedAt)
+	} else if !isTradingTime(st.cfg.Sessions, signal.GeneratedAt) {
+		session := getTradingSession(st.cfg.Sessions, signal.GeneratedAt)
 		log.Printf("⚠️ MOCK TRADING: Allowing signal %d (%s) generated outside trading hours (session: %s)", signal.ID, signal.StockSymbol, session)
 	}
 
+	// Average into an existing position on the symbol instead of rejecting it as a duplicate
+	if st.cfg.Trading.ScaleInEnabled {
+		if handled, err := st.tryScaleIn(signal); handled || err != nil {
+			return false, err
+		}
+	}
+
 	// Check duplicate prevention and position limits (with ALL optimizations)
-	shouldCreate, reason, multiplier := st.shouldCreateOutcome(signal)
+	shouldCreate, skip, multiplier := st.shouldCreateOutcome(signal)
 	if !shouldCreate {
-		log.Printf("⏭️ Skipping signal %d (%s %s): %s", signal.ID, signal.StockSymbol, signal.Decision, reason)
+		log.Printf("⏭️ Skipping signal %d (%s %s): %s", signal.ID, signal.StockSymbol, signal.Decision, skip.Text)
+		st.recordSkip(signal, skip)
 		return false, nil
 	}
 
-	session := getTradingSession(signal.GeneratedAt)
+	session := getTradingSession(st.cfg.Sessions, signal.GeneratedAt)
 
 	// Check if this signal qualifies for swing trading
 	isSwing := false
@@ -400,11 +606,20 @@ func (st *SignalTracker) createSignalOutcome(signal *database.TradingSignalDB) (
 		SignalID:          signal.ID,
 		StockSymbol:       signal.StockSymbol,
 		EntryTime:         signal.GeneratedAt,
-		EntryPrice:        signal.TriggerPrice,
+		EntryPrice:        st.simulateEntryFill(signal.TriggerPrice),
 		EntryDecision:     signal.Decision,
 		OutcomeStatus:     "OPEN",
 		ATRAtEntry:        &exitLevels.ATR,
 		TrailingStopPrice: &exitLevels.StopLossPrice,
+		PositionUnits:     1,
+	}
+
+	if st.cfg.Trading.DryRun {
+		log.Printf("🧪 DRY RUN: would open %s position in %s @ %.0f | SL %.0f (%.2f%%) | TP1 %.0f | TP2 %.0f | ATR %.1f (%.2f%%)",
+			positionType, signal.StockSymbol, outcome.EntryPrice, exitLevels.StopLossPrice, exitLevels.InitialStopPct,
+			exitLevels.TakeProfit1Price, exitLevels.TakeProfit2Price, exitLevels.ATR, exitLevels.ATRPercent)
+		log.Printf("   └─ Filter verdicts: %s", formatVerdicts(skip.Verdicts))
+		return false, nil
 	}
 
 	if err := st.repo.SaveSignalOutcome(outcome); err != nil {
@@ -413,6 +628,120 @@ func (st *SignalTracker) createSignalOutcome(signal *database.TradingSignalDB) (
 	return true, nil
 }
 
+// scaleInKey marks a signal's analysis_data with the outcome it was averaged into
+const scaleInKey = "scaled_into_outcome"
+
+// tryScaleIn averages a signal into the symbol's open position from the same strategy as an
+// additional fill. The fill must pass the whitelist/blacklist, filter pipeline, event blackout
+// and loss cooldown; only the duplicate,
+// cooldown and position-count checks are waived. Returns true when the signal was handled here
+// (scaled in now or in an earlier cycle, or rejected), false to fall through to a new position.
+func (st *SignalTracker) tryScaleIn(signal *database.TradingSignalDB) (bool, error) {
+	if _, done := parseAnalysisData(signal)[scaleInKey]; done {
+		return true, nil
+	}
+
+	if st.symbolFilter != nil && !st.symbolFilter.IsAllowed(signal.StockSymbol) {
+		return false, nil // shouldCreateOutcome records the whitelist/blacklist skip
+	}
+
+	// Only average into a position opened by the same strategy; other strategies' signals
+	// fall through to the regular duplicate and position-limit checks
+	openOutcomes, err := st.repo.GetSignalOutcomes(signal.StockSymbol, "OPEN", time.Time{}, time.Time{}, 0, 0)
+	if err != nil || len(openOutcomes) == 0 {
+		return false, err
+	}
+	signalsMap, err := st.repo.GetSignalsByIDs(outcomeSignalIDs(openOutcomes))
+	if err != nil {
+		return false, err
+	}
+	var outcome *database.SignalOutcome
+	for i := range openOutcomes {
+		if s, ok := signalsMap[openOutcomes[i].SignalID]; ok && s != nil && s.Strategy == signal.Strategy {
+			outcome = &openOutcomes[i]
+			break
+		}
+	}
+	if outcome == nil || outcome.ScaleIns >= st.cfg.Trading.MaxScaleIns {
+		return false, nil
+	}
+
+	blackoutFactor, blackoutReason := st.checkEventBlackout(signal)
+	if blackoutFactor == 0 {
+		st.recordSkip(signal, skipReason{Code: "event_blackout", Text: blackoutReason})
+		return true, nil
+	}
+	card := st.filterService.Scorecard(signal)
+	st.recordScorecard(signal, card)
+	if !card.Passed {
+		name, reason := rejection(card)
+		log.Printf("⏭️ Not scaling into %s with signal %d: %s", signal.StockSymbol, signal.ID, reason)
+		st.recordSkip(signal, skipReason{Code: name, Text: reason, Verdicts: card.Components})
+		return true, nil
+	}
+	multiplier := card.Multiplier
+	if ok, reason := st.checkLossCooldown(signal); !ok {
+		st.recordSkip(signal, skipReason{Code: "loss_cooldown", Text: reason, Verdicts: card.Components})
+		return true, nil
+	}
+
+	units := outcome.PositionUnits
+	if units <= 0 {
+		units = 1
+	}
+	added := multiplier * blackoutFactor
+	fill := st.simulateEntryFill(signal.TriggerPrice)
+	avgEntry := (outcome.EntryPrice*units + fill*added) / (units + added)
+
+	if st.cfg.Trading.DryRun {
+		log.Printf("🧪 DRY RUN: would scale into %s position %d @ %.0f (+%.2f units) | avg entry %.2f → %.2f",
+			signal.StockSymbol, outcome.ID, fill, added, outcome.EntryPrice, avgEntry)
+		return true, nil
+	}
+
+	// Mark the signal before touching the outcome: losing a scale-in beats averaging the same fill twice
+	analysis := parseAnalysisData(signal)
+	if analysis[scaleInKey], err = json.Marshal(outcome.ID); err != nil {
+		return true, err
+	}
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		return true, err
+	}
+	if err := st.repo.UpdateSignalAnalysisData(signal.ID, string(data)); err != nil {
+		return true, err
+	}
+
+	log.Printf("➕ Scaling into %s position %d with signal %d @ %.0f (+%.2f units) | avg entry %.2f → %.2f (scale-in %d/%d)",
+		signal.StockSymbol, outcome.ID, signal.ID, fill, added, outcome.EntryPrice, avgEntry,
+		outcome.ScaleIns+1, st.cfg.Trading.MaxScaleIns)
+
+	outcome.EntryPrice = avgEntry
+	outcome.PositionUnits = units + added
+	outcome.ScaleIns++
+	if saved, err := st.repo.UpdateOpenSignalOutcome(outcome); err != nil || !saved {
+		return true, err
+	}
+	return true, nil
+}
+
+// formatVerdicts renders scorecard components for a log line, e.g. "regime ✓, liquidity ✗ (thin book)"
+func formatVerdicts(components []types.ScorecardComponent) string {
+	parts := make([]string, 0, len(components))
+	for _, c := range components {
+		mark := "✓"
+		if !c.Passed {
+			mark = "✗"
+		}
+		part := c.Name + " " + mark
+		if c.Reason != "" {
+			part += " (" + c.Reason + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // updateSignalOutcome updates an existing outcome with current price data
 func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, outcome *database.SignalOutcome) error {
 	// Skip if already closed
@@ -426,13 +755,66 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 		return nil
 	}
 
-	// Check current trading session
-	now := time.Now()
-	currentSession := getTradingSession(now)
+	// Mark the position with the configured price source
+	currentPrice, source := st.GetMarkPrice(signal.StockSymbol, signal.Decision)
+	if currentPrice <= 0 {
+		// No data available at all - log warning but don't fail completely
+		log.Printf("⚠️ No price data available for %s (signal %d) - keeping OPEN status",
+			signal.StockSymbol, signal.ID)
+		return nil // Return without error to prevent blocking other updates
+	}
+	if source != st.priceSource() {
+		log.Printf("📊 Using %s price for %s: %.0f (%s unavailable)",
+			source, signal.StockSymbol, currentPrice, st.priceSource())
+	}
 
 	// Check if this is a swing trade
 	isSwing := st.isSwingTrade(signal, outcome)
 
+	// Get latest order flow to determine momentum
+	orderFlow, _ := st.repo.GetLatestOrderFlow(signal.StockSymbol)
+
+	// Calculate ATR-based exit levels - USE SWING LEVELS FOR SWING TRADES
+	var exitLevels *ExitLevels
+	if isSwing {
+		exitLevels = st.exitCalc.GetSwingExitLevels(signal.StockSymbol, outcome.EntryPrice)
+	} else {
+		exitLevels = st.exitCalc.GetExitLevels(signal.StockSymbol, outcome.EntryPrice)
+	}
+
+	currentPrice = st.markOutcome(signal, outcome, currentPrice, exitLevels, orderFlow, isSwing)
+
+	// Dry run leaves stored positions, cooldowns and subscribers untouched
+	if st.cfg.Trading.DryRun {
+		if outcome.OutcomeStatus != "OPEN" {
+			log.Printf("🧪 DRY RUN: would close position %d in %s @ %.0f: %s %.2f%% (%s)",
+				outcome.ID, signal.StockSymbol, currentPrice, outcome.OutcomeStatus, *outcome.ProfitLossPct, *outcome.ExitReason)
+		}
+		return nil
+	}
+
+	saved, err := st.repo.UpdateOpenSignalOutcome(outcome)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		log.Printf("⏭️ Outcome %d (%s) was closed elsewhere during tracking, keeping stored close", outcome.ID, signal.StockSymbol)
+		return nil
+	}
+	st.startLossCooldown(outcome)
+	st.publishPosition(outcome, signal.Strategy, currentPrice)
+	return nil
+}
+
+// markOutcome applies a mark at currentPrice to an open outcome: P/L, excursions and the trailing
+// stop are updated, then the exit rules run against the tracker clock's session and holding time
+// and close the outcome if one fires. Returns the price the outcome was marked or filled at.
+func (st *SignalTracker) markOutcome(signal *database.TradingSignalDB, outcome *database.SignalOutcome, currentPrice float64,
+	exitLevels *ExitLevels, orderFlow *database.OrderFlowImbalance, isSwing bool) float64 {
+	// Check current trading session
+	now := st.clock.Now()
+	currentSession := getTradingSession(st.cfg.Sessions, now)
+
 	// Auto-close positions at market close (16:00 WIB)
 	if !st.cfg.Trading.MockTradingMode {
 		if !isSwing && currentSession == "AFTER_HOURS" && outcome.ExitTime == nil {
@@ -441,24 +823,6 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 		}
 	}
 
-	// Get current price from latest candle with fallback to latest trade
-	var currentPrice float64
-	candle, err := st.repo.GetLatestCandle(signal.StockSymbol)
-	if err != nil || candle == nil {
-		// Fallback: Get price from latest trade if candle is unavailable
-		trades, err := st.repo.GetRecentTrades(signal.StockSymbol, 1, "")
-		if err != nil || len(trades) == 0 {
-			// No data available at all - log warning but don't fail completely
-			log.Printf("⚠️ No price data available for %s (signal %d) - keeping OPEN status",
-				signal.StockSymbol, signal.ID)
-			return nil // Return without error to prevent blocking other updates
-		}
-		currentPrice = trades[0].Price
-		log.Printf("📊 Using latest trade price for %s: %.0f (no candle data)",
-			signal.StockSymbol, currentPrice)
-	} else {
-		currentPrice = candle.Close
-	}
 	entryPrice := outcome.EntryPrice
 
 	// Calculate price change (only BUY positions)
@@ -466,8 +830,8 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 	profitLossPct := priceChangePct
 
 	// Calculate holding period
-	holdingMinutes := int(time.Since(outcome.EntryTime).Minutes())
-	holdingDays := int(time.Since(outcome.EntryTime).Hours() / 24)
+	holdingMinutes := st.holdingMinutes(outcome.EntryTime)
+	holdingDays := int(now.Sub(outcome.EntryTime).Hours() / 24)
 
 	// Update MAE and MFE (track current extremes)
 	mae := outcome.MaxAdverseExcursion
@@ -488,31 +852,16 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 		mfe = &profitLossPct
 	}
 
-	// Get latest order flow to determine momentum
-	orderFlow, _ := st.repo.GetLatestOrderFlow(signal.StockSymbol)
-
-	// Determine exit conditions with ATR-based dynamic exit strategy
-	shouldExit := false
-	exitReason := ""
-
-	// Calculate ATR-based exit levels - USE SWING LEVELS FOR SWING TRADES
-	var exitLevels *ExitLevels
-	if isSwing {
-		exitLevels = st.exitCalc.GetSwingExitLevels(signal.StockSymbol, outcome.EntryPrice)
-	} else {
-		exitLevels = st.exitCalc.GetExitLevels(signal.StockSymbol, outcome.EntryPrice)
-	}
-
 	// Get current trailing stop (initialize if nil)
 	var currentTrailingStop float64
 	if outcome.TrailingStopPrice != nil {
 		currentTrailingStop = *outcome.TrailingStopPrice
 	} else {
 		// Initialize trailing stop at entry price minus initial stop
-		currentTrailingStop = outcome.EntryPrice * (1 - exitLevels.InitialStopPct/100)
+		currentTrailingStop = helpers.RoundToTick(outcome.EntryPrice * (1 - exitLevels.InitialStopPct/100))
 	}
 
-	// Use ATR-based exit strategy
+	// Determine exit conditions with ATR-based dynamic exit strategy
 	shouldExit, exitReason, newTrailingStop := st.exitCalc.ShouldExitPosition(
 		outcome.EntryPrice,
 		currentPrice,
@@ -520,6 +869,8 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 		currentTrailingStop,
 		profitLossPct,
 		holdingMinutes,
+		signal.Strategy,
+		isSwing,
 	)
 
 	// Update trailing stop in outcome
@@ -533,7 +884,7 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 	if !st.cfg.Trading.MockTradingMode {
 		if !shouldExit && currentSession == "AFTER_HOURS" {
 			shouldExit = true
-			exitReason = "MARKET_CLOSE"
+			exitReason = models.ExitReasonMarketClose
 			log.Printf("⏰ Force exit due to market close for signal %d (%s)", signal.ID, signal.StockSymbol)
 		}
 	}
@@ -541,13 +892,13 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 	// Auto-exit in pre-closing session (14:50-15:00) if profitable
 	if !shouldExit && currentSession == "PRE_CLOSING" && profitLossPct > 1.0 {
 		shouldExit = true
-		exitReason = "PRE_CLOSE_PROFIT_TAKING"
+		exitReason = models.ExitReasonPreCloseProfitTaking
 		log.Printf("⏰ Pre-close profit taking for signal %d (%s): %.2f%%",
 			signal.ID, signal.StockSymbol, profitLossPct)
 	}
 
 	// Order flow momentum reversal check (additional exit signal)
-	if !shouldExit && isTradingTime(now) && profitLossPct > 0 && orderFlow != nil {
+	if !shouldExit && isTradingTime(st.cfg.Sessions, now) && profitLossPct > 0 && orderFlow != nil {
 		totalVolume := orderFlow.BuyVolumeLots + orderFlow.SellVolumeLots
 		var sellPressure float64
 		if totalVolume > 0 {
@@ -557,7 +908,17 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 		// Take profit if sell pressure high and we have gains
 		if sellPressure > 65 && profitLossPct >= exitLevels.TakeProfit1Pct*0.75 {
 			shouldExit = true
-			exitReason = "TAKE_PROFIT_MOMENTUM_REVERSAL"
+			exitReason = models.ExitReasonTakeProfitMomentumReversal
+		}
+	}
+
+	// Reverse signal: a strong SELL on the same symbol after entry closes the long
+	if !shouldExit {
+		if reverse := st.findReverseSignal(signal, outcome); reverse != nil {
+			shouldExit = true
+			exitReason = models.ExitReasonReverseSignal
+			log.Printf("🔄 Reverse signal exit for %s: SELL signal %d (%s, conf %.2f)",
+				signal.StockSymbol, reverse.ID, reverse.Strategy, reverse.Confidence)
 		}
 	}
 
@@ -581,7 +942,7 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 			// SWING: Check max holding days
 			if holdingDays >= st.cfg.Trading.SwingMaxHoldingDays {
 				shouldExit = true
-				exitReason = "SWING_MAX_HOLDING_DAYS"
+				exitReason = models.ExitReasonSwingMaxHoldingDays
 				log.Printf("📅 Swing max holding reached for %s: %d days, P/L %.2f%%",
 					signal.StockSymbol, holdingDays, profitLossPct)
 			}
@@ -589,7 +950,7 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 			// DAY TRADE: Check max holding minutes
 			if holdingMinutes > 60 && profitLossPct < -st.cfg.Trading.MaxHoldingLossPct {
 				shouldExit = true
-				exitReason = "TIME_BASED_CUT_LOSS"
+				exitReason = models.ExitReasonTimeBasedCutLoss
 				log.Printf("✂️ Time-based cut loss for %s: held %d mins, P/L %.2f%%",
 					signal.StockSymbol, holdingMinutes, profitLossPct)
 			}
@@ -597,23 +958,271 @@ func (st *SignalTracker) updateSignalOutcome(signal *database.TradingSignalDB, o
 	}
 
 	if shouldExit {
-		now := time.Now()
+		// Paper trading: realize the exit at a simulated fill instead of the raw close
+		if st.cfg.Trading.PaperTradingMode {
+			candle, _ := st.repo.GetLatestCandle(signal.StockSymbol) // Low for worst-case stop fills; nil is handled
+			fillPrice := st.simulateExitFill(currentPrice, candle, exitReason)
+			profitLossPct = ((fillPrice - entryPrice) / entryPrice) * 100
+			priceChangePct = profitLossPct
+			currentPrice = fillPrice
+		}
+
 		outcome.ExitTime = &now
 		outcome.ExitPrice = &currentPrice
 		outcome.ExitReason = &exitReason
 
-		// Determine outcome status - Accounting for trading fees (0.25% total: 0.15% buy + 0.10% sell)
-		const feeThreshold = 0.25 // Total round-trip fees in percentage
-		if profitLossPct > feeThreshold {
-			outcome.OutcomeStatus = "WIN"
-		} else if profitLossPct < -feeThreshold {
-			outcome.OutcomeStatus = "LOSS"
-		} else {
-			outcome.OutcomeStatus = "BREAKEVEN"
+		outcome.OutcomeStatus = st.closedOutcomeStatus(outcome, profitLossPct)
+	}
+
+	return currentPrice
+}
+
+// SetBroker enables "position_update" broadcasts after each outcome update
+func (st *SignalTracker) SetBroker(broker *realtime.Broker) {
+	st.broker = broker
+}
+
+// publishPosition broadcasts a saved outcome with its mark price
+func (st *SignalTracker) publishPosition(outcome *database.SignalOutcome, strategy string, price float64) {
+	if st.broker == nil {
+		return
+	}
+	st.broker.Broadcast("position_update", PositionUpdate{
+		SignalOutcome: outcome,
+		Strategy:      strategy,
+		CurrentPrice:  price,
+	})
+}
+
+// breakevenBand returns the ±P/L % treated as BREAKEVEN for an outcome: the configured fixed band
+// (round-trip fees by default), widened to BreakevenBandATRMult × entry ATR when that is larger
+func (st *SignalTracker) breakevenBand(outcome *database.SignalOutcome) float64 {
+	band := st.cfg.Trading.BreakevenBandPct
+	if mult := st.cfg.Trading.BreakevenBandATRMult; mult > 0 && outcome.ATRAtEntry != nil && outcome.EntryPrice > 0 {
+		if atrBand := mult * *outcome.ATRAtEntry / outcome.EntryPrice * 100; atrBand > band {
+			band = atrBand
+		}
+	}
+	return band
+}
+
+// closedOutcomeStatus classifies a realized P/L as WIN, LOSS or BREAKEVEN using the outcome's breakeven band
+func (st *SignalTracker) closedOutcomeStatus(outcome *database.SignalOutcome, profitLossPct float64) string {
+	band := st.breakevenBand(outcome)
+	if profitLossPct > band {
+		return "WIN"
+	} else if profitLossPct < -band {
+		return "LOSS"
+	}
+	return "BREAKEVEN"
+}
+
+// priceSource returns the configured mark price source, defaulting to candle_close
+func (st *SignalTracker) priceSource() string {
+	switch st.cfg.Trading.PriceSource {
+	case "last_trade", "bid", "mid":
+		return st.cfg.Trading.PriceSource
+	default:
+		return "candle_close"
+	}
+}
+
+// GetMarkPrice returns the price an open position on side (BUY = long) would be marked or exited at,
+// following cfg.Trading.PriceSource, along with the source actually used.
+// "bid" is side-aware: longs exit at the best bid, shorts at the best offer.
+// Quote-based sources fall back to the last trade, and candle close falls back to the last trade too.
+// Returns 0 when no price is available.
+func (st *SignalTracker) GetMarkPrice(symbol, side string) (float64, string) {
+	source := st.priceSource()
+
+	if (source == "bid" || source == "mid") && st.redis != nil {
+		var top types.TopOfBook
+		if err := st.redis.Get(context.Background(), types.TopOfBookCachePrefix+symbol, &top); err == nil {
+			if source == "mid" && top.Bid > 0 && top.Ask > 0 {
+				return (top.Bid + top.Ask) / 2, source
+			}
+			if source == "bid" {
+				if side == "SELL" && top.Ask > 0 {
+					return top.Ask, source
+				}
+				if side != "SELL" && top.Bid > 0 {
+					return top.Bid, source
+				}
+			}
+		}
+	}
+
+	if source == "candle_close" {
+		if candle, err := st.repo.GetLatestCandle(symbol); err == nil && candle != nil && candle.Close > 0 {
+			return candle.Close, source
 		}
 	}
 
-	return st.repo.UpdateSignalOutcome(outcome)
+	trades, err := st.repo.GetRecentTrades(symbol, 1, "")
+	if err != nil || len(trades) == 0 {
+		return 0, ""
+	}
+	return trades[0].Price, "last_trade"
+}
+
+// ClosePosition force-closes an open position, e.g. after the trader exited manually at the broker.
+// A non-positive exitPrice falls back to the configured mark price (see GetMarkPrice).
+func (st *SignalTracker) ClosePosition(outcomeID int64, exitPrice float64) (*database.SignalOutcome, error) {
+	outcome, err := st.repo.GetSignalOutcomeByID(outcomeID)
+	if err != nil {
+		return nil, err
+	}
+	if outcome == nil {
+		return nil, database.NewNotFoundErrorWithID("position", outcomeID)
+	}
+	if outcome.OutcomeStatus != "OPEN" {
+		return nil, database.NewValidationError("outcome_status", fmt.Sprintf("position already closed (%s)", outcome.OutcomeStatus))
+	}
+
+	if exitPrice <= 0 {
+		exitPrice, _ = st.GetMarkPrice(outcome.StockSymbol, "BUY")
+		if exitPrice <= 0 {
+			return nil, database.NewValidationError("price", fmt.Sprintf("no market price for %s, supply an exit price", outcome.StockSymbol))
+		}
+	}
+
+	exitReason := models.ExitReasonManualClose
+	if st.cfg.Trading.PaperTradingMode {
+		candle, _ := st.repo.GetLatestCandle(outcome.StockSymbol)
+		exitPrice = st.simulateExitFill(exitPrice, candle, exitReason)
+	}
+
+	now := st.clock.Now()
+	holdingMinutes := st.holdingMinutes(outcome.EntryTime)
+	profitLossPct := ((exitPrice - outcome.EntryPrice) / outcome.EntryPrice) * 100
+
+	outcome.ExitTime = &now
+	outcome.ExitPrice = &exitPrice
+	outcome.ExitReason = &exitReason
+	outcome.HoldingPeriodMinutes = &holdingMinutes
+	outcome.PriceChangePct = &profitLossPct
+	outcome.ProfitLossPct = &profitLossPct
+	outcome.OutcomeStatus = st.closedOutcomeStatus(outcome, profitLossPct)
+
+	saved, err := st.repo.UpdateOpenSignalOutcome(outcome)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, database.NewValidationError("outcome_status", "position already closed")
+	}
+	st.startLossCooldown(outcome)
+	st.publishPosition(outcome, "", exitPrice)
+
+	log.Printf("✋ Manually closed position %d (%s) at %.0f: %s %.2f%%",
+		outcome.ID, outcome.StockSymbol, exitPrice, outcome.OutcomeStatus, profitLossPct)
+	return outcome, nil
+}
+
+// findReverseSignal returns the strongest SELL signal for the symbol generated after entry
+// that meets the reverse-signal confidence threshold, or nil if none qualifies
+func (st *SignalTracker) findReverseSignal(signal *database.TradingSignalDB, outcome *database.SignalOutcome) *database.TradingSignalDB {
+	minConf := st.cfg.Trading.ReverseSignalMinConfidence
+	if minConf <= 0 {
+		return nil
+	}
+
+	sellSignals, err := st.repo.GetTradingSignals(signal.StockSymbol, "", "SELL", outcome.EntryTime, time.Time{}, 10, 0)
+	if err != nil {
+		return nil
+	}
+
+	var strongest *database.TradingSignalDB
+	for i := range sellSignals {
+		if sellSignals[i].Confidence >= minConf && (strongest == nil || sellSignals[i].Confidence > strongest.Confidence) {
+			strongest = &sellSignals[i]
+		}
+	}
+	return strongest
+}
+
+// processExitSignals records an EXIT_SIGNAL for each new SELL signal against the open longs on its
+// symbol, instead of discarding it. Whether the long is actually closed is decided by findReverseSignal.
+func (st *SignalTracker) processExitSignals() {
+	sellSignals, err := st.repo.GetPendingSellSignals(100)
+	if err != nil {
+		log.Printf("❌ Error getting new SELL signals: %v", err)
+		return
+	}
+
+	minConf := st.cfg.Trading.ReverseSignalMinConfidence
+	for _, signal := range sellSignals {
+		openOutcomes, err := st.repo.GetSignalOutcomes(signal.StockSymbol, "OPEN", time.Time{}, time.Time{}, 0, 0)
+		if err != nil {
+			log.Printf("❌ Error getting open positions for SELL signal %d (%s): %v", signal.ID, signal.StockSymbol, err)
+			continue
+		}
+
+		var records []database.ExitSignal
+		flagged := 0
+		for _, outcome := range openOutcomes {
+			if outcome.EntryDecision != "BUY" || !outcome.EntryTime.Before(signal.GeneratedAt) {
+				continue
+			}
+			action := "BELOW_THRESHOLD"
+			if minConf > 0 && signal.Confidence >= minConf {
+				action = "EXIT"
+				flagged++
+			}
+			outcomeID := outcome.ID
+			records = append(records, st.newExitSignal(&signal, &outcomeID, action))
+		}
+		if len(records) == 0 {
+			records = append(records, st.newExitSignal(&signal, nil, "NO_POSITION"))
+		}
+
+		if st.cfg.Trading.DryRun {
+			if flagged > 0 {
+				log.Printf("🧪 DRY RUN: SELL signal %d (%s) would exit %d open long(s) in %s", signal.ID, signal.Strategy, flagged, signal.StockSymbol)
+			}
+			continue
+		}
+		if err := st.repo.SaveExitSignals(records); err != nil {
+			log.Printf("❌ Error saving exit signal for signal %d (%s): %v", signal.ID, signal.StockSymbol, err)
+			continue
+		}
+		if flagged > 0 {
+			log.Printf("🔻 SELL signal %d (%s, conf %.2f) flags %d open long(s) in %s for exit",
+				signal.ID, signal.Strategy, signal.Confidence, flagged, signal.StockSymbol)
+		}
+	}
+}
+
+func (st *SignalTracker) newExitSignal(signal *database.TradingSignalDB, outcomeID *int64, action string) database.ExitSignal {
+	return database.ExitSignal{
+		SignalID:    signal.ID,
+		OutcomeID:   outcomeID,
+		StockSymbol: signal.StockSymbol,
+		Strategy:    signal.Strategy,
+		Confidence:  signal.Confidence,
+		Action:      action,
+	}
+}
+
+// simulateEntryFill applies paper-trading slippage to an entry price (BUY fills above the quote)
+func (st *SignalTracker) simulateEntryFill(price float64) float64 {
+	if !st.cfg.Trading.PaperTradingMode {
+		return price
+	}
+	return helpers.RoundToTick(price * (1 + st.cfg.Trading.SimulateSlippageBps/10000))
+}
+
+// simulateExitFill applies paper-trading slippage to an exit price (SELL fills below the quote)
+// Stop-type exits optionally fill at the candle low to model worst-case execution
+func (st *SignalTracker) simulateExitFill(price float64, candle *database.Candle, exitReason string) float64 {
+	base := price
+	if st.cfg.Trading.WorstCaseStopFills && candle != nil && candle.Low > 0 && candle.Low < base {
+		switch exitReason {
+		case models.ExitReasonATRStopLoss, models.ExitReasonTrailingStopHit, models.ExitReasonTimeBasedCutLoss, models.ExitReasonCatastrophicStop:
+			base = candle.Low
+		}
+	}
+	return helpers.RoundToTick(base * (1 - st.cfg.Trading.SimulateSlippageBps/10000))
 }
 
 // GetOpenPositions returns currently open trading positions with optional filters
@@ -626,11 +1235,15 @@ func (st *SignalTracker) GetOpenPositions(symbol, strategy string, limit int) ([
 
 	// Filter by strategy if provided
 	if strategy != "" && strategy != "ALL" {
+		// OPTIMIZATION: Batch fetch signals to avoid N+1 query problem
+		signalsMap, err := st.repo.GetSignalsByIDs(outcomeSignalIDs(outcomes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch signals for open positions: %w", err)
+		}
+
 		var filtered []database.SignalOutcome
 		for _, outcome := range outcomes {
-			// Get the signal to check strategy
-			signal, err := st.repo.GetSignalByID(outcome.SignalID)
-			if err == nil && signal != nil && signal.Strategy == strategy {
+			if signal, ok := signalsMap[outcome.SignalID]; ok && signal != nil && signal.Strategy == strategy {
 				filtered = append(filtered, outcome)
 			}
 		}
@@ -640,6 +1253,206 @@ func (st *SignalTracker) GetOpenPositions(symbol, strategy string, limit int) ([
 	return outcomes, nil
 }
 
+// outcomeSignalIDs returns the unique signal IDs referenced by a set of outcomes
+func outcomeSignalIDs(outcomes []database.SignalOutcome) []int64 {
+	seen := make(map[int64]bool, len(outcomes))
+	ids := make([]int64, 0, len(outcomes))
+	for _, outcome := range outcomes {
+		if !seen[outcome.SignalID] {
+			seen[outcome.SignalID] = true
+			ids = append(ids, outcome.SignalID)
+		}
+	}
+	return ids
+}
+
+// GetExitLevels returns recommended day and swing exit levels for a symbol at a given entry price
+func (st *SignalTracker) GetExitLevels(symbol string, entryPrice float64) map[string]interface{} {
+	return map[string]interface{}{
+		"day":   st.exitCalc.GetExitLevels(symbol, entryPrice),
+		"swing": st.exitCalc.GetSwingExitLevels(symbol, entryPrice),
+	}
+}
+
+// GetVolatilityPercent returns the symbol's ATR as a percentage of its last close
+func (st *SignalTracker) GetVolatilityPercent(symbol string) (float64, error) {
+	return st.exitCalc.GetVolatilityPercent(symbol)
+}
+
+// GetSignalScorecard returns the scorecard stored when the tracker evaluated a signal, or nil
+// if the signal doesn't exist or was never evaluated
+func (st *SignalTracker) GetSignalScorecard(signalID int64) (*types.SignalScorecard, error) {
+	signal, err := st.repo.GetSignalByID(signalID)
+	if err != nil || signal == nil {
+		return nil, err
+	}
+
+	raw, ok := parseAnalysisData(signal)["scorecard"]
+	if !ok {
+		return nil, nil
+	}
+	var card types.SignalScorecard
+	if err := json.Unmarshal(raw, &card); err != nil {
+		return nil, fmt.Errorf("invalid scorecard for signal %d: %w", signalID, err)
+	}
+	return &card, nil
+}
+
+// recordScorecard saves the scorecard of a tracker evaluation; dry run stores nothing
+func (st *SignalTracker) recordScorecard(signal *database.TradingSignalDB, card *types.SignalScorecard) {
+	if st.cfg.Trading.DryRun {
+		return
+	}
+	if _, err := st.saveScorecard(signal, card); err != nil {
+		log.Printf("⚠️ Failed to persist scorecard for signal %d: %v", signal.ID, err)
+	}
+}
+
+// saveScorecard stores a signal's scorecard in its analysis_data under "scorecard" so outcome
+// tracking and the ML export see the features of the entry decision. Only the first evaluation
+// is kept; returns false when a scorecard was already stored.
+func (st *SignalTracker) saveScorecard(signal *database.TradingSignalDB, card *types.SignalScorecard) (bool, error) {
+	analysis := parseAnalysisData(signal)
+	if _, ok := analysis["scorecard"]; ok {
+		return false, nil
+	}
+
+	raw, err := json.Marshal(card)
+	if err != nil {
+		return false, err
+	}
+	analysis["scorecard"] = raw
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		return false, err
+	}
+	if err := st.repo.UpdateSignalAnalysisData(signal.ID, string(data)); err != nil {
+		return false, err
+	}
+	signal.AnalysisData = string(data)
+	return true, nil
+}
+
+// parseAnalysisData decodes a signal's analysis_data into its top-level keys
+func parseAnalysisData(signal *database.TradingSignalDB) map[string]json.RawMessage {
+	analysis := make(map[string]json.RawMessage)
+	if signal.AnalysisData != "" {
+		if err := json.Unmarshal([]byte(signal.AnalysisData), &analysis); err != nil {
+			log.Printf("⚠️ Invalid analysis_data for signal %d, rebuilding: %v", signal.ID, err)
+			analysis = make(map[string]json.RawMessage)
+		}
+	}
+	return analysis
+}
+
+// skipReason explains why a signal didn't open a position. Code names the check (or the
+// rejecting filter) and stays stable across cycles, while Text carries the live numbers.
+type skipReason struct {
+	Code     string
+	Text     string
+	Verdicts []types.ScorecardComponent // Filter verdicts when the pipeline ran, nil otherwise
+}
+
+// rejection returns the name and reason of the first filter that failed a scorecard
+func rejection(card *types.SignalScorecard) (string, string) {
+	for _, c := range card.Components {
+		if !c.Passed {
+			return c.Name, c.Reason
+		}
+	}
+	return "", ""
+}
+
+// recordSkip stores why a signal was skipped in its analysis_data under "skip_audit"
+// Signals are re-evaluated every cycle while fresh, so the audit is only rewritten when a
+// different check stops the signal; the verdicts come from the evaluation that skipped it.
+func (st *SignalTracker) recordSkip(signal *database.TradingSignalDB, skip skipReason) {
+	if st.cfg.Trading.DryRun {
+		if len(skip.Verdicts) > 0 {
+			log.Printf("🧪 DRY RUN: would skip %s (%s): %s | verdicts: %s", signal.StockSymbol, signal.Strategy, skip.Text,
+				formatVerdicts(skip.Verdicts))
+		} else {
+			log.Printf("🧪 DRY RUN: would skip %s (%s): %s", signal.StockSymbol, signal.Strategy, skip.Text)
+		}
+		return
+	}
+
+	analysis := parseAnalysisData(signal)
+	if raw, ok := analysis["skip_audit"]; ok {
+		var existing types.SkipAudit
+		if err := json.Unmarshal(raw, &existing); err == nil && existing.Code == skip.Code {
+			return
+		}
+	}
+
+	audit := types.SkipAudit{
+		SignalID:  signal.ID,
+		Code:      skip.Code,
+		Reason:    skip.Text,
+		Verdicts:  skip.Verdicts,
+		SkippedAt: st.clock.Now(),
+	}
+
+	raw, err := json.Marshal(audit)
+	if err != nil {
+		return
+	}
+	analysis["skip_audit"] = raw
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		return
+	}
+	if err := st.repo.UpdateSignalAnalysisData(signal.ID, string(data)); err != nil {
+		log.Printf("⚠️ Failed to persist skip audit for signal %d: %v", signal.ID, err)
+		return
+	}
+	signal.AnalysisData = string(data)
+}
+
+// GetSkipAudit returns the recorded skip audit for a signal, or nil if it was never skipped
+func (st *SignalTracker) GetSkipAudit(signalID int64) (*types.SkipAudit, error) {
+	signal, err := st.repo.GetSignalByID(signalID)
+	if err != nil || signal == nil {
+		return nil, err
+	}
+
+	raw, ok := parseAnalysisData(signal)["skip_audit"]
+	if !ok {
+		return nil, nil
+	}
+	var audit types.SkipAudit
+	if err := json.Unmarshal(raw, &audit); err != nil {
+		return nil, fmt.Errorf("invalid skip audit for signal %d: %w", signalID, err)
+	}
+	return &audit, nil
+}
+
+// BackfillScorecards computes and stores scorecards for tracked signals missing a feature vector
+// Note: filters read current market data, so backfilled features approximate the original evaluation
+func (st *SignalTracker) BackfillScorecards(limit int) (int, error) {
+	ids, err := st.repo.GetSignalIDsMissingFeatures(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for _, id := range ids {
+		signal, err := st.repo.GetSignalByID(id)
+		if err != nil || signal == nil {
+			log.Printf("⚠️ Scorecard backfill failed for signal %d: %v", id, err)
+			continue
+		}
+		if saved, err := st.saveScorecard(signal, st.filterService.Scorecard(signal)); err != nil {
+			log.Printf("⚠️ Scorecard backfill failed for signal %d: %v", id, err)
+		} else if saved {
+			filled++
+		}
+	}
+
+	log.Printf("🧠 Backfilled scorecards for %d/%d signals", filled, len(ids))
+	return filled, nil
+}
+
 // isSwingTrade determines if a position is a swing trade
 // Checks: signal confidence, trend strength, and holding duration
 func (st *SignalTracker) isSwingTrade(signal *database.TradingSignalDB, outcome *database.SignalOutcome) bool {
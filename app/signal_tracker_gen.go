@@ -2,8 +2,12 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"stockbit-haka-haki/database"
@@ -19,9 +23,17 @@ func (st *SignalTracker) generateSignals() {
 		return
 	}
 
+	calculatedSignals = st.applyStrategyConfigs(calculatedSignals)
+	if st.cfg.Trading.ConfluenceMerge {
+		calculatedSignals = mergeConfluentSignals(calculatedSignals, st.cfg.Trading.ConfluenceBoost)
+	}
+
 	if len(calculatedSignals) > 0 {
 		// Filter duplicates and save traditional signals
 		signalsToSave := st.filterDuplicateSignals(calculatedSignals)
+		if st.cfg.Trading.DryRun {
+			signalsToSave = st.filterDryRunSeen(signalsToSave)
+		}
 		for _, signal := range signalsToSave {
 			dbSignal := &database.TradingSignalDB{
 				GeneratedAt:       signal.Timestamp,
@@ -37,6 +49,22 @@ func (st *SignalTracker) generateSignals() {
 				Reason:            signal.Reason,
 				AnalysisData:      "{}",
 			}
+			if len(signal.Strategies) > 1 {
+				if data, err := json.Marshal(map[string]interface{}{"confluence_strategies": signal.Strategies}); err == nil {
+					dbSignal.AnalysisData = string(data)
+				}
+			}
+
+			// Dry run: show what would be saved and whether it would open a position, without writing
+			if st.cfg.Trading.DryRun {
+				log.Printf("🧪 DRY RUN: would save %s %s signal for %s @ %.0f (conf %.2f): %s",
+					dbSignal.Strategy, dbSignal.Decision, dbSignal.StockSymbol, dbSignal.TriggerPrice, dbSignal.Confidence, dbSignal.Reason)
+				if _, err := st.createSignalOutcome(dbSignal); err != nil {
+					log.Printf("❌ DRY RUN outcome check failed for %s: %v", dbSignal.StockSymbol, err)
+				}
+				generated++
+				continue
+			}
 
 			if err := st.repo.SaveTradingSignal(dbSignal); err != nil {
 				log.Printf("❌ Error saving traditional signal: %v", err)
@@ -47,8 +75,10 @@ func (st *SignalTracker) generateSignals() {
 				if st.redis != nil {
 					ctx := context.Background()
 					st.redis.Publish(ctx, "signals:new", dbSignal)
-					cooldownKey := fmt.Sprintf("signal:cooldown:%s:%s", signal.StockSymbol, signal.Strategy)
-					st.redis.Set(ctx, cooldownKey, dbSignal.ID, 15*time.Minute)
+					if interval := st.cfg.Trading.SignalIntervalFor(signal.Strategy); interval > 0 {
+						cooldownKey := fmt.Sprintf("signal:cooldown:%s:%s", signal.StockSymbol, signal.Strategy)
+						st.redis.Set(ctx, cooldownKey, dbSignal.ID, time.Duration(interval)*time.Minute)
+					}
 					recentKey := fmt.Sprintf("signal:recent:%s", signal.StockSymbol)
 					st.redis.Set(ctx, recentKey, dbSignal.ID, 5*time.Minute)
 				}
@@ -61,6 +91,139 @@ func (st *SignalTracker) generateSignals() {
 	}
 }
 
+// mergeConfluentSignals collapses BUY/SELL signals that several strategies raised on the same whale alert
+// (same symbol, time and decision) into the highest-confidence one, boosting its confidence by boost per
+// additional strategy. The merged signal keeps that strategy's name; contributors are listed in Strategies.
+func mergeConfluentSignals(signals []database.TradingSignal, boost float64) []database.TradingSignal {
+	type alertKey struct {
+		symbol   string
+		at       int64
+		decision string
+	}
+
+	groups := make(map[alertKey][]int)
+	for i, signal := range signals {
+		if signal.Decision != "BUY" && signal.Decision != "SELL" {
+			continue
+		}
+		key := alertKey{signal.StockSymbol, signal.Timestamp.UnixNano(), signal.Decision}
+		groups[key] = append(groups[key], i)
+	}
+
+	drop := make(map[int]bool)
+	merged := 0
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+
+		best := members[0]
+		for _, i := range members[1:] {
+			if signals[i].Confidence > signals[best].Confidence {
+				best = i
+			}
+		}
+
+		strategies := make([]string, 0, len(members))
+		for _, i := range members {
+			strategies = append(strategies, signals[i].Strategy)
+			if i != best {
+				drop[i] = true
+			}
+		}
+		sort.Strings(strategies)
+
+		factor := 1.0 + boost*float64(len(members)-1)
+		signal := &signals[best]
+		signal.Confidence = math.Min(signal.Confidence*factor, 1.0)
+		signal.Reason += fmt.Sprintf(" (Confluence: %s ×%.2f)", strings.Join(strategies, "+"), factor)
+		signal.Strategies = strategies
+		merged++
+	}
+
+	if len(drop) == 0 {
+		return signals
+	}
+	log.Printf("🤝 Merged %d confluent signals into %d", len(drop)+merged, merged)
+
+	kept := make([]database.TradingSignal, 0, len(signals)-len(drop))
+	for i, signal := range signals {
+		if !drop[i] {
+			kept = append(kept, signal)
+		}
+	}
+	return kept
+}
+
+// applyStrategyConfigs drops signals from strategies an operator disabled via /api/config/strategies,
+// and signals below a strategy's min_confidence_override
+func (st *SignalTracker) applyStrategyConfigs(signals []database.TradingSignal) []database.TradingSignal {
+	configs, err := st.repo.GetStrategyConfigs()
+	if err != nil {
+		log.Printf("⚠️ Failed to load strategy configs, generating for all strategies: %v", err)
+		return signals
+	}
+	if len(configs) == 0 {
+		return signals
+	}
+
+	byStrategy := make(map[string]database.StrategyConfig, len(configs))
+	for _, c := range configs {
+		byStrategy[c.Strategy] = c
+	}
+
+	kept := signals[:0]
+	disabled, belowFloor := 0, 0
+	for _, signal := range signals {
+		c, ok := byStrategy[signal.Strategy]
+		switch {
+		case ok && !c.Enabled:
+			disabled++
+		case ok && c.MinConfidenceOverride != nil && signal.Confidence < *c.MinConfidenceOverride:
+			belowFloor++
+		default:
+			kept = append(kept, signal)
+		}
+	}
+
+	if disabled > 0 || belowFloor > 0 {
+		log.Printf("🎛️ Strategy configs skipped %d signals (%d disabled strategy, %d below confidence override)",
+			disabled+belowFloor, disabled, belowFloor)
+	}
+	return kept
+}
+
+// dryRunSeenTTL bounds the dry-run seen-set; signals come from alerts in the last 60 minutes
+const dryRunSeenTTL = 2 * time.Hour
+
+// filterDryRunSeen drops signals already reported by an earlier dry-run cycle (same whale alert and
+// strategy) and remembers the rest, since dry-run signals never reach the database
+func (st *SignalTracker) filterDryRunSeen(signals []database.TradingSignal) []database.TradingSignal {
+	st.dryRunMu.Lock()
+	defer st.dryRunMu.Unlock()
+
+	now := st.clock.Now()
+	if st.dryRunSeen == nil {
+		st.dryRunSeen = make(map[string]time.Time)
+	}
+	for key, seenAt := range st.dryRunSeen {
+		if now.Sub(seenAt) > dryRunSeenTTL {
+			delete(st.dryRunSeen, key)
+		}
+	}
+
+	var fresh []database.TradingSignal
+	for _, signal := range signals {
+		key := fmt.Sprintf("%s:%s:%s:%d", signal.StockSymbol, signal.Strategy, signal.Decision, signal.Timestamp.Unix())
+		if _, seen := st.dryRunSeen[key]; seen {
+			continue
+		}
+		st.dryRunSeen[key] = now
+		fresh = append(fresh, signal)
+	}
+	return fresh
+}
+
 // filterDuplicateSignals removes signals that have already been saved
 // Uses Redis batch check for performance (O(1) instead of O(N) database queries)
 func (st *SignalTracker) filterDuplicateSignals(signals []database.TradingSignal) []database.TradingSignal {
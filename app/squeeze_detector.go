@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"stockbit-haka-haki/config"
+	"stockbit-haka-haki/database"
+	models "stockbit-haka-haki/database/models_pkg"
+	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/realtime"
+)
+
+// Squeeze detection cadence: one check per 5-minute candle; a symbol stays quiet for the
+// cooldown after being reported because a squeeze usually persists for several candles
+const (
+	squeezeCheckInterval = 5 * time.Minute
+	squeezeCooldown      = 2 * time.Hour
+	squeezeFreshness     = 15 * time.Minute // Latest candle must be this recent (skips halted/illiquid names)
+)
+
+// SqueezeDetector emits SQUEEZE patterns when a symbol's Bollinger band width contracts to
+// its narrowest over the lookback, which tends to precede a volatility expansion
+type SqueezeDetector struct {
+	repo   *database.TradeRepository
+	broker *realtime.Broker
+	cfg    *config.Config
+	done   chan bool
+
+	// Symbols reported recently (symbol -> detection time), see squeezeCooldown
+	announced map[string]time.Time
+}
+
+// NewSqueezeDetector creates a new Bollinger squeeze detector
+func NewSqueezeDetector(repo *database.TradeRepository, broker *realtime.Broker, cfg *config.Config) *SqueezeDetector {
+	return &SqueezeDetector{
+		repo:      repo,
+		broker:    broker,
+		cfg:       cfg,
+		done:      make(chan bool),
+		announced: make(map[string]time.Time),
+	}
+}
+
+// Start begins the detection loop
+func (sd *SqueezeDetector) Start() {
+	log.Printf("🗜️ Squeeze Detector started (%d-candle bands at a %d-candle low, breakout boost x%.2f)",
+		sd.cfg.SqueezeBandPeriod, sd.cfg.SqueezeLookbackBars, sd.cfg.SqueezeBreakoutBoost)
+
+	ticker := time.NewTicker(squeezeCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sd.detect(time.Now())
+		case <-sd.done:
+			log.Println("🗜️ Squeeze Detector stopped")
+			return
+		}
+	}
+}
+
+// Stop stops the detection loop
+func (sd *SqueezeDetector) Stop() {
+	sd.done <- true
+}
+
+// detect looks for new squeezes while a trading session is running
+func (sd *SqueezeDetector) detect(now time.Time) {
+	local := now.In(marketLocation())
+	if weekday := local.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return
+	}
+	if session := sd.cfg.Sessions.SessionAt(local); session != "SESSION_1" && session != "SESSION_2" {
+		return
+	}
+
+	for symbol, at := range sd.announced {
+		if now.Sub(at) >= squeezeCooldown {
+			delete(sd.announced, symbol)
+		}
+	}
+
+	squeezes, err := sd.repo.GetBollingerSqueezes(sd.cfg.SqueezeBandPeriod, sd.cfg.SqueezeLookbackBars, now.Add(-squeezeFreshness))
+	if err != nil {
+		log.Printf("⚠️  Squeeze detection failed: %v", err)
+		return
+	}
+
+	for _, squeeze := range squeezes {
+		if _, seen := sd.announced[squeeze.StockSymbol]; seen {
+			continue
+		}
+
+		pattern := newSqueezePattern(squeeze, now)
+		if err := sd.repo.SaveDetectedPattern(pattern); err != nil {
+			log.Printf("⚠️  Failed to save SQUEEZE pattern for %s: %v", squeeze.StockSymbol, err)
+			continue
+		}
+		sd.announced[squeeze.StockSymbol] = now
+
+		log.Printf("🗜️ SQUEEZE %s | band width %.2f%% (prior low %.2f%%, avg %.2f%%) | bands %.0f-%.0f",
+			squeeze.StockSymbol, squeeze.WidthPct, squeeze.MinPriorWidth, squeeze.AvgPriorWidth, squeeze.Lower, squeeze.Upper)
+
+		if sd.broker != nil {
+			sd.broker.Broadcast("squeeze_pattern", pattern)
+		}
+	}
+}
+
+// newSqueezePattern builds the pattern. A squeeze has no direction; the upper band is the
+// breakout level, the lower band the stop side, and confidence grows with how tight the band
+// is relative to its lookback average
+func newSqueezePattern(squeeze types.BollingerSqueeze, now time.Time) *models.DetectedPattern {
+	tightness := 0.0
+	if squeeze.AvgPriorWidth > 0 {
+		tightness = math.Max(1-squeeze.WidthPct/squeeze.AvgPriorWidth, 0)
+	}
+	confidence := math.Min(0.5+tightness*0.45, 0.9999) // decimal(5,4)
+
+	bandRange := squeeze.Upper - squeeze.Lower
+	upper, lower := squeeze.Upper, squeeze.Lower
+	profile := fmt.Sprintf("BB width %.2f%% vs %.2f%% avg over %d candles (prior low %.2f%%)",
+		squeeze.WidthPct, squeeze.AvgPriorWidth, squeeze.Samples, squeeze.MinPriorWidth)
+	candle := squeeze.Bucket
+
+	return &models.DetectedPattern{
+		StockSymbol:   squeeze.StockSymbol,
+		DetectedAt:    now,
+		PatternType:   "SQUEEZE",
+		Confidence:    confidence,
+		PatternEnd:    &candle,
+		PriceRange:    &bandRange,
+		VolumeProfile: &profile,
+		BreakoutLevel: &upper,
+		StopLoss:      &lower,
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"stockbit-haka-haki/config"
+	"stockbit-haka-haki/database"
+)
+
+// symbolFilterRefreshInterval controls how often DB-managed lists are reloaded
+const symbolFilterRefreshInterval = time.Minute
+
+// SymbolFilterService decides which symbols are processed for whale alerts and signals
+// Lists from config (SYMBOL_WHITELIST / SYMBOL_BLACKLIST) are merged with the symbol_filters table
+type SymbolFilterService struct {
+	repo *database.TradeRepository
+	cfg  *config.Config
+
+	mu        sync.RWMutex
+	whitelist map[string]bool
+	blacklist map[string]bool
+	loadedAt  time.Time
+}
+
+// NewSymbolFilterService creates the service and loads the initial lists
+func NewSymbolFilterService(repo *database.TradeRepository, cfg *config.Config) *SymbolFilterService {
+	sf := &SymbolFilterService{repo: repo, cfg: cfg}
+	if err := sf.Refresh(); err != nil {
+		log.Printf("⚠️ Failed to load symbol filters: %v", err)
+	}
+	return sf
+}
+
+// Refresh reloads the lists from config and the database
+func (sf *SymbolFilterService) Refresh() error {
+	whitelist := make(map[string]bool)
+	blacklist := make(map[string]bool)
+	for _, s := range sf.cfg.SymbolWhitelist {
+		whitelist[strings.ToUpper(s)] = true
+	}
+	for _, s := range sf.cfg.SymbolBlacklist {
+		blacklist[strings.ToUpper(s)] = true
+	}
+
+	filters, err := sf.repo.GetSymbolFilters()
+	if err == nil {
+		for _, f := range filters {
+			switch f.ListType {
+			case "WHITELIST":
+				whitelist[f.StockSymbol] = true
+			case "BLACKLIST":
+				blacklist[f.StockSymbol] = true
+			}
+		}
+	}
+
+	sf.mu.Lock()
+	sf.whitelist = whitelist
+	sf.blacklist = blacklist
+	sf.loadedAt = time.Now()
+	sf.mu.Unlock()
+
+	return err
+}
+
+// IsAllowed reports whether a symbol passes the whitelist and blacklist
+func (sf *SymbolFilterService) IsAllowed(symbol string) bool {
+	// Claim the reload by bumping loadedAt so concurrent callers don't all hit the DB
+	sf.mu.Lock()
+	stale := time.Since(sf.loadedAt) > symbolFilterRefreshInterval
+	if stale {
+		sf.loadedAt = time.Now()
+	}
+	sf.mu.Unlock()
+	if stale {
+		if err := sf.Refresh(); err != nil {
+			log.Printf("⚠️ Failed to refresh symbol filters: %v", err)
+		}
+	}
+
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+
+	if sf.blacklist[symbol] {
+		return false
+	}
+	return len(sf.whitelist) == 0 || sf.whitelist[symbol]
+}
+
+// Lists returns the effective whitelist and blacklist (config + database), sorted
+func (sf *SymbolFilterService) Lists() (whitelist, blacklist []string) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+
+	for s := range sf.whitelist {
+		whitelist = append(whitelist, s)
+	}
+	for s := range sf.blacklist {
+		blacklist = append(blacklist, s)
+	}
+	sort.Strings(whitelist)
+	sort.Strings(blacklist)
+	return whitelist, blacklist
+}
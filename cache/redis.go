@@ -3,16 +3,33 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// Per-call timeout and circuit breaker settings
+// A slow or erroring Redis must not stall the signal loop; callers already fall back to the DB on error
+const (
+	opTimeout        = 500 * time.Millisecond
+	breakerThreshold = 5                // Consecutive failures before Redis is bypassed
+	breakerCooldown  = 30 * time.Second // How long Redis is bypassed once the breaker opens
+)
+
+// ErrCircuitOpen is returned while Redis is bypassed after repeated failures
+var ErrCircuitOpen = errors.New("redis circuit breaker open")
+
 // RedisClient wraps redis.Client
 type RedisClient struct {
 	client *redis.Client
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
 }
 
 // NewRedisClient creates a new Redis client
@@ -37,6 +54,37 @@ func NewRedisClient(host, port, password string) *RedisClient {
 	return &RedisClient{client: client}
 }
 
+// begin checks the circuit breaker and bounds ctx by the per-call timeout
+func (r *RedisClient) begin(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	r.mu.Lock()
+	open := time.Now().Before(r.openUntil)
+	r.mu.Unlock()
+	if open {
+		return ctx, func() {}, ErrCircuitOpen
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opTimeout)
+	return ctx, cancel, nil
+}
+
+// record tracks the outcome of a call; a cache miss counts as success
+func (r *RedisClient) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil || errors.Is(err, redis.Nil) {
+		r.failures = 0
+		return
+	}
+
+	r.failures++
+	if r.failures >= breakerThreshold && !time.Now().Before(r.openUntil) {
+		r.openUntil = time.Now().Add(breakerCooldown)
+		log.Printf("⚠️  Redis failing (%d consecutive errors, last: %v), bypassing for %s", r.failures, err, breakerCooldown)
+		r.failures = 0
+	}
+}
+
 // Set stores a value in Redis with expiration
 func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	if r.client == nil {
@@ -48,7 +96,15 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ex
 		return err
 	}
 
-	return r.client.Set(ctx, key, jsonBytes, expiration).Err()
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	err = r.client.Set(ctx, key, jsonBytes, expiration).Err()
+	r.record(err)
+	return err
 }
 
 // Get retrieves a value from Redis
@@ -57,7 +113,14 @@ func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) err
 		return fmt.Errorf("redis client not initialized")
 	}
 
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	val, err := r.client.Get(ctx, key).Result()
+	r.record(err)
 	if err != nil {
 		return err
 	}
@@ -65,12 +128,39 @@ func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) err
 	return json.Unmarshal([]byte(val), dest)
 }
 
+// SetNX stores a value only if the key does not exist
+// Returns true when the key was set (i.e. first writer wins)
+func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	if r.client == nil {
+		return false, fmt.Errorf("redis client not initialized")
+	}
+
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer cancel()
+
+	ok, err := r.client.SetNX(ctx, key, value, expiration).Result()
+	r.record(err)
+	return ok, err
+}
+
 // Delete removes a key from Redis
 func (r *RedisClient) Delete(ctx context.Context, key string) error {
 	if r.client == nil {
 		return fmt.Errorf("redis client not initialized")
 	}
-	return r.client.Del(ctx, key).Err()
+
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	err = r.client.Del(ctx, key).Err()
+	r.record(err)
+	return err
 }
 
 // Close closes the Redis connection
@@ -92,7 +182,15 @@ func (r *RedisClient) Publish(ctx context.Context, channel string, message inter
 		return err
 	}
 
-	return r.client.Publish(ctx, channel, jsonBytes).Err()
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	err = r.client.Publish(ctx, channel, jsonBytes).Err()
+	r.record(err)
+	return err
 }
 
 // Subscribe subscribes to a channel
@@ -109,7 +207,14 @@ func (r *RedisClient) Exists(ctx context.Context, key string) bool {
 		return false
 	}
 
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return false
+	}
+	defer cancel()
+
 	result, err := r.client.Exists(ctx, key).Result()
+	r.record(err)
 	if err != nil {
 		return false
 	}
@@ -132,7 +237,14 @@ func (r *RedisClient) MGet(ctx context.Context, keys []string, dest interface{})
 	keysInterface := make([]string, len(keys))
 	copy(keysInterface, keys)
 
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	results, err := r.client.MGet(ctx, keysInterface...).Result()
+	r.record(err)
 	if err != nil {
 		return err
 	}
@@ -166,3 +278,31 @@ func (r *RedisClient) MGet(ctx context.Context, keys []string, dest interface{})
 
 	return nil
 }
+
+// Available reports whether calls currently reach Redis, i.e. the client is connected and the
+// circuit breaker is closed. Unlike Ping it doesn't make a round trip.
+func (r *RedisClient) Available() bool {
+	if r == nil || r.client == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !time.Now().Before(r.openUntil)
+}
+
+// Ping checks whether the Redis server is reachable
+func (r *RedisClient) Ping(ctx context.Context) error {
+	if r.client == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+
+	ctx, cancel, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	err = r.client.Ping(ctx).Err()
+	r.record(err)
+	return err
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -30,16 +34,118 @@ type Config struct {
 	// LLM configuration
 	LLM LLMConfig
 
+	// HTTP API configuration
+	API APIConfig
+
+	// Webhook configuration
+	WebhookMinConfidence float64 // Global floor on WhaleAlert.ConfidenceScore (0-100 scale)
+
+	// Whale alert severity tiers (alerts below WhaleSeverityAlertZ are WARN)
+	WhaleSeverityAlertZ    float64
+	WhaleSeverityCriticalZ float64
+
+	// Whale detection statistics
+	WhaleStatsLookbackMinutes int // Window of 1-minute candles behind mean/stddev volume
+	WhaleStatsMinSamples      int // Fewer candles than this falls back to the hard lot/value thresholds
+
+	// Trade processing
+	TradeWorkerPoolSize  int  // Whale detection workers; trades are routed by symbol hash to keep per-symbol order
+	TradeBatchSize       int  // Trades buffered before a multi-row INSERT
+	TradeBatchFlushMs    int  // Max milliseconds a trade waits before the buffer is flushed
+	TradeDedupTTLMinutes int  // Redis dedup key lifetime for trade numbers (0 disables)
+	TradeIncludeOddLots  bool // Count odd-lot trades (not a whole number of lots) in whale detection and order flow
+	TradeRejectInvalid   bool // Drop trades with an empty symbol or non-positive price/volume (false only counts them)
+
+	// Pre-opening auction
+	PreOpenCaptureEnabled bool // Record PRE_OPENING indicative price and imbalance from order book updates
+
+	// Signal generation
+	SignalWorkerPoolSize int // Symbols evaluated concurrently per signal generation cycle
+
+	// Background jobs
+	PerformanceRefreshMinutes int // Interval between strategy_performance_daily refreshes
+
+	// TimescaleDB retention windows
+	Retention RetentionConfig
+
+	// Opening gap detection (today's open vs the previous daily close)
+	GapMinPct        float64 // Minimum absolute gap % to emit GAP_UP/GAP_DOWN (0 disables)
+	GapMinVolumePct  float64 // Volume since the open as % of the 20-day average daily volume
+	GapWindowMinutes int     // Minutes after the SESSION_1 open during which gaps are checked
+
+	// Bollinger band squeeze detection on 5-minute candles
+	SqueezeLookbackBars  int     // Candles the current band width must be the narrowest of (0 disables)
+	SqueezeBandPeriod    int     // Candles in the band's SMA / standard deviation
+	SqueezeBreakoutBoost float64 // Confidence multiplier for VOLUME_BREAKOUT signals after a recent SQUEEZE (1 disables)
+
+	// Symbol filtering (merged with the symbol_filters table)
+	SymbolWhitelist []string // When non-empty, only these symbols are processed
+	SymbolBlacklist []string // Always excluded
+
+	// IDX trading session boundaries (WIB)
+	Sessions SessionConfig
+
 	// Trading configuration
 	Trading TradingConfig
 }
 
+// defaultSessionSchedule is the regular IDX schedule; each session starts at its time and runs until the next
+const defaultSessionSchedule = "PRE_OPENING=08:45,SESSION_1=09:00,LUNCH_BREAK=12:00,SESSION_2=13:30,PRE_CLOSING=14:50,POST_MARKET=15:00,AFTER_HOURS=16:00"
+
+// SessionBoundary marks the WIB wall-clock time at which a trading session starts
+type SessionBoundary struct {
+	Name   string
+	Minute int // Minutes since midnight WIB
+}
+
+// SessionConfig holds the trading session schedule, with an optional date-range override
+// for temporary exchange hours such as Ramadan
+type SessionConfig struct {
+	Default      []SessionBoundary
+	Override     []SessionBoundary
+	OverrideFrom string // YYYY-MM-DD (WIB), inclusive
+	OverrideTo   string // YYYY-MM-DD (WIB), inclusive
+}
+
+// RetentionConfig holds retention windows (days) for the raw tape and the tables derived from it.
+// Hard dependencies: an outcome needs its signal row, and an OPEN outcome needs the trades from its
+// entry until it closes. Alerts and signals store their own prices and scores, so they may outlive the tape.
+type RetentionConfig struct {
+	TradesDays      int // running_trades
+	WhaleAlertsDays int // whale_alerts (detected from running_trades)
+	SignalsDays     int // trading_signals (generated from whale alerts and trades)
+	OutcomesDays    int // signal_outcomes (one per signal)
+}
+
 // LLMConfig holds LLM service configuration
 type LLMConfig struct {
-	Enabled  bool
-	Endpoint string
-	APIKey   string
-	Model    string
+	Enabled          bool
+	Endpoint         string
+	APIKey           string
+	Model            string
+	MaxContextTokens int // Rough cap on database context sent with custom prompts (0 disables)
+	CacheTTLSeconds  int // Reuse non-streaming insights for identical prompts within this window (0 disables)
+}
+
+// APIConfig holds HTTP API protection settings
+type APIConfig struct {
+	RateLimitEnabled        bool
+	RateLimitRPS            float64 // Sustained requests per second per IP
+	RateLimitBurst          int     // Burst size per IP
+	ExpensiveRateLimitRPS   float64 // Limit for LLM and streaming endpoints
+	ExpensiveRateLimitBurst int
+	TrustedProxies          []string // Proxy IPs/CIDRs whose X-Forwarded-For is used for the client IP; empty ignores the header
+
+	APIKey           string // Required on write/LLM routes when set; empty disables auth
+	AuthProtectReads bool   // Also require the key on read-only endpoints
+
+	MLExportNotional float64 // Position size in Rupiah used to compute absolute P&L in the ML export
+
+	SSEMaxClients   int // Concurrent SSE connections; further clients get 503
+	SSEClientBuffer int // Messages buffered per SSE client before the oldest are dropped
+	SSEReplayBuffer int // Recent /api/events events kept for clients reconnecting with Last-Event-ID (0 disables)
+
+	HealthMaxCandleLagMinutes int // /health fails when the newest candle_1min bucket is older than this during a session
 }
 
 // TradingConfig holds trading parameters and thresholds
@@ -50,19 +156,103 @@ type TradingConfig struct {
 	MaxPositionsPerSymbol    int
 	SignalTimeWindowMinutes  int
 
+	// Per-strategy overrides for MinSignalIntervalMinutes (strategy -> minutes)
+	StrategySignalIntervals map[string]int
+
+	// Per-strategy caps on open positions, enforced alongside MaxOpenPositions (strategy -> count)
+	StrategyMaxOpenPositions map[string]int
+
+	// Only generate signals from regular-board (RG) whale alerts; TN cash-board alerts are skipped too
+	SignalRGOnly bool
+
+	// Scale-in: a qualifying signal on a symbol with an open position is averaged into it
+	// (volume-weighted entry, larger size) instead of being rejected; off keeps one fill per position
+	ScaleInEnabled bool
+	MaxScaleIns    int // Additional fills allowed per position
+
+	// Confluence: same-direction signals from several strategies on one whale alert are merged into one
+	// signal (highest-confidence strategy) with confidence boosted per additional agreeing strategy
+	ConfluenceMerge bool
+	ConfluenceBoost float64 // Confidence boost per additional strategy (0.15 = ×1.15 for two, ×1.30 for three)
+
+	// Per-filter switches for the signal filter pipeline (filter key -> enabled); filters not listed
+	// stay enabled. Used to measure each filter's marginal contribution by turning it off
+	FilterToggles map[string]bool
+
+	// Log generated signals, would-be positions and filter verdicts without saving them (testing filter changes on live data)
+	DryRun bool
+
+	// Price used to mark open outcomes and manual closes: candle_close, last_trade, bid or mid
+	// (bid/mid read the cached top of book and fall back to the last trade when it's missing)
+	PriceSource string
+
 	// Thresholds
 	MinBaselineSampleSize       int
 	MinBaselineSampleSizeStrict int
+	ZScoreClamp                 float64 // Baseline z-scores are clamped to ±this value (logged when hit)
+	MinBaselineStdDevPct        float64 // Skip baselines whose price or volume stddev is below this % of the mean
+	BaselineDecayAfterMinutes   int     // Baseline age after which signal confidence starts decaying (0 disables)
+	BaselineDecayPerHour        float64 // Confidence fraction lost per hour of baseline age past the threshold
 
 	// Strategy Performance
 	MinStrategySignals   int
 	LowWinRateThreshold  float64 // Percent
 	HighWinRateThreshold float64 // Percent
+	RegimeMinSamples     int     // Closed trades required before a strategy × regime win rate can shrink its position size
 
 	// Risk Management
 	MaxHoldingLossPct    float64 // Cut loss if held too long and loss exceeds this (positive value representing negative %)
 	MaxDailyLossPct      float64 // Maximum daily loss percentage before stopping trading
 	MaxConsecutiveLosses int     // Maximum consecutive losses before circuit breaker
+	LossCooldownMinutes  int     // Block new positions in a symbol for this long after it closes as LOSS (0 disables)
+
+	// Holding Period (day trades)
+	MaxHoldingMinutes         int            // Max intraday holding before forced profit-taking; time-decay starts at half of this
+	StrategyMaxHoldingMinutes map[string]int // Per-strategy overrides for MaxHoldingMinutes (strategy -> minutes)
+
+	// Confidence Calibration
+	ConfidenceMode   string               // "linear" (z-score interpolation) or "logistic" (calibrated P(win))
+	ConfidenceModels map[string][]float64 // Per-strategy logistic coefficients: intercept, price_z, volume_z, aggressive_buy, price_change
+
+	// Reverse Signal Exit
+	ReverseSignalMinConfidence float64 // Close a long when a SELL signal on the same symbol reaches this confidence (0 disables)
+
+	// Multi-Timeframe Confirmation
+	MTFTimeframes         []string       // Timeframes checked for trend alignment (e.g. 5min, 15min)
+	MTFMinAligned         int            // Timeframes that must be in an uptrend before a BUY is tracked (0 disables)
+	StrategyMTFMinAligned map[string]int // Per-strategy overrides for MTFMinAligned (strategy -> count)
+
+	// Mean Reversion RSI Confirmation
+	MeanReversionRSITimeframe  string  // Candle timeframe the RSI is computed on
+	MeanReversionRSIPeriod     int     // RSI lookback in bars (0 disables the confirmation)
+	MeanReversionRSIOverbought float64 // SELL requires RSI above this
+	MeanReversionRSIOversold   float64 // BUY requires RSI below this
+
+	// Liquidity
+	MinAvgDailyValue      float64 // Minimum average daily traded value (Rupiah) for a symbol to be traded (0 disables)
+	LiquidityLookbackDays int     // Completed sessions averaged for MinAvgDailyValue
+
+	// Spread / top-of-book depth (from the latest order book update)
+	MaxSpreadBps    float64 // Widest bid-ask spread, in bps of the mid price, at which a signal is taken (0 disables)
+	MinTopBookValue float64 // Minimum Rupiah value queued at the best offer (BUY) or bid (SELL) (0 disables)
+
+	// Data Quarantine (symbols whose baselines/candles keep failing sanity checks)
+	QuarantineAnomalies     int // Anomalous signal evaluations within the window that quarantine a symbol (0 disables)
+	QuarantineWindowMinutes int // Window the anomalies are counted over
+	QuarantineMinutes       int // How long a quarantined symbol is skipped before being re-evaluated
+
+	// Followup Reliability
+	FollowupReliabilityWeight float64 // Max boost/penalty from a symbol's whale alert hit rate (0.3 = 0.7x-1.3x, 0 disables)
+	FollowupMinSamples        int     // Completed followups required before the hit rate is used
+	FollowupLookbackDays      int     // Followups considered
+
+	// Opening Volatility
+	OpeningBlackoutMinutes     int            // Minutes after the SESSION_1 open in which new BUYs are blocked (0 disables)
+	OpeningBlackoutTierMinutes map[string]int // Per symbol tier overrides (symbol_meta.tier -> minutes), e.g. liquid large caps at 0
+
+	// Order Flow Freshness
+	OrderFlowMaxAgeMinutes int  // Order flow older than this is not used to judge a BUY
+	RequireOrderFlow       bool // Reject BUY signals when order flow is missing or stale instead of ignoring it
 
 	// ATR Multipliers
 	StopLossATRMultiplier     float64
@@ -70,10 +260,27 @@ type TradingConfig struct {
 	TakeProfit1ATRMultiplier  float64
 	TakeProfit2ATRMultiplier  float64
 
+	// Regime-aware take profit: TP1/TP2 percentages are scaled by the symbol's latest regime
+	TakeProfitRegimeMultipliers map[string]float64 // Regime (TRENDING_UP, RANGING, ...) -> multiplier; unlisted regimes use 1.0
+
+	// Trailing Stop Activation
+	TrailActivationPct float64 // Profit percentage before the trailing stop starts moving (0 trails from any profit)
+
+	// Stop grace period
+	MinHoldBeforeStopMinutes int // Minutes after entry during which only a catastrophic loss triggers the stop (0 disables)
+
 	// Breakeven Settings
 	BreakevenTriggerPct float64 // Profit percentage to trigger breakeven stop
 	BreakevenBufferPct  float64 // Buffer above entry price for breakeven stop
 
+	// Outcome Classification
+	BreakevenBandPct     float64 // Closed P/L within ±this % is BREAKEVEN rather than WIN/LOSS
+	BreakevenBandATRMult float64 // Widen the band to this multiple of entry ATR (as % of entry) when larger; 0 disables
+
+	// IDX Trading Rules
+	TickSchedule string // Price fraction bands "upTo:tick,...,*:tick"; empty uses the IDX default
+	LotSize      int    // Shares per lot
+
 	// Swing Trading Configuration
 	EnableSwingTrading   bool    // Enable swing trading mode
 	SwingMinConfidence   float64 // Minimum confidence for swing signals (higher than day trading)
@@ -85,6 +292,11 @@ type TradingConfig struct {
 
 	// Testing & Simulation
 	MockTradingMode bool // Bypass strict market hours and trend checks for simulation
+
+	// Paper Trading (fill simulation)
+	PaperTradingMode    bool    // Simulate realistic fills instead of using raw candle close
+	SimulateSlippageBps float64 // Slippage + half-spread in basis points applied to entries and exits
+	WorstCaseStopFills  bool    // Fill stop exits at the candle low instead of the close
 }
 
 // LoadFromEnv loads configuration from environment variables
@@ -118,6 +330,85 @@ func LoadFromEnv() *Config {
 			Endpoint: getEnvOrDefault("LLM_ENDPOINT", "https://ai.onehub.biz.id/v1"),
 			APIKey:   getEnvOrDefault("LLM_API_KEY", ""),
 			Model:    getEnvOrDefault("LLM_MODEL", "qwen3-max"),
+
+			MaxContextTokens: getEnvInt("LLM_MAX_CONTEXT_TOKENS", 4000),
+			CacheTTLSeconds:  getEnvInt("LLM_CACHE_TTL_SECONDS", 300),
+		},
+
+		// Webhook configuration - 0 keeps per-webhook filters only
+		WebhookMinConfidence: getEnvFloat("WEBHOOK_MIN_CONFIDENCE", 0),
+
+		// Whale alert severity tiers - WARN below 5σ, ALERT from 5σ, CRITICAL from 8σ
+		WhaleSeverityAlertZ:    getEnvFloat("WHALE_SEVERITY_ALERT_Z", 5.0),
+		WhaleSeverityCriticalZ: getEnvFloat("WHALE_SEVERITY_CRITICAL_Z", 8.0),
+
+		// Whale detection statistics - 1 hour of candles, at least 10 of them
+		WhaleStatsLookbackMinutes: getEnvInt("WHALE_STATS_LOOKBACK_MINUTES", 60),
+		WhaleStatsMinSamples:      getEnvInt("WHALE_STATS_MIN_SAMPLES", 10),
+
+		// Trade processing
+		TradeWorkerPoolSize:  getEnvInt("TRADE_WORKER_POOL_SIZE", 5),
+		TradeBatchSize:       getEnvInt("TRADE_BATCH_SIZE", 500),
+		TradeBatchFlushMs:    getEnvInt("TRADE_BATCH_FLUSH_MS", 500),
+		TradeDedupTTLMinutes: getEnvInt("TRADE_DEDUP_TTL_MINUTES", 1440), // One WIB trading day
+		TradeIncludeOddLots:  getEnvOrDefault("TRADE_INCLUDE_ODD_LOTS", "false") == "true",
+		TradeRejectInvalid:   getEnvOrDefault("TRADE_REJECT_INVALID", "true") == "true",
+
+		// Pre-opening auction capture - off by default
+		PreOpenCaptureEnabled: getEnvOrDefault("PREOPEN_CAPTURE_ENABLED", "false") == "true",
+
+		// Signal generation - bounded so a burst of alerts can't exhaust the DB pool
+		SignalWorkerPoolSize: getEnvInt("SIGNAL_WORKER_POOL_SIZE", 4),
+
+		// Background jobs
+		PerformanceRefreshMinutes: getEnvInt("PERFORMANCE_REFRESH_MINUTES", 5),
+
+		// Opening gaps - checked each minute during the first half hour of SESSION_1
+		GapMinPct:        getEnvFloat("GAP_MIN_PCT", 3.0),
+		GapMinVolumePct:  getEnvFloat("GAP_MIN_VOLUME_PCT", 5.0),
+		GapWindowMinutes: getEnvInt("GAP_WINDOW_MINUTES", 30),
+
+		// Bollinger squeeze - 20-candle bands at a 100-candle (about two trading days) low
+		SqueezeLookbackBars:  getEnvInt("SQUEEZE_LOOKBACK_BARS", 100),
+		SqueezeBandPeriod:    getEnvInt("SQUEEZE_BAND_PERIOD", 20),
+		SqueezeBreakoutBoost: getEnvFloat("SQUEEZE_BREAKOUT_BOOST", 1.15),
+
+		// Retention - defaults match the previously hardcoded policies (see RetentionConfig for dependencies)
+		Retention: RetentionConfig{
+			TradesDays:      getEnvInt("RETENTION_TRADES_DAYS", 90),
+			WhaleAlertsDays: getEnvInt("RETENTION_WHALE_ALERTS_DAYS", 365),
+			SignalsDays:     getEnvInt("RETENTION_SIGNALS_DAYS", 730),
+			OutcomesDays:    getEnvInt("RETENTION_OUTCOMES_DAYS", 730),
+		},
+
+		// Symbol filtering - e.g. SYMBOL_WHITELIST=BBCA,BBRI,TLKM
+		SymbolWhitelist: getEnvList("SYMBOL_WHITELIST", ""),
+		SymbolBlacklist: getEnvList("SYMBOL_BLACKLIST", ""),
+
+		// Trading sessions - e.g. Ramadan: MARKET_SESSIONS_OVERRIDE=...,LUNCH_BREAK=11:30,...
+		Sessions: SessionConfig{
+			Default:      getEnvSessions("MARKET_SESSIONS", defaultSessionSchedule),
+			Override:     getEnvSessions("MARKET_SESSIONS_OVERRIDE", ""),
+			OverrideFrom: os.Getenv("MARKET_SESSIONS_OVERRIDE_FROM"),
+			OverrideTo:   os.Getenv("MARKET_SESSIONS_OVERRIDE_TO"),
+		},
+
+		// HTTP API configuration
+		API: APIConfig{
+			RateLimitEnabled:        getEnvOrDefault("API_RATE_LIMIT_ENABLED", "true") == "true",
+			RateLimitRPS:            getEnvFloat("API_RATE_LIMIT_RPS", 10.0),
+			RateLimitBurst:          getEnvInt("API_RATE_LIMIT_BURST", 30),
+			ExpensiveRateLimitRPS:   getEnvFloat("API_EXPENSIVE_RATE_LIMIT_RPS", 0.2), // 1 request per 5s
+			ExpensiveRateLimitBurst: getEnvInt("API_EXPENSIVE_RATE_LIMIT_BURST", 3),
+			TrustedProxies:          getEnvList("API_TRUSTED_PROXIES", ""),
+			APIKey:                  os.Getenv("API_KEY"),
+			AuthProtectReads:        getEnvOrDefault("API_AUTH_PROTECT_READS", "false") == "true",
+			MLExportNotional:        getEnvFloat("ML_EXPORT_NOTIONAL", 10000000), // Rp 10 juta
+			SSEMaxClients:           getEnvInt("API_SSE_MAX_CLIENTS", 100),
+			SSEClientBuffer:         getEnvInt("API_SSE_CLIENT_BUFFER", 100),
+			SSEReplayBuffer:         getEnvInt("API_SSE_REPLAY_BUFFER", 500),
+
+			HealthMaxCandleLagMinutes: getEnvInt("HEALTH_MAX_CANDLE_LAG_MINUTES", 5),
 		},
 
 		// Trading configuration - Relaxed for mock trading / active signals
@@ -127,20 +418,84 @@ func LoadFromEnv() *Config {
 			MaxOpenPositions:         getEnvInt("TRADING_MAX_OPEN_POSITIONS", 20),
 			MaxPositionsPerSymbol:    getEnvInt("TRADING_MAX_POSITIONS_PER_SYMBOL", 3),
 			SignalTimeWindowMinutes:  getEnvInt("TRADING_SIGNAL_TIME_WINDOW", 2),
+			StrategySignalIntervals:  getEnvIntMap("TRADING_STRATEGY_SIGNAL_INTERVALS"),   // e.g. VOLUME_BREAKOUT:10,MEAN_REVERSION:30
+			StrategyMaxOpenPositions: getEnvIntMap("TRADING_STRATEGY_MAX_OPEN_POSITIONS"), // e.g. FAKEOUT_FILTER:3,MEAN_REVERSION:4
+			SignalRGOnly:             getEnvOrDefault("TRADING_SIGNAL_RG_ONLY", "false") == "true",
+			PriceSource:              getEnvOrDefault("TRADING_PRICE_SOURCE", "candle_close"),
+			DryRun:                   getEnvOrDefault("TRADING_DRY_RUN", "false") == "true",
+			ScaleInEnabled:           getEnvOrDefault("TRADING_SCALE_IN_ENABLED", "false") == "true",
+			MaxScaleIns:              getEnvInt("TRADING_MAX_SCALE_INS", 1),
+			FilterToggles:            getEnvBoolMap("TRADING_FILTER_TOGGLES"), // e.g. order_flow:false,liquidity:false
+			ConfluenceMerge:          getEnvOrDefault("TRADING_CONFLUENCE_MERGE", "false") == "true",
+			ConfluenceBoost:          getEnvFloat("TRADING_CONFLUENCE_BOOST", 0.15),
 
 			// Thresholds - Relaxed for mock testing
-			MinBaselineSampleSize:       getEnvInt("TRADING_MIN_BASELINE_SAMPLE", 5),           // Dropped to 5 for quick mock
+			MinBaselineSampleSize:       getEnvInt("TRADING_MIN_BASELINE_SAMPLE", 5), // Dropped to 5 for quick mock
 			MinBaselineSampleSizeStrict: getEnvInt("TRADING_MIN_BASELINE_SAMPLE_STRICT", 10),
+			ZScoreClamp:                 getEnvFloat("TRADING_ZSCORE_CLAMP", 100.0),
+			MinBaselineStdDevPct:        getEnvFloat("TRADING_MIN_BASELINE_STDDEV_PCT", 0.05), // Below ~one tick the baseline is meaningless
+			BaselineDecayAfterMinutes:   getEnvInt("TRADING_BASELINE_DECAY_AFTER_MINUTES", 120),
+			BaselineDecayPerHour:        getEnvFloat("TRADING_BASELINE_DECAY_PER_HOUR", 0.1),
 
 			// Strategy Performance - Allow newer strategies to trade
-			MinStrategySignals:   getEnvInt("TRADING_MIN_STRATEGY_SIGNALS", 0),  // 0 so new DB instances can start mock trading
-			LowWinRateThreshold:  getEnvFloat("TRADING_LOW_WIN_RATE", 0.0),      // 0% to allow testing
+			MinStrategySignals:   getEnvInt("TRADING_MIN_STRATEGY_SIGNALS", 0), // 0 so new DB instances can start mock trading
+			LowWinRateThreshold:  getEnvFloat("TRADING_LOW_WIN_RATE", 0.0),     // 0% to allow testing
 			HighWinRateThreshold: getEnvFloat("TRADING_HIGH_WIN_RATE", 50.0),
+			RegimeMinSamples:     getEnvInt("TRADING_REGIME_MIN_SAMPLES", 10),
 
 			// Risk Management - Tighter to prevent large losses
 			MaxHoldingLossPct:    getEnvFloat("TRADING_MAX_HOLDING_LOSS_PCT", 10.0), // Relaxed
 			MaxDailyLossPct:      getEnvFloat("TRADING_MAX_DAILY_LOSS_PCT", 20.0),   // Relaxed
-			MaxConsecutiveLosses: getEnvInt("TRADING_MAX_CONSECUTIVE_LOSSES", 10),  // Relaxed
+			MaxConsecutiveLosses: getEnvInt("TRADING_MAX_CONSECUTIVE_LOSSES", 10),   // Relaxed
+			LossCooldownMinutes:  getEnvInt("TRADING_LOSS_COOLDOWN_MINUTES", 0),
+
+			// Holding Period - Default 4 hours for intraday positions
+			MaxHoldingMinutes:         getEnvInt("TRADING_MAX_HOLDING_MINUTES", 240),
+			StrategyMaxHoldingMinutes: getEnvIntMap("TRADING_STRATEGY_MAX_HOLDING"), // e.g. VOLUME_BREAKOUT:30,MEAN_REVERSION:180
+
+			// Confidence Calibration - Linear until coefficients have been fit offline
+			ConfidenceMode:   getEnvOrDefault("TRADING_CONFIDENCE_MODE", "linear"),
+			ConfidenceModels: getEnvFloatListMap("TRADING_CONFIDENCE_MODELS"), // e.g. VOLUME_BREAKOUT:-2.1|0.15|0.2|1.8|0.05
+
+			// Reverse Signal Exit - Only strong opposite signals close a long
+			ReverseSignalMinConfidence: getEnvFloat("TRADING_REVERSE_SIGNAL_MIN_CONFIDENCE", 0.7),
+
+			// Multi-Timeframe Confirmation - Disabled by default
+			MTFTimeframes:         getEnvList("TRADING_MTF_TIMEFRAMES", "5min,15min"),
+			MTFMinAligned:         getEnvInt("TRADING_MTF_MIN_ALIGNED", 0),
+			StrategyMTFMinAligned: getEnvIntMap("TRADING_STRATEGY_MTF_MIN_ALIGNED"), // e.g. VOLUME_BREAKOUT:2,MEAN_REVERSION:0
+
+			// Mean Reversion RSI Confirmation - standard 14-bar RSI with 70/30 bands
+			MeanReversionRSITimeframe:  getEnvOrDefault("TRADING_MR_RSI_TIMEFRAME", "5min"),
+			MeanReversionRSIPeriod:     getEnvInt("TRADING_MR_RSI_PERIOD", 14),
+			MeanReversionRSIOverbought: getEnvFloat("TRADING_MR_RSI_OVERBOUGHT", 70.0),
+			MeanReversionRSIOversold:   getEnvFloat("TRADING_MR_RSI_OVERSOLD", 30.0),
+
+			// Liquidity - Rp 1B/day keeps a realistic position from moving the price
+			MinAvgDailyValue:      getEnvFloat("TRADING_MIN_AVG_DAILY_VALUE", 0),
+			LiquidityLookbackDays: getEnvInt("TRADING_LIQUIDITY_LOOKBACK_DAYS", 20),
+
+			// Spread / depth - 200 bps still admits a one-tick spread on any IDX price
+			MaxSpreadBps:    getEnvFloat("TRADING_MAX_SPREAD_BPS", 0),
+			MinTopBookValue: getEnvFloat("TRADING_MIN_TOP_BOOK_VALUE", 0),
+
+			// Data Quarantine - 5 of the last ~20 three-minute signal cycles
+			QuarantineAnomalies:     getEnvInt("TRADING_QUARANTINE_ANOMALIES", 5),
+			QuarantineWindowMinutes: getEnvInt("TRADING_QUARANTINE_WINDOW_MINUTES", 60),
+			QuarantineMinutes:       getEnvInt("TRADING_QUARANTINE_MINUTES", 120),
+
+			// Followup Reliability - a 50% hit rate is neutral
+			FollowupReliabilityWeight: getEnvFloat("TRADING_FOLLOWUP_RELIABILITY_WEIGHT", 0.3),
+			FollowupMinSamples:        getEnvInt("TRADING_FOLLOWUP_MIN_SAMPLES", 10),
+			FollowupLookbackDays:      getEnvInt("TRADING_FOLLOWUP_LOOKBACK_DAYS", 30),
+
+			// Opening Volatility - off by default; 15 covers the noisiest minutes after the bell
+			OpeningBlackoutMinutes:     getEnvInt("TRADING_OPENING_BLACKOUT_MINUTES", 0),
+			OpeningBlackoutTierMinutes: getEnvIntMap("TRADING_OPENING_BLACKOUT_TIERS"), // e.g. LARGE:0,MID:5
+
+			// Order Flow Freshness - Buckets flush every minute, so 3 minutes tolerates one missed flush
+			OrderFlowMaxAgeMinutes: getEnvInt("TRADING_ORDER_FLOW_MAX_AGE_MINUTES", 3),
+			RequireOrderFlow:       getEnvOrDefault("TRADING_REQUIRE_ORDER_FLOW", "false") == "true",
 
 			// ATR Multipliers - Optimized for risk/reward
 			StopLossATRMultiplier:     getEnvFloat("TRADING_SL_ATR_MULT", 1.5), // Reduced from 2.0 for tighter stops
@@ -149,14 +504,31 @@ func LoadFromEnv() *Config {
 			TakeProfit1ATRMultiplier: getEnvFloat("TRADING_TP1_ATR_MULT", 3.0), // Reduced from 4.0 for faster profits
 			TakeProfit2ATRMultiplier: getEnvFloat("TRADING_TP2_ATR_MULT", 6.0), // Reduced from 8.0
 
+			// Let trends run, take range profits early
+			TakeProfitRegimeMultipliers: getEnvFloatMap("TRADING_TP_REGIME_MULTIPLIERS", ""),
+
+			// Trailing Stop Activation - Ignore noise right after entry
+			TrailActivationPct: getEnvFloat("TRADING_TRAIL_ACTIVATION_PCT", 0),
+
+			// Stop grace period - disabled by default
+			MinHoldBeforeStopMinutes: getEnvInt("TRADING_MIN_HOLD_BEFORE_STOP_MINUTES", 0),
+
 			// Breakeven Settings - NEW
 			BreakevenTriggerPct: getEnvFloat("TRADING_BREAKEVEN_TRIGGER_PCT", 1.0), // Trigger at 1% profit
 			BreakevenBufferPct:  getEnvFloat("TRADING_BREAKEVEN_BUFFER_PCT", 0.15), // Set stop at +0.15% to cover fees
 
+			// Outcome Classification - Fixed band covers round-trip fees (0.15% buy + 0.10% sell)
+			BreakevenBandPct:     getEnvFloat("TRADING_BREAKEVEN_BAND_PCT", 0.25),
+			BreakevenBandATRMult: getEnvFloat("TRADING_BREAKEVEN_BAND_ATR_MULT", 0),
+
+			// IDX Trading Rules - Exit levels and simulated fills are rounded to valid ticks
+			TickSchedule: getEnvOrDefault("TRADING_TICK_SCHEDULE", ""),
+			LotSize:      getEnvInt("TRADING_LOT_SIZE", 100),
+
 			// Swing Trading Configuration - NEW
 			EnableSwingTrading:   getEnvOrDefault("SWING_TRADING_ENABLED", "true") == "false", // Disabled by default
 			SwingMinConfidence:   getEnvFloat("SWING_MIN_CONFIDENCE", 0.75),                   // Higher threshold for swing
-			SwingMaxHoldingDays:  getEnvInt("SWING_MAX_HOLDING_DAYS", 30),                     // Max 30 days
+			SwingMaxHoldingDays:  getEnvIntAtLeast("SWING_MAX_HOLDING_DAYS", 30, 1),           // Swing limits are day-scale
 			SwingATRMultiplier:   getEnvFloat("SWING_ATR_MULTIPLIER", 3.0),                    // More lenient than day trading (1.5)
 			SwingMinBaselineDays: getEnvInt("SWING_MIN_BASELINE_DAYS", 20),                    // Need 20 days of history
 			SwingPositionSizePct: getEnvFloat("SWING_POSITION_SIZE_PCT", 5.0),                 // 5% of portfolio
@@ -164,10 +536,122 @@ func LoadFromEnv() *Config {
 
 			// Testing & Simulation
 			MockTradingMode: getEnvOrDefault("MOCK_TRADING_MODE", "true") == "true",
+
+			// Paper Trading
+			PaperTradingMode:    getEnvOrDefault("PAPER_TRADING_MODE", "false") == "true",
+			SimulateSlippageBps: getEnvFloat("PAPER_SLIPPAGE_BPS", 10.0), // 0.10% per side
+			WorstCaseStopFills:  getEnvOrDefault("PAPER_WORST_CASE_STOP_FILLS", "false") == "true",
 		},
 	}
 }
 
+// SignalIntervalFor returns the minimum signal interval for a strategy,
+// falling back to MinSignalIntervalMinutes when no override is configured
+func (t TradingConfig) SignalIntervalFor(strategy string) int {
+	if minutes, ok := t.StrategySignalIntervals[strategy]; ok {
+		return minutes
+	}
+	return t.MinSignalIntervalMinutes
+}
+
+// MTFMinAlignedFor returns how many timeframes must confirm an uptrend for a strategy,
+// falling back to MTFMinAligned when no override is configured
+func (t TradingConfig) MTFMinAlignedFor(strategy string) int {
+	if count, ok := t.StrategyMTFMinAligned[strategy]; ok {
+		return count
+	}
+	return t.MTFMinAligned
+}
+
+// MaxHoldingMinutesFor returns the intraday max holding period for a strategy,
+// falling back to MaxHoldingMinutes when no override is configured
+func (t TradingConfig) MaxHoldingMinutesFor(strategy string) int {
+	if minutes, ok := t.StrategyMaxHoldingMinutes[strategy]; ok && minutes > 0 {
+		return minutes
+	}
+	return t.MaxHoldingMinutes
+}
+
+// redacted replaces secret values in Sanitized; empty secrets stay empty so "not set" remains visible
+const redacted = "[REDACTED]"
+
+// Sanitized returns a copy of the config with credentials and API keys masked, safe to expose
+// to operators. The copy shares its maps with c and must not be modified.
+func (c Config) Sanitized() Config {
+	mask := func(secret string) string {
+		if secret == "" {
+			return ""
+		}
+		return redacted
+	}
+
+	c.Username = mask(c.Username)
+	c.Password = mask(c.Password)
+	c.DatabasePassword = mask(c.DatabasePassword)
+	c.RedisPassword = mask(c.RedisPassword)
+	c.LLM.APIKey = mask(c.LLM.APIKey)
+	c.API.APIKey = mask(c.API.APIKey)
+	return c
+}
+
+// Warnings lists retention windows that break a dependency between tables: outcomes kept longer than
+// their signals, or trades dropped while a position can still be open (maxHoldingDays)
+func (r RetentionConfig) Warnings(maxHoldingDays int) []string {
+	var warnings []string
+	if r.OutcomesDays > r.SignalsDays {
+		warnings = append(warnings, fmt.Sprintf("signal_outcomes kept %d days but trading_signals only %d days; older outcomes lose their signal",
+			r.OutcomesDays, r.SignalsDays))
+	}
+	if r.TradesDays < maxHoldingDays {
+		warnings = append(warnings, fmt.Sprintf("running_trades kept %d days but positions can stay open %d days; long-held positions lose their entry-period trades",
+			r.TradesDays, maxHoldingDays))
+	}
+	return warnings
+}
+
+// MaxHoldingDays is the longest a position can stay OPEN: the swing holding limit when swing
+// trading is enabled, otherwise a day trade's single session
+func (t TradingConfig) MaxHoldingDays() int {
+	if t.EnableSwingTrading {
+		return t.SwingMaxHoldingDays
+	}
+	return 1
+}
+
+// SessionAt returns the session name in effect at t, which must already be in WIB.
+// Times before the first boundary of the day are AFTER_HOURS.
+func (s SessionConfig) SessionAt(t time.Time) string {
+	minute := t.Hour()*60 + t.Minute()
+	session := "AFTER_HOURS"
+	for _, b := range s.boundariesOn(t) {
+		if minute < b.Minute {
+			break
+		}
+		session = b.Name
+	}
+	return session
+}
+
+// SessionStart returns when the named session starts on t's day (t must already be in WIB)
+func (s SessionConfig) SessionStart(t time.Time, name string) (time.Time, bool) {
+	for _, b := range s.boundariesOn(t) {
+		if b.Name == name {
+			return time.Date(t.Year(), t.Month(), t.Day(), b.Minute/60, b.Minute%60, 0, 0, t.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// boundariesOn returns the schedule in effect on t's day
+func (s SessionConfig) boundariesOn(t time.Time) []SessionBoundary {
+	if len(s.Override) > 0 && s.OverrideFrom != "" && s.OverrideTo != "" {
+		if day := t.Format("2006-01-02"); day >= s.OverrideFrom && day <= s.OverrideTo {
+			return s.Override
+		}
+	}
+	return s.Default
+}
+
 // getEnvInt gets environment variable as int or returns default value
 func getEnvInt(key string, defaultValue int) int {
 	value := os.Getenv(key)
@@ -181,6 +665,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return intValue
 }
 
+// getEnvIntAtLeast is getEnvInt raised to minValue, logging once at load when the configured value is lower
+func getEnvIntAtLeast(key string, defaultValue, minValue int) int {
+	value := getEnvInt(key, defaultValue)
+	if value < minValue {
+		log.Printf("⚠️ %s=%d is below the minimum of %d, using %d", key, value, minValue, minValue)
+		return minValue
+	}
+	return value
+}
+
 // getEnvFloat gets environment variable as float64 or returns default value
 func getEnvFloat(key string, defaultValue float64) float64 {
 	value := os.Getenv(key)
@@ -194,6 +688,148 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 	return floatValue
 }
 
+// getEnvList parses a comma-separated environment variable into a slice
+func getEnvList(key, defaultValue string) []string {
+	var result []string
+	for _, item := range strings.Split(getEnvOrDefault(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvIntMap parses a "KEY:int,KEY:int" environment variable into a map
+// Malformed entries are skipped
+func getEnvIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	value := os.Getenv(key)
+	if value == "" {
+		return result
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var intValue int
+		if _, err := fmt.Sscanf(strings.TrimSpace(parts[1]), "%d", &intValue); err != nil {
+			continue
+		}
+		result[strings.TrimSpace(parts[0])] = intValue
+	}
+	return result
+}
+
+// getEnvFloatMap parses a "KEY:float,KEY:float" environment variable (or defaultValue when unset) into a map
+// Malformed entries are skipped
+func getEnvFloatMap(key, defaultValue string) map[string]float64 {
+	result := make(map[string]float64)
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var floatValue float64
+		if _, err := fmt.Sscanf(strings.TrimSpace(parts[1]), "%f", &floatValue); err != nil {
+			continue
+		}
+		result[strings.TrimSpace(parts[0])] = floatValue
+	}
+	return result
+}
+
+// getEnvBoolMap parses a "KEY:true,KEY:false" environment variable into a map
+// Malformed entries are skipped
+func getEnvBoolMap(key string) map[string]bool {
+	result := make(map[string]bool)
+	value := os.Getenv(key)
+	if value == "" {
+		return result
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		boolValue, err := strconv.ParseBool(strings.TrimSpace(parts[1]))
+		if err != nil {
+			continue
+		}
+		result[strings.TrimSpace(parts[0])] = boolValue
+	}
+	return result
+}
+
+// getEnvFloatListMap parses a "KEY:f|f|f,KEY:f|f|f" environment variable into a map
+// Entries with a malformed number are skipped
+func getEnvFloatListMap(key string) map[string][]float64 {
+	result := make(map[string][]float64)
+	value := os.Getenv(key)
+	if value == "" {
+		return result
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var values []float64
+		for _, item := range strings.Split(parts[1], "|") {
+			var floatValue float64
+			if _, err := fmt.Sscanf(strings.TrimSpace(item), "%f", &floatValue); err != nil {
+				values = nil
+				break
+			}
+			values = append(values, floatValue)
+		}
+		if values != nil {
+			result[strings.TrimSpace(parts[0])] = values
+		}
+	}
+	return result
+}
+
+// getEnvSessions reads a session schedule, logging and falling back to defaultValue when malformed
+func getEnvSessions(key, defaultValue string) []SessionBoundary {
+	if value := os.Getenv(key); value != "" {
+		sessions, err := parseSessions(value)
+		if err == nil {
+			return sessions
+		}
+		log.Printf("⚠️ Invalid %s (%v), using default schedule", key, err)
+	}
+	sessions, _ := parseSessions(defaultValue)
+	return sessions
+}
+
+// parseSessions parses "NAME=HH:MM,NAME=HH:MM" into boundaries sorted by start time
+func parseSessions(value string) ([]SessionBoundary, error) {
+	var result []SessionBoundary
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("entry %q is not NAME=HH:MM", pair)
+		}
+		var hour, minute int
+		if _, err := fmt.Sscanf(strings.TrimSpace(parts[1]), "%d:%d", &hour, &minute); err != nil ||
+			hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+			return nil, fmt.Errorf("invalid time %q", parts[1])
+		}
+		result = append(result, SessionBoundary{Name: strings.TrimSpace(parts[0]), Minute: hour*60 + minute})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Minute < result[j].Minute })
+	return result, nil
+}
+
 // getEnvOrDefault gets environment variable or returns default value
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -3,9 +3,11 @@ package analytics
 import (
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	models "stockbit-haka-haki/database/models_pkg"
+	"stockbit-haka-haki/database/types"
 
 	"gorm.io/gorm"
 )
@@ -108,15 +110,36 @@ func (r *Repository) GetAggregateBaseline() (*models.StatisticalBaseline, error)
 }
 
 // CalculateBaselinesDB calculates statistical baselines directly in the database
-// Uses candle_1min view for efficient aggregation
+// Uses the regular-board candle_1min_rg view so NG/TN trades don't skew the baseline
 func (r *Repository) CalculateBaselinesDB(minutesBack int, minTrades int) ([]models.StatisticalBaseline, error) {
+	baselines, err := r.calculateBaselines(minutesBack, minTrades, "")
+	if err != nil {
+		return nil, fmt.Errorf("CalculateBaselinesDB: %w", err)
+	}
+	return baselines, nil
+}
+
+// CalculateSymbolBaselineDB calculates one symbol's baseline; nil when it has fewer than minTrades candles
+func (r *Repository) CalculateSymbolBaselineDB(symbol string, minutesBack int, minTrades int) (*models.StatisticalBaseline, error) {
+	baselines, err := r.calculateBaselines(minutesBack, minTrades, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("CalculateSymbolBaselineDB: %w", err)
+	}
+	if len(baselines) == 0 {
+		return nil, nil
+	}
+	return &baselines[0], nil
+}
+
+// calculateBaselines runs the baseline aggregation, optionally for a single symbol
+func (r *Repository) calculateBaselines(minutesBack int, minTrades int, symbol string) ([]models.StatisticalBaseline, error) {
 	var baselines []models.StatisticalBaseline
 
 	// Calculate hours for display/storage (integer division)
 	lookbackHours := minutesBack / 60
 
 	// Complex aggregation query using Postgres/TimescaleDB functions
-	// We use candle_1min to get precise volume/price data but aggregated by minute first for speed
+	// We use candle_1min_rg to get precise volume/price data but aggregated by minute first for speed
 	// Note: We use fmt.Sprintf for lookback_hours in SELECT to avoid type inference issues
 	query := fmt.Sprintf(`
 		WITH stats AS (
@@ -135,8 +158,16 @@ func (r *Repository) CalculateBaselinesDB(minutesBack int, minTrades int) ([]mod
 				PERCENTILE_CONT(0.75) WITHIN GROUP (ORDER BY volume_lots) as volume_p75,
 				AVG(total_value) as mean_value,
 				STDDEV(total_value) as std_dev_value
-			FROM candle_1min
+			FROM candle_1min_rg c
 			WHERE bucket >= NOW() - INTERVAL '1 minute' * ?
+			  AND (? = '' OR stock_symbol = ?)
+			  -- Skip candles from before a split/corporate action (price discontinuity)
+			  AND NOT EXISTS (
+				SELECT 1 FROM corporate_actions ca
+				WHERE ca.stock_symbol = c.stock_symbol
+				  AND ca.effective_date > c.bucket
+				  AND ca.effective_date <= NOW()
+			  )
 			GROUP BY stock_symbol
 			HAVING COUNT(*) >= ?
 		)
@@ -160,13 +191,108 @@ func (r *Repository) CalculateBaselinesDB(minutesBack int, minTrades int) ([]mod
 		FROM stats
 	`, lookbackHours)
 
-	if err := r.db.Raw(query, minutesBack, minTrades).Scan(&baselines).Error; err != nil {
-		return nil, fmt.Errorf("CalculateBaselinesDB: %w", err)
+	if err := r.db.Raw(query, minutesBack, symbol, symbol, minTrades).Scan(&baselines).Error; err != nil {
+		return nil, err
 	}
 
 	return baselines, nil
 }
 
+// InvalidateBaselines removes stored baselines for a symbol so the next run recomputes them
+func (r *Repository) InvalidateBaselines(symbol string) error {
+	if err := r.db.Where("stock_symbol = ?", symbol).Delete(&models.StatisticalBaseline{}).Error; err != nil {
+		return fmt.Errorf("InvalidateBaselines: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// Corporate Actions
+// ============================================================================
+
+// SaveCorporateAction persists a corporate action
+func (r *Repository) SaveCorporateAction(action *models.CorporateAction) error {
+	if err := r.db.Create(action).Error; err != nil {
+		return fmt.Errorf("SaveCorporateAction: %w", err)
+	}
+	return nil
+}
+
+// GetCorporateActions retrieves corporate actions, optionally filtered by symbol
+func (r *Repository) GetCorporateActions(symbol string, limit int) ([]models.CorporateAction, error) {
+	var actions []models.CorporateAction
+	query := r.db.Order("effective_date DESC")
+	if symbol != "" {
+		query = query.Where("stock_symbol = ?", symbol)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&actions).Error; err != nil {
+		return nil, fmt.Errorf("GetCorporateActions: %w", err)
+	}
+	return actions, nil
+}
+
+// DetectOvernightGaps finds symbols whose latest daily open gapped from the previous close
+// by at least thresholdPct, and that have no corporate action recorded for that day yet
+func (r *Repository) DetectOvernightGaps(thresholdPct float64) ([]models.CorporateAction, error) {
+	var gaps []struct {
+		StockSymbol   string
+		EffectiveDate time.Time
+		PrevClose     float64
+		OpenPrice     float64
+		GapPct        float64
+	}
+
+	query := `
+		WITH ranked AS (
+			SELECT
+				stock_symbol,
+				bucket,
+				open,
+				close,
+				ROW_NUMBER() OVER (PARTITION BY stock_symbol ORDER BY bucket DESC) as rn
+			FROM candle_1day
+			WHERE bucket >= NOW() - INTERVAL '7 days'
+		)
+		SELECT
+			cur.stock_symbol,
+			cur.bucket as effective_date,
+			prev.close as prev_close,
+			cur.open as open_price,
+			(cur.open - prev.close) / prev.close * 100 as gap_pct
+		FROM ranked cur
+		JOIN ranked prev ON prev.stock_symbol = cur.stock_symbol AND prev.rn = 2
+		WHERE cur.rn = 1
+		  AND prev.close > 0
+		  AND ABS((cur.open - prev.close) / prev.close * 100) >= ?
+		  AND NOT EXISTS (
+			SELECT 1 FROM corporate_actions ca
+			WHERE ca.stock_symbol = cur.stock_symbol
+			  AND ca.effective_date >= cur.bucket
+		  )
+	`
+
+	if err := r.db.Raw(query, thresholdPct).Scan(&gaps).Error; err != nil {
+		return nil, fmt.Errorf("DetectOvernightGaps: %w", err)
+	}
+
+	actions := make([]models.CorporateAction, 0, len(gaps))
+	for _, g := range gaps {
+		actions = append(actions, models.CorporateAction{
+			StockSymbol:   g.StockSymbol,
+			EffectiveDate: g.EffectiveDate,
+			ActionType:    "PRICE_GAP",
+			Ratio:         g.OpenPrice / g.PrevClose,
+			Source:        "AUTO_DETECTED",
+			Notes:         fmt.Sprintf("Overnight gap %.1f%% (%.0f → %.0f)", g.GapPct, g.PrevClose, g.OpenPrice),
+		})
+	}
+	return actions, nil
+}
+
 // ============================================================================
 // Market Regimes
 // ============================================================================
@@ -192,6 +318,18 @@ func (r *Repository) GetLatestRegime(symbol string) (*models.MarketRegime, error
 	return &regime, nil
 }
 
+// GetRegimeHistory returns a symbol's regime detections since a point in time, oldest first
+func (r *Repository) GetRegimeHistory(symbol string, since time.Time) ([]models.MarketRegime, error) {
+	var regimes []models.MarketRegime
+	err := r.db.Where("stock_symbol = ? AND detected_at >= ?", symbol, since).
+		Order("detected_at ASC").
+		Find(&regimes).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetRegimeHistory: %w", err)
+	}
+	return regimes, nil
+}
+
 // GetAggregateMarketRegime calculates the overall market regime based on individual stock regimes
 func (r *Repository) GetAggregateMarketRegime() (*models.MarketRegime, error) {
 	type result struct {
@@ -281,6 +419,130 @@ func (r *Repository) GetAllRecentPatterns(since time.Time) ([]models.DetectedPat
 	return patterns, nil
 }
 
+// GetPatterns lists detected patterns since a time, newest first; symbol and patternType are optional
+func (r *Repository) GetPatterns(symbol, patternType string, since time.Time, limit int) ([]models.DetectedPattern, error) {
+	var patterns []models.DetectedPattern
+	query := r.db.Where("detected_at >= ?", since).Order("detected_at DESC")
+	if symbol != "" {
+		query = query.Where("stock_symbol = ?", symbol)
+	}
+	if patternType != "" {
+		query = query.Where("pattern_type = ?", patternType)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&patterns).Error; err != nil {
+		return nil, fmt.Errorf("GetPatterns: %w", err)
+	}
+	return patterns, nil
+}
+
+// GetOpeningGaps returns symbols whose first 1-minute open since dayStart is at least minGapPct away
+// from the previous daily close, with the volume traded since the open and the 20-day average.
+// Today's open comes from candle_1min since the daily aggregate is only materialized later.
+func (r *Repository) GetOpeningGaps(dayStart time.Time, minGapPct float64) ([]types.OpeningGap, error) {
+	var gaps []types.OpeningGap
+
+	query := `
+		WITH today AS (
+			SELECT stock_symbol,
+				FIRST(open, bucket) AS open,
+				LAST(close, bucket) AS last_price,
+				SUM(volume_lots) AS volume_lots
+			FROM candle_1min
+			WHERE bucket >= ?
+			GROUP BY stock_symbol
+		), prev AS (
+			SELECT DISTINCT ON (stock_symbol) stock_symbol, close AS prev_close
+			FROM candle_1day
+			WHERE bucket < ? AND bucket >= ?::timestamptz - INTERVAL '10 days'
+			ORDER BY stock_symbol, bucket DESC
+		), vol AS (
+			SELECT stock_symbol, AVG(volume_lots) AS avg_daily_volume
+			FROM (
+				SELECT stock_symbol, volume_lots,
+					ROW_NUMBER() OVER (PARTITION BY stock_symbol ORDER BY bucket DESC) AS rn
+				FROM candle_1day
+				WHERE bucket < ? AND bucket >= ?::timestamptz - INTERVAL '45 days'
+			) d
+			WHERE rn <= 20
+			GROUP BY stock_symbol
+		)
+		SELECT t.stock_symbol, t.open, p.prev_close,
+			(t.open - p.prev_close) / p.prev_close * 100 AS gap_pct,
+			t.last_price, t.volume_lots,
+			COALESCE(v.avg_daily_volume, 0) AS avg_daily_volume
+		FROM today t
+		JOIN prev p ON p.stock_symbol = t.stock_symbol
+		LEFT JOIN vol v ON v.stock_symbol = t.stock_symbol
+		WHERE p.prev_close > 0
+		  AND ABS(t.open - p.prev_close) / p.prev_close * 100 >= ?
+		ORDER BY ABS(t.open - p.prev_close) / p.prev_close DESC
+	`
+
+	if err := r.db.Raw(query, dayStart, dayStart, dayStart, dayStart, dayStart, minGapPct).Scan(&gaps).Error; err != nil {
+		return nil, fmt.Errorf("GetOpeningGaps: %w", err)
+	}
+	return gaps, nil
+}
+
+// GetBollingerSqueezes returns symbols whose Bollinger band width (period-candle SMA ± 2σ on
+// candle_5min) on their latest candle is at or below the narrowest width of the previous
+// lookback candles. Symbols without a candle since freshSince or without a full lookback are skipped.
+func (r *Repository) GetBollingerSqueezes(period, lookback int, freshSince time.Time) ([]types.BollingerSqueeze, error) {
+	var squeezes []types.BollingerSqueeze
+
+	query := `
+		WITH bars AS (
+			SELECT stock_symbol, bucket, close,
+				ROW_NUMBER() OVER (PARTITION BY stock_symbol ORDER BY bucket DESC) AS rn
+			FROM candle_5min
+			WHERE bucket >= NOW() - INTERVAL '10 days'
+		), bands AS (
+			SELECT stock_symbol, bucket, close, rn,
+				AVG(close) OVER w AS middle,
+				STDDEV_POP(close) OVER w AS sd,
+				COUNT(*) OVER w AS n
+			FROM bars
+			WHERE rn <= ? + ?
+			WINDOW w AS (PARTITION BY stock_symbol ORDER BY bucket ROWS BETWEEN ? PRECEDING AND CURRENT ROW)
+		), widths AS (
+			SELECT stock_symbol, bucket, close, rn, middle, sd,
+				4 * sd / middle * 100 AS width_pct
+			FROM bands
+			WHERE n = ? AND middle > 0
+		), prior AS (
+			SELECT stock_symbol,
+				MIN(width_pct) AS min_prior_width,
+				AVG(width_pct) AS avg_prior_width,
+				COUNT(*) AS samples
+			FROM widths
+			WHERE rn > 1
+			GROUP BY stock_symbol
+		)
+		SELECT w.stock_symbol, w.bucket, w.close, w.middle,
+			w.middle + 2 * w.sd AS upper,
+			w.middle - 2 * w.sd AS lower,
+			w.width_pct, p.min_prior_width, p.avg_prior_width, p.samples
+		FROM widths w
+		JOIN prior p ON p.stock_symbol = w.stock_symbol
+		WHERE w.rn = 1
+		  AND w.bucket >= ?
+		  AND p.samples >= ?
+		  AND w.width_pct > 0
+		  AND w.width_pct <= p.min_prior_width
+		ORDER BY w.width_pct / NULLIF(p.avg_prior_width, 0) ASC
+	`
+
+	// Candles beyond the latest lookback+period are never needed for a full band
+	if err := r.db.Raw(query, lookback, period, period-1, period, freshSince, lookback).Scan(&squeezes).Error; err != nil {
+		return nil, fmt.Errorf("GetBollingerSqueezes: %w", err)
+	}
+	return squeezes, nil
+}
+
 // UpdatePatternOutcome updates the outcome of a detected pattern
 func (r *Repository) UpdatePatternOutcome(id int64, outcome string, breakout bool, maxMove float64) error {
 	if err := r.db.Model(&models.DetectedPattern{}).Where("id = ?", id).Updates(map[string]interface{}{
@@ -331,6 +593,119 @@ func (r *Repository) GetStockCorrelations(symbol string, limit int) ([]models.St
 	return correlations, nil
 }
 
+// GetSectorSweeps groups symbols with whale BUY alerts since a point in time into clusters linked by
+// correlation >= minCorrelation, returning clusters of at least minSymbols, largest value first
+func (r *Repository) GetSectorSweeps(since time.Time, minSymbols int, minCorrelation float64) ([]types.SectorSweep, error) {
+	type symbolActivity struct {
+		StockSymbol  string
+		AlertCount   int64
+		TotalValue   float64
+		FirstAlertAt time.Time
+		LastAlertAt  time.Time
+	}
+	if minSymbols < 2 {
+		minSymbols = 2 // A sweep needs at least one correlated pair
+	}
+
+	var activity []symbolActivity
+	err := r.db.Raw(`
+		SELECT
+			stock_symbol,
+			COUNT(*) as alert_count,
+			SUM(trigger_value) as total_value,
+			MIN(detected_at) as first_alert_at,
+			MAX(detected_at) as last_alert_at
+		FROM whale_alerts
+		WHERE action = 'BUY'
+		  AND detected_at >= ?
+		  AND (market_board != 'NG' OR market_board IS NULL)
+		GROUP BY stock_symbol
+	`, since).Scan(&activity).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetSectorSweeps: %w", err)
+	}
+	if len(activity) < minSymbols {
+		return nil, nil
+	}
+
+	symbols := make([]string, len(activity))
+	for i, a := range activity {
+		symbols[i] = a.StockSymbol
+	}
+
+	// Latest coefficient per pair among the active symbols
+	var pairs []models.StockCorrelation
+	err = r.db.Raw(`
+		SELECT DISTINCT ON (LEAST(stock_a, stock_b), GREATEST(stock_a, stock_b)) *
+		FROM stock_correlations
+		WHERE stock_a IN ? AND stock_b IN ?
+		ORDER BY LEAST(stock_a, stock_b), GREATEST(stock_a, stock_b), calculated_at DESC
+	`, symbols, symbols).Scan(&pairs).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetSectorSweeps: %w", err)
+	}
+
+	// Union-find over strongly correlated pairs
+	parent := make(map[string]string, len(symbols))
+	var find func(string) string
+	find = func(s string) string {
+		if parent[s] != s {
+			parent[s] = find(parent[s])
+		}
+		return parent[s]
+	}
+	for _, s := range symbols {
+		parent[s] = s
+	}
+	var edges []models.StockCorrelation
+	for _, p := range pairs {
+		if p.CorrelationCoefficient < minCorrelation {
+			continue
+		}
+		edges = append(edges, p)
+		parent[find(p.StockA)] = find(p.StockB)
+	}
+
+	clusters := make(map[string]*types.SectorSweep)
+	for _, a := range activity {
+		root := find(a.StockSymbol)
+		sweep, ok := clusters[root]
+		if !ok {
+			sweep = &types.SectorSweep{FirstAlertAt: a.FirstAlertAt, LastAlertAt: a.LastAlertAt}
+			clusters[root] = sweep
+		}
+		sweep.Symbols = append(sweep.Symbols, a.StockSymbol)
+		sweep.AlertCount += a.AlertCount
+		sweep.TotalValue += a.TotalValue
+		if a.FirstAlertAt.Before(sweep.FirstAlertAt) {
+			sweep.FirstAlertAt = a.FirstAlertAt
+		}
+		if a.LastAlertAt.After(sweep.LastAlertAt) {
+			sweep.LastAlertAt = a.LastAlertAt
+		}
+	}
+
+	edgeSums := make(map[string]float64)
+	edgeCounts := make(map[string]int)
+	for _, e := range edges {
+		root := find(e.StockA)
+		edgeSums[root] += e.CorrelationCoefficient
+		edgeCounts[root]++
+	}
+
+	var sweeps []types.SectorSweep
+	for root, sweep := range clusters {
+		if len(sweep.Symbols) < minSymbols {
+			continue
+		}
+		sort.Strings(sweep.Symbols)
+		sweep.AvgCorrelation = edgeSums[root] / float64(edgeCounts[root])
+		sweeps = append(sweeps, *sweep)
+	}
+	sort.Slice(sweeps, func(i, j int) bool { return sweeps[i].TotalValue > sweeps[j].TotalValue })
+	return sweeps, nil
+}
+
 // GetCorrelationsForPair retrieves historical correlations between two specific stocks
 func (r *Repository) GetCorrelationsForPair(stockA, stockB string) ([]models.StockCorrelation, error) {
 	var correlations []models.StockCorrelation
@@ -380,6 +755,106 @@ func (r *Repository) GetOrderFlowImbalance(symbol string, startTime, endTime tim
 	return flows, nil
 }
 
+// GetOrderFlowBuckets sums per-minute order flow into buckets of the given interval (e.g. "5min")
+// so it lines up with candles built by time_bucket over the same interval
+func (r *Repository) GetOrderFlowBuckets(symbol, interval string, startTime, endTime time.Time) ([]types.CandleFlow, error) {
+	var flows []types.CandleFlow
+	err := r.db.Raw(`
+		SELECT
+			time_bucket(?::interval, bucket) AS bucket,
+			SUM(buy_volume_lots) AS buy_volume_lots,
+			SUM(sell_volume_lots) AS sell_volume_lots,
+			SUM(delta_volume) AS delta_volume
+		FROM order_flow_imbalance
+		WHERE stock_symbol = ? AND bucket >= ? AND bucket < ?
+		GROUP BY 1
+		ORDER BY 1 DESC
+	`, interval, symbol, startTime, endTime).Scan(&flows).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderFlowBuckets: %w", err)
+	}
+	return flows, nil
+}
+
+// GetOrderFlowTrend reports whether cumulative delta volume is rising or falling over the last N minutes
+// The window is split in halves so a positive total with a fading second half is not read as RISING
+func (r *Repository) GetOrderFlowTrend(symbol string, minutes int) (*types.OrderFlowTrend, error) {
+	var flows []models.OrderFlowImbalance
+	err := r.db.Where("stock_symbol = ? AND bucket >= ?", symbol, time.Now().Add(-time.Duration(minutes)*time.Minute)).
+		Order("bucket ASC").
+		Find(&flows).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderFlowTrend: %w", err)
+	}
+
+	trend := &types.OrderFlowTrend{
+		StockSymbol: symbol,
+		Minutes:     minutes,
+		Buckets:     len(flows),
+		Direction:   "INSUFFICIENT_DATA",
+	}
+	if len(flows) < 3 {
+		return trend, nil
+	}
+
+	half := len(flows) / 2
+	for i, flow := range flows {
+		trend.CumulativeDelta += flow.DeltaVolume
+		if i < half {
+			trend.PriorDelta += flow.DeltaVolume
+		} else {
+			trend.RecentDelta += flow.DeltaVolume
+		}
+	}
+
+	switch {
+	case trend.CumulativeDelta > 0 && trend.RecentDelta >= trend.PriorDelta:
+		trend.Direction = "RISING"
+	case trend.CumulativeDelta < 0 && trend.RecentDelta <= trend.PriorDelta:
+		trend.Direction = "FALLING"
+	default:
+		trend.Direction = "MIXED"
+	}
+	return trend, nil
+}
+
+// GetOrderFlowSummary aggregates order flow per symbol over the last N minutes and returns the
+// top `limit` symbols by buy pressure and by sell pressure in one pass. Symbols trading fewer than
+// minLots in the window are left out so thin names can't top the ranking with a handful of lots.
+func (r *Repository) GetOrderFlowSummary(minutes int, minLots float64, limit int) ([]types.OrderFlowSummary, error) {
+	var rows []types.OrderFlowSummary
+	err := r.db.Raw(`
+		WITH agg AS (
+			SELECT
+				stock_symbol,
+				SUM(buy_volume_lots) AS buy_lots,
+				SUM(sell_volume_lots) AS sell_lots,
+				SUM(buy_volume_lots + sell_volume_lots) AS total_lots,
+				SUM(buy_volume_lots - sell_volume_lots) AS delta_lots,
+				SUM(buy_value) AS buy_value,
+				SUM(sell_value) AS sell_value,
+				COUNT(*) AS buckets
+			FROM order_flow_imbalance
+			WHERE bucket >= ?
+			GROUP BY stock_symbol
+			HAVING SUM(buy_volume_lots + sell_volume_lots) >= ? AND SUM(buy_volume_lots + sell_volume_lots) > 0
+		), ranked AS (
+			SELECT *,
+				delta_lots / total_lots AS imbalance_ratio,
+				ROW_NUMBER() OVER (ORDER BY delta_lots / total_lots DESC, total_lots DESC) AS buy_rank,
+				ROW_NUMBER() OVER (ORDER BY delta_lots / total_lots ASC, total_lots DESC) AS sell_rank
+			FROM agg
+		)
+		SELECT * FROM ranked
+		WHERE buy_rank <= ? OR sell_rank <= ?
+		ORDER BY imbalance_ratio DESC
+	`, time.Now().Add(-time.Duration(minutes)*time.Minute), minLots, limit, limit).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetOrderFlowSummary: %w", err)
+	}
+	return rows, nil
+}
+
 // GetLatestOrderFlow retrieves the most recent order flow for a symbol
 func (r *Repository) GetLatestOrderFlow(symbol string) (*models.OrderFlowImbalance, error) {
 	var flow models.OrderFlowImbalance
@@ -396,3 +871,28 @@ func (r *Repository) GetLatestOrderFlow(symbol string) (*models.OrderFlowImbalan
 	}
 	return &flow, nil
 }
+
+// GetLatestOrderFlowForSymbols retrieves the most recent order flow for each symbol in a single query
+// Symbols without any order flow are absent from the returned map
+func (r *Repository) GetLatestOrderFlowForSymbols(symbols []string) (map[string]*models.OrderFlowImbalance, error) {
+	result := make(map[string]*models.OrderFlowImbalance, len(symbols))
+	if len(symbols) == 0 {
+		return result, nil
+	}
+
+	var flows []models.OrderFlowImbalance
+	err := r.db.Raw(`
+		SELECT DISTINCT ON (stock_symbol) *
+		FROM order_flow_imbalance
+		WHERE stock_symbol IN ?
+		ORDER BY stock_symbol, bucket DESC
+	`, symbols).Scan(&flows).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetLatestOrderFlowForSymbols: %w", err)
+	}
+
+	for i := range flows {
+		result[flows[i].StockSymbol] = &flows[i]
+	}
+	return result, nil
+}
@@ -79,6 +79,8 @@ type WhaleWebhookLog = models.WhaleWebhookLog
 type TradingSignal = models.TradingSignal
 type TradingSignalDB = models.TradingSignalDB
 type SignalOutcome = models.SignalOutcome
+type ExitSignal = models.ExitSignal
+type PreOpenSnapshot = models.PreOpenSnapshot
 type WhaleAlertFollowup = models.WhaleAlertFollowup
 type OrderFlowImbalance = models.OrderFlowImbalance
 type StatisticalBaseline = models.StatisticalBaseline
@@ -86,3 +88,23 @@ type MarketRegime = models.MarketRegime
 type DetectedPattern = models.DetectedPattern
 type StockCorrelation = models.StockCorrelation
 type WhaleStats = models.WhaleStats
+type CorporateAction = models.CorporateAction
+type SymbolFilter = models.SymbolFilter
+type ScheduledEvent = models.ScheduledEvent
+type SuppressionRule = models.SuppressionRule
+type StrategyConfig = models.StrategyConfig
+type SymbolStrategyPreference = models.SymbolStrategyPreference
+type SymbolMeta = models.SymbolMeta
+
+// NormalizeConfidence converts a confidence value to the 0.0-1.0 signal scale
+var NormalizeConfidence = models.NormalizeConfidence
+
+// Whale alert severity tiers (see models.SeverityRank)
+const (
+	SeverityWarn     = models.SeverityWarn
+	SeverityAlert    = models.SeverityAlert
+	SeverityCritical = models.SeverityCritical
+)
+
+// SeverityRank orders whale alert severities for minimum-severity filters
+var SeverityRank = models.SeverityRank
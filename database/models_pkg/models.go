@@ -86,7 +86,7 @@ func (Candle) TableName() string {
 //   - TriggerPrice/VolumeLots/Value: The trade that triggered the alert
 //   - ZScore: Statistical significance (how many standard deviations from mean)
 //   - VolumeVsAvgPct: Volume as percentage of average
-//   - ConfidenceScore: Algorithm confidence (0.0 to 1.0)
+//   - ConfidenceScore: Algorithm confidence on a 0-100 percentage scale (see NormalizeConfidence)
 //
 // Detection Logic:
 //   - Single trades with volume > 3 standard deviations from mean
@@ -108,11 +108,13 @@ type WhaleAlert struct {
 	TotalPatternValue  *float64  `gorm:"type:decimal(20,2)" json:"total_pattern_value,omitempty"`
 	ZScore             *float64  `gorm:"type:decimal(10,4)" json:"z_score,omitempty"`
 	VolumeVsAvgPct     *float64  `gorm:"type:decimal(10,2)" json:"volume_vs_avg_pct,omitempty"`
-	AvgPrice           *float64  `gorm:"type:decimal(15,2)" json:"avg_price,omitempty"` // New field for average price context
-	ConfidenceScore    float64   `gorm:"type:decimal(5,2);not null" json:"confidence_score"`
+	AvgPrice           *float64  `gorm:"type:decimal(15,2)" json:"avg_price,omitempty"`      // New field for average price context
+	ConfidenceScore    float64   `gorm:"type:decimal(5,2);not null" json:"confidence_score"` // 0-100 (percent)
 	MarketBoard        string    `gorm:"type:text" json:"market_board,omitempty"`
 	AdaptiveThreshold  *float64  `gorm:"type:decimal(5,2)" json:"adaptive_threshold,omitempty"`
 	VolatilityPct      *float64  `gorm:"type:decimal(5,2)" json:"volatility_pct,omitempty"`
+	ImpactBps          *float64  `gorm:"type:decimal(12,4)" json:"impact_bps,omitempty"` // Trigger value vs free float (or market cap), basis points
+	Severity           string    `gorm:"type:text" json:"severity,omitempty"`            // WARN, ALERT, CRITICAL (z-score tier)
 }
 
 // TableName specifies the table name for WhaleAlert
@@ -129,10 +131,11 @@ type WhaleWebhook struct {
 	AuthType           string     `gorm:"size:20" json:"auth_type"`
 	AuthHeader         string     `gorm:"size:100" json:"auth_header"`
 	AuthValue          string     `json:"auth_value"`
-	AlertTypes         string     `json:"alert_types"`   // Stored as JSON array
-	StockSymbols       string     `json:"stock_symbols"` // Stored as JSON array
-	MinConfidence      *float64   `gorm:"type:decimal(5,2)" json:"min_confidence,omitempty"`
+	AlertTypes         string     `json:"alert_types"`                                       // Stored as JSON array
+	StockSymbols       string     `json:"stock_symbols"`                                     // Stored as JSON array
+	MinConfidence      *float64   `gorm:"type:decimal(5,2)" json:"min_confidence,omitempty"` // 0-100, compared to WhaleAlert.ConfidenceScore
 	MinValue           *float64   `gorm:"type:decimal(20,2)" json:"min_value,omitempty"`
+	MinSeverity        string     `gorm:"size:10" json:"min_severity,omitempty"` // WARN, ALERT or CRITICAL; empty sends every severity
 	IsActive           bool       `gorm:"default:true" json:"is_active"`
 	RetryCount         int        `gorm:"default:3" json:"retry_count"`
 	RetryDelaySeconds  int        `gorm:"default:5" json:"retry_delay_seconds"`
@@ -159,7 +162,7 @@ type WhaleWebhookLog struct {
 	WebhookID      int       `gorm:"index;not null" json:"webhook_id"`
 	WhaleAlertID   *int64    `json:"whale_alert_id,omitempty"`
 	TriggeredAt    time.Time `gorm:"primaryKey;index;not null" json:"triggered_at"`
-	Status         string    `gorm:"type:text" json:"status"` // SUCCESS, FAILED, TIMEOUT, RATE_LIMITED
+	Status         string    `gorm:"type:text" json:"status"` // SUCCESS, FAILED, TIMEOUT, RATE_LIMITED, TEST_SUCCESS, TEST_FAILED
 	HTTPStatusCode *int      `json:"http_status_code,omitempty"`
 	ResponseBody   string    `json:"response_body,omitempty"`
 	ErrorMessage   string    `json:"error_message,omitempty"`
@@ -177,11 +180,12 @@ type TradingSignal struct {
 	Price         float64   `json:"price"`
 	Volume        float64   `json:"volume"`
 	Change        float64   `json:"change"`
-	Confidence    float64   `json:"confidence"`
+	Confidence    float64   `json:"confidence"` // 0.0-1.0
 	Reason        string    `json:"reason"`
 	Outcome       string    `json:"outcome,omitempty"`        // WIN, LOSS, BREAKEVEN
 	OutcomeStatus string    `json:"outcome_status,omitempty"` // OPEN, SKIPPED, or Outcome
 	ProfitLossPct float64   `json:"profit_loss_pct,omitempty"`
+	Strategies    []string  `json:"strategies,omitempty"` // Contributing strategies when same-direction signals were merged
 }
 
 // WhaleStats represents aggregated statistics for whale activity
@@ -202,7 +206,7 @@ type WhaleStats struct {
 //   - StockSymbol: The stock ticker symbol (indexed)
 //   - Strategy: Strategy type (VOLUME_BREAKOUT, MEAN_REVERSION, FAKEOUT_FILTER)
 //   - Decision: Trading decision (BUY, SELL, WAIT, NO_TRADE)
-//   - Confidence: Signal confidence score (0.0 to 1.0)
+//   - Confidence: Signal confidence score (0.0 to 1.0, enforced on save)
 //   - PriceZScore/VolumeZScore: Statistical significance metrics
 //   - WhaleAlertID: Optional reference to related whale alert
 //
@@ -240,6 +244,50 @@ type MLTrainingData struct {
 	OutcomeResult string    `json:"outcome_result"`
 	ProfitLossPct float64   `json:"profit_loss_pct"`
 	ExitReason    string    `json:"exit_reason"`
+
+	// Outcome magnitude targets
+	EntryPrice     float64 `json:"entry_price"`
+	HoldingMinutes int     `json:"holding_minutes"`
+	MAE            float64 `gorm:"column:mae" json:"mae"`    // Max adverse excursion (%)
+	MFE            float64 `gorm:"column:mfe" json:"mfe"`    // Max favorable excursion (%)
+	ProfitLossIDR  float64 `gorm:"-" json:"profit_loss_idr"` // Computed at export from the configured notional
+}
+
+// Whale alert severity tiers, lowest first
+const (
+	SeverityWarn     = "WARN"
+	SeverityAlert    = "ALERT"
+	SeverityCritical = "CRITICAL"
+)
+
+// SeverityRank orders severities for minimum-severity filters (WARN=1 ... CRITICAL=3).
+// Unknown or empty severities (alerts saved before tiers existed) rank 0.
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityWarn:
+		return 1
+	case SeverityAlert:
+		return 2
+	case SeverityCritical:
+		return 3
+	}
+	return 0
+}
+
+// NormalizeConfidence converts a confidence value to the 0.0-1.0 signal scale.
+// Values above 1.0 are treated as percentages (e.g. WhaleAlert.ConfidenceScore)
+// and divided by 100; the result is clamped to [0, 1].
+func NormalizeConfidence(value float64) float64 {
+	if value > 1.0 {
+		value = value / 100.0
+	}
+	if value < 0 {
+		return 0
+	}
+	if value > 1.0 {
+		return 1.0
+	}
+	return value
 }
 
 // TableName specifies the table name for TradingSignalDB
@@ -259,14 +307,16 @@ type SignalOutcome struct {
 	TrailingStopPrice     *float64   `gorm:"type:decimal(15,2)" json:"trailing_stop_price,omitempty"`
 	ExitTime              *time.Time `gorm:"index" json:"exit_time,omitempty"`
 	ExitPrice             *float64   `gorm:"type:decimal(15,2)" json:"exit_price,omitempty"`
-	ExitReason            *string    `gorm:"type:text" json:"exit_reason,omitempty"` // TAKE_PROFIT, STOP_LOSS, TIME_BASED, REVERSE_SIGNAL
+	ExitReason            *string    `gorm:"type:text" json:"exit_reason,omitempty"` // One of the ExitReason* constants
 	HoldingPeriodMinutes  *int       `json:"holding_period_minutes,omitempty"`
 	PriceChangePct        *float64   `gorm:"type:decimal(10,4)" json:"price_change_pct,omitempty"`                           // (exit - entry) / entry * 100
 	ProfitLossPct         *float64   `gorm:"type:decimal(10,4)" json:"profit_loss_pct,omitempty"`                            // Adjusted for direction
 	MaxFavorableExcursion *float64   `gorm:"type:decimal(10,4)" json:"max_favorable_excursion,omitempty"`                    // MFE: Best price reached
 	MaxAdverseExcursion   *float64   `gorm:"type:decimal(10,4)" json:"max_adverse_excursion,omitempty"`                      // MAE: Worst price reached
 	RiskRewardRatio       *float64   `gorm:"type:decimal(10,4)" json:"risk_reward_ratio,omitempty"`                          // MFE / MAE
-	OutcomeStatus         string     `gorm:"size:20;index;index:idx_outcome_symbol_status,priority:2" json:"outcome_status"` // WIN, LOSS, BREAKEVEN, OPEN
+	OutcomeStatus         string     `gorm:"size:20;index;index:idx_outcome_symbol_status,priority:2" json:"outcome_status"` // WIN, LOSS, BREAKEVEN, OPEN, ORPHANED
+	PositionUnits         float64    `gorm:"type:decimal(10,4);default:1" json:"position_units"`                             // Size in base positions; grows with scale-ins
+	ScaleIns              int        `gorm:"default:0" json:"scale_ins"`                                                     // Later signals averaged into EntryPrice
 }
 
 // TableName specifies the table name for SignalOutcome
@@ -274,6 +324,99 @@ func (SignalOutcome) TableName() string {
 	return "signal_outcomes"
 }
 
+// PreOpenSnapshot is the latest pre-opening auction state of a symbol for one trading day,
+// estimated from the visible order book (read-only intelligence; nothing trades on it)
+type PreOpenSnapshot struct {
+	TradeDate       string    `gorm:"type:date;primaryKey" json:"trade_date"` // WIB date
+	StockSymbol     string    `gorm:"type:text;primaryKey" json:"stock_symbol"`
+	IndicativePrice float64   `gorm:"type:decimal(15,2)" json:"indicative_price"` // 0 when the book doesn't cross
+	MatchedLots     float64   `gorm:"type:decimal(20,2)" json:"matched_lots"`
+	BidLots         float64   `gorm:"type:decimal(20,2)" json:"bid_lots"`       // Demand at the indicative price (total bids if uncrossed)
+	OfferLots       float64   `gorm:"type:decimal(20,2)" json:"offer_lots"`     // Supply at the indicative price (total offers if uncrossed)
+	ImbalanceLots   float64   `gorm:"type:decimal(20,2)" json:"imbalance_lots"` // BidLots - OfferLots; positive = buy surplus
+	ImbalancePct    float64   `gorm:"type:decimal(10,4)" json:"imbalance_pct"`  // Imbalance as % of BidLots + OfferLots
+	Crossed         bool      `json:"crossed"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for PreOpenSnapshot
+func (PreOpenSnapshot) TableName() string {
+	return "preopen_snapshots"
+}
+
+// Exit reasons recorded on SignalOutcome.ExitReason
+const (
+	// Stops
+	ExitReasonATRStopLoss      = "ATR_STOP_LOSS"
+	ExitReasonCatastrophicStop = "CATASTROPHIC_STOP"
+	ExitReasonTrailingStopHit  = "TRAILING_STOP_HIT"
+	ExitReasonTimeBasedCutLoss = "TIME_BASED_CUT_LOSS"
+
+	// Profit targets
+	ExitReasonTakeProfitFull             = "TAKE_PROFIT_FULL"
+	ExitReasonTakeProfitTimeBased        = "TAKE_PROFIT_TIME_BASED"
+	ExitReasonTakeProfitMomentumReversal = "TAKE_PROFIT_MOMENTUM_REVERSAL"
+	ExitReasonTimeDecayProfit            = "TIME_DECAY_PROFIT"
+	ExitReasonPreCloseProfitTaking       = "PRE_CLOSE_PROFIT_TAKING"
+
+	// Holding-time and session limits
+	ExitReasonMaxHoldingProfit      = "MAX_HOLDING_PROFIT"
+	ExitReasonMaxHoldingSmallProfit = "MAX_HOLDING_SMALL_PROFIT"
+	ExitReasonSwingMaxHoldingDays   = "SWING_MAX_HOLDING_DAYS"
+	ExitReasonMarketClose           = "MARKET_CLOSE"
+
+	// Other
+	ExitReasonReverseSignal = "REVERSE_SIGNAL"
+	ExitReasonManualClose   = "MANUAL_CLOSE"
+	ExitReasonSignalDeleted = "SIGNAL_DELETED"
+)
+
+// Exit reason categories
+const (
+	ExitCategoryStop       = "STOP"
+	ExitCategoryTakeProfit = "TAKE_PROFIT"
+	ExitCategoryTime       = "TIME"
+	ExitCategorySignal     = "SIGNAL"
+	ExitCategoryManual     = "MANUAL"
+	ExitCategoryOther      = "OTHER" // SIGNAL_DELETED and reasons not in the taxonomy
+)
+
+// ExitReasonCategory groups an exit reason so stop, target and time-based exits can be compared
+func ExitReasonCategory(reason string) string {
+	switch reason {
+	case ExitReasonATRStopLoss, ExitReasonCatastrophicStop, ExitReasonTrailingStopHit, ExitReasonTimeBasedCutLoss:
+		return ExitCategoryStop
+	case ExitReasonTakeProfitFull, ExitReasonTakeProfitTimeBased, ExitReasonTakeProfitMomentumReversal,
+		ExitReasonTimeDecayProfit, ExitReasonPreCloseProfitTaking:
+		return ExitCategoryTakeProfit
+	case ExitReasonMaxHoldingProfit, ExitReasonMaxHoldingSmallProfit, ExitReasonSwingMaxHoldingDays, ExitReasonMarketClose:
+		return ExitCategoryTime
+	case ExitReasonReverseSignal:
+		return ExitCategorySignal
+	case ExitReasonManualClose:
+		return ExitCategoryManual
+	}
+	return ExitCategoryOther
+}
+
+// ExitSignal records how a SELL signal was used by the long-only tracker: it never opens a
+// position, but flags open longs on the same symbol for a reverse-signal exit
+type ExitSignal struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SignalID    int64     `gorm:"index;not null" json:"signal_id"`   // The SELL signal
+	OutcomeID   *int64    `gorm:"index" json:"outcome_id,omitempty"` // Open BUY outcome it targeted; nil for NO_POSITION
+	StockSymbol string    `gorm:"type:text;index;not null" json:"stock_symbol"`
+	Strategy    string    `gorm:"type:text" json:"strategy"`
+	Confidence  float64   `gorm:"type:decimal(5,4)" json:"confidence"`
+	Action      string    `gorm:"size:20;not null" json:"action"` // EXIT, BELOW_THRESHOLD, NO_POSITION
+	CreatedAt   time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for ExitSignal
+func (ExitSignal) TableName() string {
+	return "exit_signals"
+}
+
 // WhaleAlertFollowup tracks price movement after whale alert detection
 type WhaleAlertFollowup struct {
 	ID                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -435,3 +578,133 @@ type StockCorrelation struct {
 func (StockCorrelation) TableName() string {
 	return "stock_correlations"
 }
+
+// CorporateAction records a price-discontinuity event (split, reverse split, rights issue)
+// Candles before EffectiveDate are excluded from baselines so the jump is not read as an anomaly
+type CorporateAction struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	StockSymbol   string    `gorm:"type:text;not null;index:idx_corporate_actions_symbol_date" json:"stock_symbol"`
+	EffectiveDate time.Time `gorm:"not null;index:idx_corporate_actions_symbol_date" json:"effective_date"`
+	ActionType    string    `gorm:"type:text;not null" json:"action_type"`    // SPLIT, REVERSE_SPLIT, RIGHTS_ISSUE, PRICE_GAP
+	Ratio         float64   `gorm:"type:decimal(15,6);not null" json:"ratio"` // Price factor: new price = old price × ratio
+	Source        string    `gorm:"type:text;not null" json:"source"`         // MANUAL or AUTO_DETECTED
+	Notes         string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for CorporateAction
+func (CorporateAction) TableName() string {
+	return "corporate_actions"
+}
+
+// SymbolFilter is an operator-managed whitelist/blacklist entry
+// A non-empty whitelist restricts processing to listed symbols; blacklisted symbols are always skipped
+type SymbolFilter struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	StockSymbol string    `gorm:"type:text;not null;uniqueIndex:idx_symbol_filters_symbol_type" json:"stock_symbol"`
+	ListType    string    `gorm:"type:text;not null;uniqueIndex:idx_symbol_filters_symbol_type" json:"list_type"` // WHITELIST or BLACKLIST
+	Notes       string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for SymbolFilter
+func (SymbolFilter) TableName() string {
+	return "symbol_filters"
+}
+
+// StrategyConfig is an operator switch for a signal strategy
+// Strategies without a row are enabled and use the generator's default confidence floor
+type StrategyConfig struct {
+	Strategy              string    `gorm:"type:text;primaryKey" json:"strategy"`
+	Enabled               bool      `gorm:"not null" json:"enabled"`
+	MinConfidenceOverride *float64  `gorm:"type:decimal(5,4)" json:"min_confidence_override,omitempty"` // 0-1 scale
+	Notes                 string    `gorm:"type:text" json:"notes,omitempty"`
+	UpdatedAt             time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for StrategyConfig
+func (StrategyConfig) TableName() string {
+	return "strategy_configs"
+}
+
+// SymbolStrategyPreference records operator knowledge of which strategies suit a symbol
+// AVOID never evaluates the strategy for the symbol; once a symbol has any PREFER row,
+// only its preferred strategies are evaluated
+type SymbolStrategyPreference struct {
+	StockSymbol string    `gorm:"type:text;primaryKey" json:"stock_symbol"`
+	Strategy    string    `gorm:"type:text;primaryKey" json:"strategy"`
+	Preference  string    `gorm:"type:text;not null" json:"preference"` // PREFER or AVOID
+	Notes       string    `gorm:"type:text" json:"notes,omitempty"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for SymbolStrategyPreference
+func (SymbolStrategyPreference) TableName() string {
+	return "symbol_strategy_preferences"
+}
+
+// SymbolMeta holds size data used to normalize whale value across symbols
+// Either field may be zero; free float is preferred when both are set
+type SymbolMeta struct {
+	StockSymbol     string    `gorm:"type:text;primaryKey" json:"stock_symbol"`
+	FreeFloatShares float64   `gorm:"type:decimal(20,0)" json:"free_float_shares"`
+	MarketCap       float64   `gorm:"type:decimal(24,2)" json:"market_cap"` // Rupiah
+	Tier            string    `gorm:"type:text" json:"tier,omitempty"`      // Liquidity tier (e.g. LARGE, MID, SMALL) for per-tier trading rules
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for SymbolMeta
+func (SymbolMeta) TableName() string {
+	return "symbol_meta"
+}
+
+// ImpactBps expresses a whale trade's value in basis points of free float value at price,
+// falling back to market cap. Returns nil when no size data is available.
+func (m *SymbolMeta) ImpactBps(value, price float64) *float64 {
+	var base float64
+	switch {
+	case m == nil:
+		return nil
+	case m.FreeFloatShares > 0 && price > 0:
+		base = m.FreeFloatShares * price
+	case m.MarketCap > 0:
+		base = m.MarketCap
+	default:
+		return nil
+	}
+	impact := value / base * 10000
+	return &impact
+}
+
+// ScheduledEvent is a known volatility event (FOMC, BI rate decision, index rebalancing)
+// New entries are blocked (HIGH) or down-weighted (MEDIUM/LOW) while the window is active
+type ScheduledEvent struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	StartTime   time.Time `gorm:"not null;index:idx_scheduled_events_window" json:"start_time"`
+	EndTime     time.Time `gorm:"not null;index:idx_scheduled_events_window" json:"end_time"`
+	Description string    `gorm:"type:text;not null" json:"description"`
+	Severity    string    `gorm:"type:text;not null" json:"severity"`      // HIGH (block), MEDIUM, LOW (down-weight)
+	StockSymbol string    `gorm:"type:text" json:"stock_symbol,omitempty"` // Empty = market-wide
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for ScheduledEvent
+func (ScheduledEvent) TableName() string {
+	return "scheduled_events"
+}
+
+// SuppressionRule mutes whale alert delivery (webhooks and SSE) for a symbol and/or alert type
+// until a deadline; matching alerts are still detected, saved and logged
+type SuppressionRule struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	StockSymbol string    `gorm:"type:text;index" json:"stock_symbol,omitempty"` // Empty = all symbols
+	AlertType   string    `gorm:"type:text" json:"alert_type,omitempty"`         // SINGLE_TRADE, ICEBERG, ...; empty = all types
+	Until       time.Time `gorm:"not null;index" json:"until"`
+	Reason      string    `gorm:"type:text;not null" json:"reason"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for SuppressionRule
+func (SuppressionRule) TableName() string {
+	return "suppression_rules"
+}
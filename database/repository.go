@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"stockbit-haka-haki/database/analytics"
@@ -9,9 +10,11 @@ import (
 	"stockbit-haka-haki/database/trades"
 	"stockbit-haka-haki/database/types"
 	"stockbit-haka-haki/database/whales"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TradeRepository is a facade that delegates to domain-specific repositories
@@ -22,6 +25,17 @@ type TradeRepository struct {
 	whales    *whales.Repository
 	signals   *signals.Repository
 	analytics *analytics.Repository
+
+	rgOnlySignals bool // Only generate signals from regular-board (RG) whale alerts
+	retention     RetentionPolicy
+}
+
+// RetentionPolicy holds retention windows in days for the configurable hypertables (0 keeps the default)
+type RetentionPolicy struct {
+	RunningTradesDays  int
+	WhaleAlertsDays    int
+	TradingSignalsDays int
+	SignalOutcomesDays int
 }
 
 // NewTradeRepository creates a new trade repository facade
@@ -45,6 +59,81 @@ func NewTradeRepository(db *Database) *TradeRepository {
 	}
 }
 
+// SetZScoreLimits configures the baseline z-score clamp and minimum stddev (% of mean) used for signal generation
+func (r *TradeRepository) SetZScoreLimits(clamp, minStdDevPct float64) {
+	r.signals.SetZScoreLimits(clamp, minStdDevPct)
+}
+
+// SetBaselineDecay configures the confidence decay for signals scored against old baselines
+func (r *TradeRepository) SetBaselineDecay(afterMinutes int, perHour float64) {
+	r.signals.SetBaselineDecay(afterMinutes, perHour)
+}
+
+// SetConfidenceModels enables calibrated (logistic) confidence for the given strategies
+func (r *TradeRepository) SetConfidenceModels(byStrategy map[string]types.ConfidenceModel) {
+	r.signals.SetConfidenceModels(byStrategy)
+}
+
+// SetSignalWorkers bounds how many symbols are evaluated concurrently during signal generation
+func (r *TradeRepository) SetSignalWorkers(n int) {
+	r.signals.SetSignalWorkers(n)
+}
+
+// SetSqueezeBreakoutBoost sets the VOLUME_BREAKOUT confidence multiplier after a recent SQUEEZE pattern
+func (r *TradeRepository) SetSqueezeBreakoutBoost(multiplier float64) {
+	r.signals.SetSqueezeBreakoutBoost(multiplier)
+}
+
+// SetSymbolQuarantine configures the per-symbol quarantine after repeated data anomalies
+func (r *TradeRepository) SetSymbolQuarantine(anomalies, windowMinutes, quarantineMinutes int) {
+	r.signals.SetSymbolQuarantine(anomalies, windowMinutes, quarantineMinutes)
+}
+
+// GetQuarantinedSymbols returns the symbols currently excluded from signal generation
+func (r *TradeRepository) GetQuarantinedSymbols() []types.QuarantinedSymbol {
+	return r.signals.GetQuarantinedSymbols()
+}
+
+// ReleaseQuarantinedSymbol ends a symbol's quarantine early
+func (r *TradeRepository) ReleaseQuarantinedSymbol(symbol string) bool {
+	return r.signals.ReleaseQuarantinedSymbol(symbol)
+}
+
+// SetMeanReversionRSI configures the RSI confirmation required for MEAN_REVERSION entries
+func (r *TradeRepository) SetMeanReversionRSI(timeframe string, period int, overbought, oversold float64) {
+	r.signals.SetMeanReversionRSI(timeframe, period, overbought, oversold)
+}
+
+// SetRGOnlySignals restricts signal generation to whale alerts from the regular (RG) board
+// TN (cash) trades settle differently and are thinner, so some setups prefer to ignore them
+func (r *TradeRepository) SetRGOnlySignals(enabled bool) {
+	r.rgOnlySignals = enabled
+}
+
+// SetRetention sets the retention windows applied by InitSchema
+func (r *TradeRepository) SetRetention(policy RetentionPolicy) {
+	r.retention = policy
+}
+
+// retentionInterval returns days as a SQL interval, or fallback when days is not set
+func retentionInterval(days int, fallback string) string {
+	if days <= 0 {
+		return fallback
+	}
+	return fmt.Sprintf("INTERVAL '%d days'", days)
+}
+
+// applyRetentionPolicy replaces the table's retention policy so a changed window takes effect
+// (add_retention_policy with if_not_exists would keep the old one)
+func (r *TradeRepository) applyRetentionPolicy(table, interval string) {
+	r.db.db.Exec(`SELECT remove_retention_policy('` + table + `', if_exists => TRUE)`)
+	if err := r.db.db.Exec(`
+		SELECT add_retention_policy('` + table + `', ` + interval + `, if_not_exists => TRUE)
+	`).Error; err != nil {
+		fmt.Printf("⚠️ Warning: Failed to add retention policy for %s: %v\n", table, err)
+	}
+}
+
 // Close closes the database connection
 func (r *TradeRepository) Close() error {
 	return r.db.Close()
@@ -58,9 +147,11 @@ func (r *TradeRepository) Close() error {
 func (r *TradeRepository) InitSchema() error {
 	fmt.Println("🔄 Starting database schema initialization...")
 
-	// Drop continuous aggregate view if exists to allow table alterations
-	if err := r.db.db.Exec("DROP MATERIALIZED VIEW IF EXISTS candle_1min CASCADE").Error; err != nil {
-		fmt.Printf("⚠️ Warning: Failed to drop view candle_1min: %v\n", err)
+	// Drop continuous aggregate views if they exist to allow table alterations
+	for _, view := range []string{"candle_1min", "candle_1min_rg"} {
+		if err := r.db.db.Exec("DROP MATERIALIZED VIEW IF EXISTS " + view + " CASCADE").Error; err != nil {
+			fmt.Printf("⚠️ Warning: Failed to drop view %s: %v\n", view, err)
+		}
 	}
 
 	// Create running_trades table manually if not exists
@@ -114,7 +205,7 @@ func (r *TradeRepository) InitSchema() error {
 	}
 
 	// Auto-migrate remaining tables
-	if err := r.db.db.AutoMigrate(&WhaleWebhook{}); err != nil {
+	if err := r.db.db.AutoMigrate(&WhaleWebhook{}, &CorporateAction{}, &SymbolFilter{}, &ScheduledEvent{}, &StrategyConfig{}, &SymbolStrategyPreference{}, &SymbolMeta{}, &SuppressionRule{}, &ExitSignal{}, &PreOpenSnapshot{}); err != nil {
 		return fmt.Errorf("auto-migration failed: %w", err)
 	}
 
@@ -123,6 +214,11 @@ func (r *TradeRepository) InitSchema() error {
 		return err
 	}
 
+	// Create strategy_regime_effectiveness view (non-fatal: regime data is optional)
+	if err := r.createRegimeEffectivenessView(); err != nil {
+		fmt.Printf("⚠️ Warning: strategy_regime_effectiveness unavailable: %v\n", err)
+	}
+
 	// Manual migrations for whale_alert_followup columns
 	r.db.db.Exec(`
 		ALTER TABLE whale_alert_followup 
@@ -148,7 +244,9 @@ func (r *TradeRepository) InitSchema() error {
 	r.db.db.Exec(`
 		ALTER TABLE whale_alerts 
 		ADD COLUMN IF NOT EXISTS adaptive_threshold DECIMAL(5,2),
-		ADD COLUMN IF NOT EXISTS volatility_pct DECIMAL(5,2)
+		ADD COLUMN IF NOT EXISTS volatility_pct DECIMAL(5,2),
+		ADD COLUMN IF NOT EXISTS impact_bps DECIMAL(12,4),
+		ADD COLUMN IF NOT EXISTS severity TEXT
 	`)
 
 	// Manual migration for trading_signals analysis_data
@@ -157,11 +255,23 @@ func (r *TradeRepository) InitSchema() error {
 		ADD COLUMN IF NOT EXISTS analysis_data JSONB
 	`)
 
-	// Manual migration for signal_outcomes ATR and trailing stop columns
+	// Data fix: trading_signals.confidence must be on the 0-1 scale
+	// Rows written on the 0-100 whale scale are rescaled, anything else is clamped
+	if res := r.db.db.Exec(`
+		UPDATE trading_signals
+		SET confidence = LEAST(GREATEST(CASE WHEN confidence > 1 THEN confidence / 100 ELSE confidence END, 0), 1)
+		WHERE confidence < 0 OR confidence > 1
+	`); res.Error == nil && res.RowsAffected > 0 {
+		fmt.Printf("⚠️ Normalized %d trading_signals rows with out-of-range confidence\n", res.RowsAffected)
+	}
+
+	// Manual migration for signal_outcomes ATR, trailing stop and scale-in columns
 	r.db.db.Exec(`
 		ALTER TABLE signal_outcomes 
 		ADD COLUMN IF NOT EXISTS atr_at_entry DECIMAL(15,4),
-		ADD COLUMN IF NOT EXISTS trailing_stop_price DECIMAL(15,2)
+		ADD COLUMN IF NOT EXISTS trailing_stop_price DECIMAL(15,2),
+		ADD COLUMN IF NOT EXISTS position_units DECIMAL(10,4) DEFAULT 1,
+		ADD COLUMN IF NOT EXISTS scale_ins INTEGER DEFAULT 0
 	`)
 
 	// Setup TimescaleDB extension and hypertables
@@ -201,6 +311,8 @@ func (r *TradeRepository) createHypertableTables() error {
 			market_board TEXT,
 			adaptive_threshold DECIMAL(5,2),
 			volatility_pct DECIMAL(5,2),
+			impact_bps DECIMAL(12,4),
+			severity TEXT,
 			PRIMARY KEY (id, detected_at)
 		)`,
 		`whale_webhook_logs (
@@ -453,6 +565,53 @@ func (r *TradeRepository) createPerformanceView() error {
 	return nil
 }
 
+// createRegimeEffectivenessView creates the strategy × market regime win rate materialized view.
+// Each closed outcome is attributed to the regime recorded on the outcome, or else the
+// latest regime detected for the symbol at signal time.
+func (r *TradeRepository) createRegimeEffectivenessView() error {
+	fmt.Println("📊 Creating strategy_regime_effectiveness materialized view...")
+
+	r.db.db.Exec(`DROP MATERIALIZED VIEW IF EXISTS strategy_regime_effectiveness`)
+
+	if err := r.db.db.Exec(`
+		CREATE MATERIALIZED VIEW strategy_regime_effectiveness AS
+		SELECT
+			ts.strategy,
+			COALESCE(so.market_regime, mr.regime, 'UNKNOWN') AS market_regime,
+			COUNT(*) AS total_signals,
+			SUM(CASE WHEN so.outcome_status = 'WIN' THEN 1 ELSE 0 END) AS wins,
+			SUM(CASE WHEN so.outcome_status = 'LOSS' THEN 1 ELSE 0 END) AS losses,
+			ROUND(
+				(SUM(CASE WHEN so.outcome_status = 'WIN' THEN 1 ELSE 0 END)::DECIMAL / COUNT(*)) * 100,
+				2
+			) AS win_rate,
+			COALESCE(AVG(CASE WHEN so.outcome_status = 'WIN' THEN so.profit_loss_pct END), 0) AS avg_profit_pct,
+			COALESCE(AVG(CASE WHEN so.outcome_status = 'LOSS' THEN so.profit_loss_pct END), 0) AS avg_loss_pct,
+			COALESCE(AVG(so.profit_loss_pct), 0) AS expected_value
+		FROM signal_outcomes so
+		JOIN trading_signals ts ON so.signal_id = ts.id
+		LEFT JOIN LATERAL (
+			SELECT regime
+			FROM market_regimes
+			WHERE stock_symbol = ts.stock_symbol
+			  AND detected_at <= ts.generated_at
+			ORDER BY detected_at DESC
+			LIMIT 1
+		) mr ON TRUE
+		WHERE so.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN')
+		  AND ts.generated_at >= NOW() - INTERVAL '30 days'
+		GROUP BY ts.strategy, COALESCE(so.market_regime, mr.regime, 'UNKNOWN')
+	`).Error; err != nil {
+		return fmt.Errorf("createRegimeEffectivenessView: %w", err)
+	}
+
+	// Unique index is required for REFRESH ... CONCURRENTLY
+	r.db.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_strategy_regime_effectiveness_key ON strategy_regime_effectiveness(strategy, market_regime)`)
+
+	fmt.Println("✅ strategy_regime_effectiveness view created successfully")
+	return nil
+}
+
 // setupTimescaleDB creates hypertables and policies
 func (r *TradeRepository) setupTimescaleDB() error {
 	fmt.Println("⏰ Setting up TimescaleDB extension and hypertables...")
@@ -469,8 +628,8 @@ func (r *TradeRepository) setupTimescaleDB() error {
 		chunk      string
 		retention  string
 	}{
-		{"running_trades", "timestamp", "INTERVAL '1 day'", "INTERVAL '3 months'"},
-		{"whale_alerts", "detected_at", "INTERVAL '7 days'", "INTERVAL '1 year'"},
+		{"running_trades", "timestamp", "INTERVAL '1 day'", retentionInterval(r.retention.RunningTradesDays, "INTERVAL '3 months'")},
+		{"whale_alerts", "detected_at", "INTERVAL '7 days'", retentionInterval(r.retention.WhaleAlertsDays, "INTERVAL '1 year'")},
 		{"whale_webhook_logs", "triggered_at", "INTERVAL '7 days'", "INTERVAL '30 days'"},
 	}
 
@@ -486,11 +645,7 @@ func (r *TradeRepository) setupTimescaleDB() error {
 			continue
 		}
 
-		if err := r.db.db.Exec(`
-			SELECT add_retention_policy('` + ht.table + `', ` + ht.retention + `, if_not_exists => TRUE)
-		`).Error; err != nil {
-			fmt.Printf("⚠️ Warning: Failed to add retention policy for %s: %v\n", ht.table, err)
-		}
+		r.applyRetentionPolicy(ht.table, ht.retention)
 	}
 
 	// Create continuous aggregate for 1-minute candles
@@ -527,6 +682,37 @@ func (r *TradeRepository) setupTimescaleDB() error {
 		`)
 	}
 
+	// Regular-board only 1-minute candles for baselines and z-scores
+	// NG block crosses and TN cash trades print at off-market prices and sizes, which skews the statistics
+	if err := r.db.db.Exec(`
+		CREATE MATERIALIZED VIEW IF NOT EXISTS candle_1min_rg
+		WITH (timescaledb.continuous) AS
+		SELECT
+			time_bucket('1 minute', timestamp) AS bucket,
+			stock_symbol,
+			LAST(price, timestamp) AS close,
+			SUM(volume_lot) AS volume_lots,
+			SUM(total_amount) AS total_value,
+			COUNT(*) AS trade_count
+		FROM running_trades
+		WHERE market_board = 'RG'
+		GROUP BY bucket, stock_symbol
+	`).Error; err != nil {
+		fmt.Printf("⚠️ Warning: Failed to create candle_1min_rg view: %v\n", err)
+	} else {
+		r.db.db.Exec(`
+			SELECT add_continuous_aggregate_policy('candle_1min_rg',
+				start_offset => INTERVAL '3 minutes',
+				end_offset => INTERVAL '1 minute',
+				schedule_interval => INTERVAL '1 minute',
+				if_not_exists => TRUE
+			)
+		`)
+		r.db.db.Exec(`
+			SELECT add_retention_policy('candle_1min_rg', INTERVAL '10 years', if_not_exists => TRUE)
+		`)
+	}
+
 	return nil
 }
 
@@ -539,8 +725,8 @@ func (r *TradeRepository) setupEnhancedTables() error {
 		chunk     string
 		retention string
 	}{
-		{"trading_signals", "generated_at", "INTERVAL '7 days'", "INTERVAL '2 years'"},
-		{"signal_outcomes", "entry_time", "INTERVAL '7 days'", "INTERVAL '2 years'"},
+		{"trading_signals", "generated_at", "INTERVAL '7 days'", retentionInterval(r.retention.TradingSignalsDays, "INTERVAL '2 years'")},
+		{"signal_outcomes", "entry_time", "INTERVAL '7 days'", retentionInterval(r.retention.SignalOutcomesDays, "INTERVAL '2 years'")},
 		{"whale_alert_followup", "alert_time", "INTERVAL '7 days'", "INTERVAL '1 year'"},
 		{"order_flow_imbalance", "bucket", "INTERVAL '1 day'", "INTERVAL '3 months'"},
 	}
@@ -556,9 +742,7 @@ func (r *TradeRepository) setupEnhancedTables() error {
 			fmt.Printf("⚠️ Warning: Failed to create hypertable for %s: %v\n", t.table, err)
 			continue
 		}
-		r.db.db.Exec(`
-			SELECT add_retention_policy('` + t.table + `', ` + t.retention + `, if_not_exists => TRUE)
-		`)
+		r.applyRetentionPolicy(t.table, t.retention)
 	}
 
 	// Phase 2 enhancement tables
@@ -674,6 +858,10 @@ func (r *TradeRepository) GetRecentTrades(stockSymbol string, limit int, actionF
 	return r.trades.GetRecentTrades(stockSymbol, limit, actionFilter)
 }
 
+func (r *TradeRepository) GetTrades(stockSymbol string, startTime, endTime time.Time, actionFilter, board string, limit int) ([]Trade, error) {
+	return r.trades.GetTrades(stockSymbol, startTime, endTime, actionFilter, board, limit)
+}
+
 func (r *TradeRepository) GetCandles(stockSymbol string, startTime, endTime time.Time, limit int) ([]Candle, error) {
 	return r.trades.GetCandles(stockSymbol, startTime, endTime, limit)
 }
@@ -698,10 +886,61 @@ func (r *TradeRepository) GetStockStats(symbol string, lookbackMinutes int) (*ty
 	return r.trades.GetStockStats(symbol, lookbackMinutes)
 }
 
+func (r *TradeRepository) GetStockStatsAsOf(symbol string, lookbackMinutes int, asOf time.Time) (*types.StockStats, error) {
+	return r.trades.GetStockStatsAsOf(symbol, lookbackMinutes, asOf)
+}
+
+func (r *TradeRepository) GetReplayTradeNumbers(from, to time.Time) (map[int64]bool, error) {
+	return r.trades.GetReplayTradeNumbers(from, to)
+}
+
+func (r *TradeRepository) RefreshCandles(from, to time.Time) error {
+	return r.trades.RefreshCandles(from, to)
+}
+
+func (r *TradeRepository) GetAverageDailyValue(symbol string, days int) (float64, int64, error) {
+	return r.trades.GetAverageDailyValue(symbol, days)
+}
+
 func (r *TradeRepository) GetPriceVolumeZScores(symbol string, currentPrice, currentVolume float64, lookbackMinutes int) (*types.ZScoreData, error) {
 	return r.trades.GetPriceVolumeZScores(symbol, currentPrice, currentVolume, lookbackMinutes)
 }
 
+// GetMarketBreadth combines price breadth from candles with whale flow into a single sentiment read
+func (r *TradeRepository) GetMarketBreadth(since time.Time) (*types.MarketBreadth, error) {
+	advancers, decliners, unchanged, err := r.trades.GetPriceBreadth(since)
+	if err != nil {
+		return nil, err
+	}
+	buyValue, sellValue, err := r.whales.GetWhaleFlowTotals(since)
+	if err != nil {
+		return nil, err
+	}
+
+	breadth := &types.MarketBreadth{
+		Advancers:      advancers,
+		Decliners:      decliners,
+		Unchanged:      unchanged,
+		WhaleBuyValue:  buyValue,
+		WhaleSellValue: sellValue,
+		Sentiment:      "NEUTRAL",
+	}
+	if advancers+decliners > 0 {
+		breadth.BreadthRatio = float64(advancers) / float64(advancers+decliners)
+	}
+	if buyValue+sellValue > 0 {
+		breadth.WhaleBuyRatio = buyValue / (buyValue + sellValue)
+	}
+
+	// Both price action and whale flow must agree for a directional read
+	if breadth.BreadthRatio >= 0.6 && breadth.WhaleBuyRatio >= 0.55 {
+		breadth.Sentiment = "RISK_ON"
+	} else if breadth.BreadthRatio <= 0.4 && breadth.WhaleBuyRatio <= 0.45 {
+		breadth.Sentiment = "RISK_OFF"
+	}
+	return breadth, nil
+}
+
 // Whale methods
 func (r *TradeRepository) SaveWhaleAlert(alert *WhaleAlert) error {
 	return r.whales.SaveWhaleAlert(alert)
@@ -711,6 +950,14 @@ func (r *TradeRepository) GetHistoricalWhales(stockSymbol string, startTime, end
 	return r.whales.GetHistoricalWhales(stockSymbol, startTime, endTime, alertType, action, board, minAmount, limit, offset)
 }
 
+func (r *TradeRepository) GetHistoricalWhalesOrdered(stockSymbol string, startTime, endTime time.Time, alertType string, action string, board string, minAmount float64, limit, offset int, orderBy string) ([]WhaleAlert, error) {
+	return r.whales.GetHistoricalWhalesOrdered(stockSymbol, startTime, endTime, alertType, action, board, minAmount, limit, offset, orderBy)
+}
+
+func (r *TradeRepository) GetWhaleFlowBuckets(symbol, interval string, startTime, endTime time.Time) ([]types.WhaleFlowBucket, error) {
+	return r.whales.GetWhaleFlowBuckets(symbol, interval, startTime, endTime)
+}
+
 func (r *TradeRepository) GetWhaleAlertByID(id int64) (*WhaleAlert, error) {
 	return r.whales.GetWhaleAlertByID(id)
 }
@@ -727,14 +974,19 @@ func (r *TradeRepository) GetAccumulationPattern(hoursBack int, minAlerts int) (
 	return r.whales.GetAccumulationPattern(hoursBack, minAlerts)
 }
 
-func (r *TradeRepository) GetAccumulationDistributionSummary(startTime time.Time) (accumulation []types.AccumulationDistributionSummary, distribution []types.AccumulationDistributionSummary, err error) {
-	return r.whales.GetAccumulationDistributionSummary(startTime)
+func (r *TradeRepository) GetAccumulationDistributionSummary(startTime time.Time, basis, rankBy string) (accumulation []types.AccumulationDistributionSummary, distribution []types.AccumulationDistributionSummary, err error) {
+	return r.whales.GetAccumulationDistributionSummary(startTime, basis, rankBy)
 }
 
 func (r *TradeRepository) GetExtremeAnomalies(minZScore float64, hoursBack int) ([]WhaleAlert, error) {
 	return r.whales.GetExtremeAnomalies(minZScore, hoursBack)
 }
 
+// GetAlertQuality returns followup-based predictive quality of whale alerts per alert type or symbol
+func (r *TradeRepository) GetAlertQuality(daysBack int, groupBy string, minAlerts int) ([]types.AlertQuality, error) {
+	return r.whales.GetAlertQuality(daysBack, groupBy, minAlerts)
+}
+
 func (r *TradeRepository) GetTimeBasedStats(daysBack int) ([]types.TimeBasedStat, error) {
 	return r.whales.GetTimeBasedStats(daysBack)
 }
@@ -768,6 +1020,11 @@ func (r *TradeRepository) GetWhaleFollowups(symbol, status string, limit int) ([
 	return r.whales.GetWhaleFollowups(symbol, status, limit)
 }
 
+// GetFollowupReliability returns how often a symbol's whale alerts for action moved in their direction
+func (r *TradeRepository) GetFollowupReliability(symbol, action string, daysBack int) (*types.FollowupReliability, error) {
+	return r.whales.GetFollowupReliability(symbol, action, daysBack)
+}
+
 func (r *TradeRepository) GetActiveWebhooks() ([]WhaleWebhook, error) {
 	return r.whales.GetActiveWebhooks()
 }
@@ -789,6 +1046,10 @@ func (r *TradeRepository) GetSignalByID(id int64) (*TradingSignalDB, error) {
 	return r.signals.GetSignalByID(id)
 }
 
+func (r *TradeRepository) UpdateSignalAnalysisData(id int64, data string) error {
+	return r.signals.UpdateSignalAnalysisData(id, data)
+}
+
 // OPTIMIZATION: Bulk fetch signals by IDs to eliminate N+1 queries
 func (r *TradeRepository) GetSignalsByIDs(ids []int64) (map[int64]*TradingSignalDB, error) {
 	return r.signals.GetSignalsByIDs(ids)
@@ -802,6 +1063,10 @@ func (r *TradeRepository) UpdateSignalOutcome(outcome *SignalOutcome) error {
 	return r.signals.UpdateSignalOutcome(outcome)
 }
 
+func (r *TradeRepository) UpdateOpenSignalOutcome(outcome *SignalOutcome) (bool, error) {
+	return r.signals.UpdateOpenSignalOutcome(outcome)
+}
+
 func (r *TradeRepository) GetSignalOutcomes(symbol string, status string, startTime, endTime time.Time, limit, offset int) ([]SignalOutcome, error) {
 	return r.signals.GetSignalOutcomes(symbol, status, startTime, endTime, limit, offset)
 }
@@ -810,6 +1075,22 @@ func (r *TradeRepository) GetSignalOutcomeBySignalID(signalID int64) (*SignalOut
 	return r.signals.GetSignalOutcomeBySignalID(signalID)
 }
 
+func (r *TradeRepository) GetSignalOutcomeByID(id int64) (*SignalOutcome, error) {
+	return r.signals.GetSignalOutcomeByID(id)
+}
+
+func (r *TradeRepository) GetLastOutcomeEntryTime(symbol, strategy string) (*time.Time, error) {
+	return r.signals.GetLastOutcomeEntryTime(symbol, strategy)
+}
+
+func (r *TradeRepository) GetLastLossExitTime(symbol string) (*time.Time, error) {
+	return r.signals.GetLastLossExitTime(symbol)
+}
+
+func (r *TradeRepository) CloseOrphanedOutcomes() (int64, error) {
+	return r.signals.CloseOrphanedOutcomes()
+}
+
 func (r *TradeRepository) GetOpenSignals(limit int) ([]TradingSignalDB, error) {
 	return r.signals.GetOpenSignals(limit)
 }
@@ -823,6 +1104,11 @@ func (r *TradeRepository) CalculateBaselinesDB(minutesBack int, minTrades int) (
 	return r.analytics.CalculateBaselinesDB(minutesBack, minTrades)
 }
 
+// CalculateSymbolBaselineDB calculates a single symbol's baseline without saving it
+func (r *TradeRepository) CalculateSymbolBaselineDB(symbol string, minutesBack int, minTrades int) (*models.StatisticalBaseline, error) {
+	return r.analytics.CalculateSymbolBaselineDB(symbol, minutesBack, minTrades)
+}
+
 func (r *TradeRepository) GetGlobalPerformanceStats() (*types.PerformanceStats, error) {
 	return r.signals.GetGlobalPerformanceStats()
 }
@@ -831,6 +1117,14 @@ func (r *TradeRepository) GetDailyStrategyPerformance(strategy, symbol string, l
 	return r.signals.GetDailyStrategyPerformance(strategy, symbol, limit)
 }
 
+func (r *TradeRepository) RefreshStrategyPerformanceDaily() error {
+	return r.signals.RefreshStrategyPerformanceDaily()
+}
+
+func (r *TradeRepository) StrategyPerformanceRefreshedAt() time.Time {
+	return r.signals.StrategyPerformanceRefreshedAt()
+}
+
 func (r *TradeRepository) EvaluateVolumeBreakoutStrategy(alert *models.WhaleAlert, zscores *types.ZScoreData, vwap float64, orderFlow *models.OrderFlowImbalance) *TradingSignal {
 	signal := r.signals.EvaluateVolumeBreakoutStrategy(alert, zscores, vwap, orderFlow)
 	// Convert models.TradingSignal back to TradingSignal
@@ -849,8 +1143,8 @@ func (r *TradeRepository) EvaluateVolumeBreakoutStrategy(alert *models.WhaleAler
 	}
 }
 
-func (r *TradeRepository) EvaluateMeanReversionStrategy(alert *models.WhaleAlert, zscores *types.ZScoreData, prevVolumeZScore float64, vwap float64, orderFlow *models.OrderFlowImbalance) *TradingSignal {
-	signal := r.signals.EvaluateMeanReversionStrategy(alert, zscores, prevVolumeZScore, vwap, orderFlow)
+func (r *TradeRepository) EvaluateMeanReversionStrategy(alert *models.WhaleAlert, zscores *types.ZScoreData, prevVolumeZScore float64, vwap float64, orderFlow *models.OrderFlowImbalance, rsi *float64) *TradingSignal {
+	signal := r.signals.EvaluateMeanReversionStrategy(alert, zscores, prevVolumeZScore, vwap, orderFlow, rsi)
 	// Convert models.TradingSignal back to TradingSignal
 	return &TradingSignal{
 		StockSymbol:  signal.StockSymbol,
@@ -888,9 +1182,13 @@ func (r *TradeRepository) EvaluateFakeoutFilterStrategy(alert *models.WhaleAlert
 func (r *TradeRepository) GetStrategySignals(lookbackMinutes int, minConfidence float64, strategyFilter string) ([]TradingSignal, error) {
 	// Get recent whale alerts
 	var alerts []models.WhaleAlert
-	err := r.db.db.Where("detected_at >= NOW() - INTERVAL '1 minute' * ?", lookbackMinutes).
-		Where("market_board != 'NG' OR market_board IS NULL").
-		Order("detected_at DESC").
+	query := r.db.db.Where("detected_at >= NOW() - INTERVAL '1 minute' * ?", lookbackMinutes)
+	if r.rgOnlySignals {
+		query = query.Where("market_board = 'RG'")
+	} else {
+		query = query.Where("market_board != 'NG' OR market_board IS NULL")
+	}
+	err := query.Order("detected_at DESC").
 		Limit(50).
 		Find(&alerts).Error
 
@@ -901,8 +1199,15 @@ func (r *TradeRepository) GetStrategySignals(lookbackMinutes int, minConfidence
 
 	log.Printf("📊 Found %d whale alerts in last %d minutes", len(alerts), lookbackMinutes)
 
+	// Symbols with operator preferences only run the strategies suited to them
+	prefs, err := r.GetSymbolStrategyPreferences()
+	if err != nil {
+		log.Printf("⚠️ Failed to load symbol strategy preferences, evaluating every strategy: %v", err)
+		prefs = nil
+	}
+
 	// Get signals from signals repository
-	modelSignals, err := r.signals.GetStrategySignals(lookbackMinutes, minConfidence, strategyFilter, alerts)
+	modelSignals, err := r.signals.GetStrategySignals(lookbackMinutes, minConfidence, strategyFilter, alerts, prefs)
 	if err != nil {
 		log.Printf("❌ Error generating strategy signals: %v", err)
 		return nil, err
@@ -949,6 +1254,22 @@ func (r *TradeRepository) GetAggregateBaseline() (*models.StatisticalBaseline, e
 	return r.analytics.GetAggregateBaseline()
 }
 
+func (r *TradeRepository) SaveCorporateAction(action *models.CorporateAction) error {
+	return r.analytics.SaveCorporateAction(action)
+}
+
+func (r *TradeRepository) GetCorporateActions(symbol string, limit int) ([]models.CorporateAction, error) {
+	return r.analytics.GetCorporateActions(symbol, limit)
+}
+
+func (r *TradeRepository) DetectOvernightGaps(thresholdPct float64) ([]models.CorporateAction, error) {
+	return r.analytics.DetectOvernightGaps(thresholdPct)
+}
+
+func (r *TradeRepository) InvalidateBaselines(symbol string) error {
+	return r.analytics.InvalidateBaselines(symbol)
+}
+
 func (r *TradeRepository) SaveDetectedPattern(pattern *models.DetectedPattern) error {
 	return r.analytics.SaveDetectedPattern(pattern)
 }
@@ -961,6 +1282,26 @@ func (r *TradeRepository) GetAllRecentPatterns(since time.Time) ([]models.Detect
 	return r.analytics.GetAllRecentPatterns(since)
 }
 
+// GetPatterns lists detected patterns with optional symbol and type filters
+func (r *TradeRepository) GetPatterns(symbol, patternType string, since time.Time, limit int) ([]models.DetectedPattern, error) {
+	return r.analytics.GetPatterns(symbol, patternType, since, limit)
+}
+
+// GetRecentCandleCloses returns a symbol's last n closes for a timeframe, oldest first
+func (r *TradeRepository) GetRecentCandleCloses(timeframe, symbol string, n int) ([]types.CandleClose, error) {
+	return r.trades.GetRecentCandleCloses(timeframe, symbol, n)
+}
+
+// GetOpeningGaps returns today's opening gaps against the previous daily close
+func (r *TradeRepository) GetOpeningGaps(dayStart time.Time, minGapPct float64) ([]types.OpeningGap, error) {
+	return r.analytics.GetOpeningGaps(dayStart, minGapPct)
+}
+
+// GetBollingerSqueezes returns symbols whose 5-minute Bollinger band width hit a lookback low
+func (r *TradeRepository) GetBollingerSqueezes(period, lookback int, freshSince time.Time) ([]types.BollingerSqueeze, error) {
+	return r.analytics.GetBollingerSqueezes(period, lookback, freshSince)
+}
+
 func (r *TradeRepository) UpdatePatternOutcome(id int64, outcome string, breakout bool, maxMove float64) error {
 	return r.analytics.UpdatePatternOutcome(id, outcome, breakout, maxMove)
 }
@@ -989,6 +1330,40 @@ func (r *TradeRepository) GetLatestOrderFlow(symbol string) (*models.OrderFlowIm
 	return r.analytics.GetLatestOrderFlow(symbol)
 }
 
+func (r *TradeRepository) GetLatestOrderFlowForSymbols(symbols []string) (map[string]*models.OrderFlowImbalance, error) {
+	return r.analytics.GetLatestOrderFlowForSymbols(symbols)
+}
+
+func (r *TradeRepository) GetLatestRegime(symbol string) (*models.MarketRegime, error) {
+	return r.analytics.GetLatestRegime(symbol)
+}
+
+func (r *TradeRepository) GetSectorSweeps(since time.Time, minSymbols int, minCorrelation float64) ([]types.SectorSweep, error) {
+	return r.analytics.GetSectorSweeps(since, minSymbols, minCorrelation)
+}
+
+func (r *TradeRepository) GetRegimeHistory(symbol string, since time.Time) ([]models.MarketRegime, error) {
+	return r.analytics.GetRegimeHistory(symbol, since)
+}
+
+// GetCandleFlow returns buy/sell volume aligned to the candle buckets of a timeframe
+func (r *TradeRepository) GetCandleFlow(timeframe, symbol string, startTime, endTime time.Time) ([]types.CandleFlow, error) {
+	view, err := trades.CandleView(timeframe)
+	if err != nil {
+		return nil, err
+	}
+	return r.analytics.GetOrderFlowBuckets(symbol, strings.TrimPrefix(view, "candle_"), startTime, endTime)
+}
+
+func (r *TradeRepository) GetOrderFlowTrend(symbol string, minutes int) (*types.OrderFlowTrend, error) {
+	return r.analytics.GetOrderFlowTrend(symbol, minutes)
+}
+
+// GetOrderFlowSummary returns the strongest buy- and sell-pressure symbols over the last N minutes
+func (r *TradeRepository) GetOrderFlowSummary(minutes int, minLots float64, limit int) ([]types.OrderFlowSummary, error) {
+	return r.analytics.GetOrderFlowSummary(minutes, minLots, limit)
+}
+
 // Webhook management methods (kept for backward compatibility)
 func (r *TradeRepository) GetWebhooks() ([]models.WhaleWebhook, error) {
 	var webhooks []models.WhaleWebhook
@@ -1013,6 +1388,195 @@ func (r *TradeRepository) DeleteWebhook(id int) error {
 	return r.db.db.Delete(&models.WhaleWebhook{}, id).Error
 }
 
+// Symbol whitelist/blacklist management
+func (r *TradeRepository) GetSymbolFilters() ([]models.SymbolFilter, error) {
+	var filters []models.SymbolFilter
+	err := r.db.db.Order("list_type ASC, stock_symbol ASC").Find(&filters).Error
+	return filters, err
+}
+
+func (r *TradeRepository) SaveSymbolFilter(filter *models.SymbolFilter) error {
+	return r.db.db.Where(models.SymbolFilter{StockSymbol: filter.StockSymbol, ListType: filter.ListType}).
+		Assign(models.SymbolFilter{Notes: filter.Notes}).
+		FirstOrCreate(filter).Error
+}
+
+func (r *TradeRepository) DeleteSymbolFilter(symbol, listType string) error {
+	return r.db.db.Where("stock_symbol = ? AND list_type = ?", symbol, listType).Delete(&models.SymbolFilter{}).Error
+}
+
+// Scheduled event (news blackout) management
+func (r *TradeRepository) GetScheduledEvents(since time.Time) ([]models.ScheduledEvent, error) {
+	var events []models.ScheduledEvent
+	err := r.db.db.Where("end_time >= ?", since).Order("start_time ASC").Find(&events).Error
+	return events, err
+}
+
+// GetActiveScheduledEvents returns events covering the given time that apply to the symbol or the whole market
+func (r *TradeRepository) GetActiveScheduledEvents(at time.Time, symbol string) ([]models.ScheduledEvent, error) {
+	var events []models.ScheduledEvent
+	err := r.db.db.Where("start_time <= ? AND end_time >= ?", at, at).
+		Where("stock_symbol IS NULL OR stock_symbol = '' OR stock_symbol = ?", symbol).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *TradeRepository) SaveScheduledEvent(event *models.ScheduledEvent) error {
+	return r.db.db.Save(event).Error
+}
+
+func (r *TradeRepository) DeleteScheduledEvent(id int64) error {
+	return r.db.db.Delete(&models.ScheduledEvent{}, id).Error
+}
+
+// Ping runs a trivial query to verify the database is reachable
+func (r *TradeRepository) Ping(ctx context.Context) error {
+	return r.db.db.WithContext(ctx).Exec("SELECT 1").Error
+}
+
+// GetLatestCandleBucket returns the newest candle_1min bucket of the last day, or nil when there is none
+func (r *TradeRepository) GetLatestCandleBucket(ctx context.Context) (*time.Time, error) {
+	var bucket *time.Time
+	err := r.db.db.WithContext(ctx).
+		Raw("SELECT MAX(bucket) FROM candle_1min WHERE bucket >= NOW() - INTERVAL '1 day'").
+		Scan(&bucket).Error
+	return bucket, err
+}
+
+// Alert suppression (snooze) rules
+func (r *TradeRepository) GetSuppressionRules(since time.Time) ([]models.SuppressionRule, error) {
+	var rules []models.SuppressionRule
+	err := r.db.db.Where("until >= ?", since).Order("until ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetActiveSuppressionRule returns a rule muting an alert of this symbol and type at the given time, or nil
+// Rules only apply to alerts detected after they were created, so replays of old data are unaffected
+func (r *TradeRepository) GetActiveSuppressionRule(at time.Time, symbol, alertType string) (*models.SuppressionRule, error) {
+	var rules []models.SuppressionRule
+	err := r.db.db.Where("created_at <= ? AND until > ?", at, at).
+		Where("stock_symbol IS NULL OR stock_symbol = '' OR stock_symbol = ?", symbol).
+		Where("alert_type IS NULL OR alert_type = '' OR alert_type = ?", alertType).
+		Order("until DESC").Limit(1).Find(&rules).Error
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	return &rules[0], nil
+}
+
+func (r *TradeRepository) SaveSuppressionRule(rule *models.SuppressionRule) error {
+	return r.db.db.Save(rule).Error
+}
+
+func (r *TradeRepository) DeleteSuppressionRule(id int64) error {
+	return r.db.db.Delete(&models.SuppressionRule{}, id).Error
+}
+
+// GetPendingSellSignals returns recent SELL signals that have no exit signal record yet
+func (r *TradeRepository) GetPendingSellSignals(limit int) ([]models.TradingSignalDB, error) {
+	var signals []models.TradingSignalDB
+	handled := r.db.db.Model(&models.ExitSignal{}).Select("signal_id")
+	err := r.db.db.Where("id NOT IN (?)", handled).
+		Where("decision = ?", "SELL").
+		Where("generated_at >= ?", time.Now().Add(-15*time.Minute)).
+		Order("generated_at ASC").Limit(limit).Find(&signals).Error
+	return signals, err
+}
+
+func (r *TradeRepository) SaveExitSignals(records []models.ExitSignal) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.db.db.Create(&records).Error
+}
+
+// GetExitSignals returns exit signal records since a time, newest first, optionally for one symbol
+func (r *TradeRepository) GetExitSignals(symbol string, since time.Time, limit int) ([]models.ExitSignal, error) {
+	var records []models.ExitSignal
+	query := r.db.db.Where("created_at >= ?", since)
+	if symbol != "" {
+		query = query.Where("stock_symbol = ?", symbol)
+	}
+	err := query.Order("created_at DESC").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// UpsertPreOpenSnapshots saves the latest pre-opening auction state, one row per symbol and day
+func (r *TradeRepository) UpsertPreOpenSnapshots(snapshots []models.PreOpenSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return r.db.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&snapshots).Error
+}
+
+// GetPreOpenImbalances returns a day's pre-opening snapshots with |imbalance_pct| >= minPct, largest
+// imbalance first, each with the number of whale alerts detected in [from, to)
+func (r *TradeRepository) GetPreOpenImbalances(tradeDate string, minPct float64, side string, from, to time.Time, limit int) ([]types.PreOpenImbalance, error) {
+	var results []types.PreOpenImbalance
+	query := r.db.db.Table("preopen_snapshots p").
+		Select(`p.*, (
+			SELECT COUNT(*) FROM whale_alerts wa
+			WHERE wa.stock_symbol = p.stock_symbol AND wa.detected_at >= ? AND wa.detected_at < ?
+		) AS whale_alerts`, from, to).
+		Where("p.trade_date = ? AND ABS(p.imbalance_pct) >= ?", tradeDate, minPct)
+	switch side {
+	case "BUY":
+		query = query.Where("p.imbalance_lots > 0")
+	case "SELL":
+		query = query.Where("p.imbalance_lots < 0")
+	}
+	err := query.Order("ABS(p.imbalance_lots) DESC").Limit(limit).Scan(&results).Error
+	return results, err
+}
+
+// Strategy enable/disable switches
+func (r *TradeRepository) GetStrategyConfigs() ([]models.StrategyConfig, error) {
+	var configs []models.StrategyConfig
+	err := r.db.db.Order("strategy ASC").Find(&configs).Error
+	return configs, err
+}
+
+func (r *TradeRepository) SaveStrategyConfig(cfg *models.StrategyConfig) error {
+	return r.db.db.Save(cfg).Error
+}
+
+// Per-symbol strategy preferences (PREFER / AVOID)
+func (r *TradeRepository) GetSymbolStrategyPreferences() ([]models.SymbolStrategyPreference, error) {
+	var prefs []models.SymbolStrategyPreference
+	err := r.db.db.Order("stock_symbol ASC, strategy ASC").Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *TradeRepository) SaveSymbolStrategyPreference(pref *models.SymbolStrategyPreference) error {
+	return r.db.db.Save(pref).Error
+}
+
+func (r *TradeRepository) DeleteSymbolStrategyPreference(symbol, strategy string) error {
+	return r.db.db.Where("stock_symbol = ? AND strategy = ?", symbol, strategy).
+		Delete(&models.SymbolStrategyPreference{}).Error
+}
+
+// Symbol size data (free float / market cap) for whale impact
+func (r *TradeRepository) GetSymbolMetas() ([]models.SymbolMeta, error) {
+	var metas []models.SymbolMeta
+	err := r.db.db.Order("stock_symbol ASC").Find(&metas).Error
+	return metas, err
+}
+
+// GetSymbolMeta returns nil without error when the symbol has no meta
+func (r *TradeRepository) GetSymbolMeta(symbol string) (*models.SymbolMeta, error) {
+	var meta models.SymbolMeta
+	err := r.db.db.Where("stock_symbol = ?", symbol).Limit(1).Find(&meta).Error
+	if err != nil || meta.StockSymbol == "" {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (r *TradeRepository) SaveSymbolMeta(meta *models.SymbolMeta) error {
+	return r.db.db.Save(meta).Error
+}
+
 // GetRecentSignalsWithOutcomes retrieves recent persisted signals with their outcomes
 func (r *TradeRepository) GetRecentSignalsWithOutcomes(lookbackMinutes int, minConfidence float64, strategyFilter string) ([]TradingSignal, error) {
 	return r.signals.GetRecentSignalsWithOutcomes(lookbackMinutes, minConfidence, strategyFilter)
@@ -1027,6 +1591,16 @@ func (r *TradeRepository) GetStrategyEffectiveness(daysBack int) ([]types.Strate
 	return r.signals.GetStrategyEffectiveness(daysBack)
 }
 
+// GetStrategyEffectivenessByRegime returns pre-aggregated effectiveness for a strategy in a market regime
+func (r *TradeRepository) GetStrategyEffectivenessByRegime(strategy, regime string) (*types.StrategyEffectiveness, error) {
+	return r.signals.GetStrategyEffectivenessByRegime(strategy, regime)
+}
+
+// RefreshStrategyRegimeEffectiveness refreshes the strategy_regime_effectiveness view
+func (r *TradeRepository) RefreshStrategyRegimeEffectiveness() error {
+	return r.signals.RefreshStrategyRegimeEffectiveness()
+}
+
 // GetOptimalConfidenceThresholds calculates optimal confidence thresholds per strategy
 func (r *TradeRepository) GetOptimalConfidenceThresholds(daysBack int) ([]types.OptimalThreshold, error) {
 	return r.signals.GetOptimalConfidenceThresholds(daysBack)
@@ -1037,30 +1611,73 @@ func (r *TradeRepository) GetTimeOfDayEffectiveness(daysBack int) ([]types.TimeE
 	return r.signals.GetTimeOfDayEffectiveness(daysBack)
 }
 
+// GetHourlyExpectedValue returns expected value per strategy and WIB hour of day
+func (r *TradeRepository) GetHourlyExpectedValue(strategy string, daysBack int) ([]types.HourlyExpectedValue, error) {
+	return r.signals.GetHourlyExpectedValue(strategy, daysBack)
+}
+
+// GetExitReasonStats aggregates closed outcomes by exit reason
+func (r *TradeRepository) GetExitReasonStats(daysBack int, strategy string) ([]types.ExitReasonStats, error) {
+	return r.signals.GetExitReasonStats(daysBack, strategy)
+}
+
+// GetSignalLatency returns the signal-to-outcome tracking latency distribution
+func (r *TradeRepository) GetSignalLatency(daysBack int, strategy string) ([]types.SignalLatency, error) {
+	return r.signals.GetSignalLatency(daysBack, strategy)
+}
+
+// GetStrategyComparison returns side-by-side metrics for each strategy
+func (r *TradeRepository) GetStrategyComparison(daysBack int) ([]types.StrategyComparison, error) {
+	return r.signals.GetStrategyComparison(daysBack)
+}
+
 // GetSignalExpectedValues returns expected value calculations for all strategies
 func (r *TradeRepository) GetSignalExpectedValues(daysBack int) ([]types.SignalExpectedValue, error) {
 	return r.signals.GetSignalExpectedValues(daysBack)
 }
 
+// mlHasFeatures matches signals whose analysis_data is a non-empty JSON object (usable feature vector)
+const mlHasFeatures = "jsonb_typeof(s.analysis_data) = 'object' AND s.analysis_data != '{}'::jsonb"
+
 // GetMLTrainingData retrieves joined data for machine learning training
-func (r *TradeRepository) GetMLTrainingData() ([]models.MLTrainingData, error) {
+// Rows without a usable feature vector are excluded unless includeIncomplete is set
+func (r *TradeRepository) GetMLTrainingData(includeIncomplete bool) ([]models.MLTrainingData, error) {
 	var results []models.MLTrainingData
 
 	// Query to join signals with outcomes and flatten result
 	// OPTIMIZATION: Include OPEN outcomes for real-time training data
 	// Cast JSONB to text for CSV export while filtering using proper JSONB operators
-	err := r.db.db.Table("trading_signals s").
-		Select("s.generated_at, s.stock_symbol, s.strategy, s.confidence, s.analysis_data::text as analysis_data, o.outcome_status as outcome_result, o.profit_loss_pct, o.exit_reason").
+	query := r.db.db.Table("trading_signals s").
+		Select("s.generated_at, s.stock_symbol, s.strategy, s.confidence, COALESCE(s.analysis_data::text, '') as analysis_data, o.outcome_status as outcome_result, o.profit_loss_pct, o.exit_reason, " +
+			"o.entry_price, COALESCE(o.holding_period_minutes, 0) as holding_minutes, " +
+			"COALESCE(o.max_adverse_excursion, 0) as mae, COALESCE(o.max_favorable_excursion, 0) as mfe").
 		Joins("JOIN signal_outcomes o ON s.id = o.signal_id").
-		Where("s.analysis_data IS NOT NULL").
-		Where("s.analysis_data != '{}'::jsonb").                           // Exclude empty JSONB objects
-		Where("o.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN', 'OPEN')"). // Include OPEN for real-time training
-		Order("s.generated_at DESC").
-		Scan(&results).Error
+		Where("o.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN', 'OPEN')") // Include OPEN for real-time training
+
+	if !includeIncomplete {
+		query = query.Where(mlHasFeatures)
+	}
+
+	err := query.Order("s.generated_at DESC").Scan(&results).Error
 
 	return results, err
 }
 
+// GetSignalIDsMissingFeatures returns IDs of signals with outcomes but no usable feature vector
+func (r *TradeRepository) GetSignalIDsMissingFeatures(limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.db.Table("trading_signals s").
+		Select("DISTINCT s.id").
+		Joins("JOIN signal_outcomes o ON s.id = o.signal_id").
+		Where("NOT COALESCE("+mlHasFeatures+", false)").
+		Limit(limit).
+		Pluck("s.id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetSignalIDsMissingFeatures: %w", err)
+	}
+	return ids, nil
+}
+
 // GetMLTrainingDataStats returns statistics about ML training data availability
 func (r *TradeRepository) GetMLTrainingDataStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -1105,13 +1722,24 @@ func (r *TradeRepository) GetMLTrainingDataStats() (map[string]interface{}, erro
 	var completeRecords int64
 	if err := r.db.db.Table("trading_signals s").
 		Joins("JOIN signal_outcomes o ON s.id = o.signal_id").
-		Where("s.analysis_data IS NOT NULL AND s.analysis_data != '{}'::jsonb").
+		Where(mlHasFeatures).
 		Where("o.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN', 'OPEN')").
 		Count(&completeRecords).Error; err != nil {
 		return nil, err
 	}
 	stats["complete_training_records"] = completeRecords
 
+	// Count joined rows dropped from the export for missing/unusable features
+	var missingFeatures int64
+	if err := r.db.db.Table("trading_signals s").
+		Joins("JOIN signal_outcomes o ON s.id = o.signal_id").
+		Where("NOT COALESCE(" + mlHasFeatures + ", false)").
+		Where("o.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN', 'OPEN')").
+		Count(&missingFeatures).Error; err != nil {
+		return nil, err
+	}
+	stats["dropped_missing_features"] = missingFeatures
+
 	// Count by outcome status
 	var recordsByOutcome []struct {
 		Status string
@@ -1120,7 +1748,7 @@ func (r *TradeRepository) GetMLTrainingDataStats() (map[string]interface{}, erro
 	if err := r.db.db.Table("trading_signals s").
 		Select("o.outcome_status as status, COUNT(*) as count").
 		Joins("JOIN signal_outcomes o ON s.id = o.signal_id").
-		Where("s.analysis_data IS NOT NULL AND s.analysis_data != '{}'::jsonb").
+		Where(mlHasFeatures).
 		Group("o.outcome_status").
 		Scan(&recordsByOutcome).Error; err != nil {
 		return nil, err
@@ -0,0 +1,91 @@
+package signals
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	models "stockbit-haka-haki/database/models_pkg"
+)
+
+// Signal cache bounds: signal rows don't change after creation (analysis_data updates invalidate
+// the entry), so the TTL only has to cover retention deletes
+const (
+	signalCacheSize = 5000
+	signalCacheTTL  = 10 * time.Minute
+)
+
+// signalCache is a small LRU of trading signals keyed by ID with a per-entry TTL
+type signalCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // Front = most recently used
+	entries map[int64]*list.Element
+}
+
+type signalCacheEntry struct {
+	signal    models.TradingSignalDB
+	expiresAt time.Time
+}
+
+func newSignalCache(size int, ttl time.Duration) *signalCache {
+	return &signalCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[int64]*list.Element),
+	}
+}
+
+// get returns a copy of the cached signal so callers can't modify the cached row
+func (c *signalCache) get(id int64) (*models.TradingSignalDB, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*signalCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	signal := entry.signal
+	return &signal, true
+}
+
+func (c *signalCache) put(signal *models.TradingSignalDB) {
+	if signal == nil || signal.ID == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &signalCacheEntry{signal: *signal, expiresAt: time.Now().Add(c.ttl)}
+	if elem, ok := c.entries[signal.ID]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[signal.ID] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*signalCacheEntry).signal.ID)
+	}
+}
+
+func (c *signalCache) invalidate(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+	}
+}
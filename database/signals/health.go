@@ -0,0 +1,145 @@
+package signals
+
+import (
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"stockbit-haka-haki/database/types"
+)
+
+// Data anomaly kinds recorded per symbol evaluation
+const (
+	anomalyFlatBaseline  = "flat_baseline"  // Baseline stddev below the floor (see baselineTooFlat)
+	anomalyClampedZScore = "clamped_zscore" // Z-score hit the clamp bound
+	anomalyMissingData   = "missing_data"   // No usable baseline and too few recent candles
+)
+
+// symbolHealth quarantines symbols whose data keeps producing anomalies, which usually means
+// a halt, delisting or unadjusted split rather than a trading opportunity
+type symbolHealth struct {
+	mu          sync.Mutex
+	threshold   int // Anomalous evaluations within window that trigger a quarantine
+	window      time.Duration
+	duration    time.Duration // How long a quarantined symbol is skipped
+	anomalies   map[string][]symbolAnomaly
+	quarantined map[string]types.QuarantinedSymbol
+}
+
+type symbolAnomaly struct {
+	at    time.Time
+	kinds []string
+}
+
+func newSymbolHealth(threshold int, window, duration time.Duration) *symbolHealth {
+	return &symbolHealth{
+		threshold:   threshold,
+		window:      window,
+		duration:    duration,
+		anomalies:   make(map[string][]symbolAnomaly),
+		quarantined: make(map[string]types.QuarantinedSymbol),
+	}
+}
+
+// record notes one anomalous evaluation of symbol and quarantines it once threshold
+// evaluations fall inside the window
+func (h *symbolHealth) record(symbol string, kinds []string, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.quarantined[symbol]; ok {
+		return
+	}
+
+	recent := h.anomalies[symbol][:0]
+	for _, a := range h.anomalies[symbol] {
+		if now.Sub(a.at) < h.window {
+			recent = append(recent, a)
+		}
+	}
+	recent = append(recent, symbolAnomaly{at: now, kinds: kinds})
+	if len(recent) < h.threshold {
+		h.anomalies[symbol] = recent
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, a := range recent {
+		for _, kind := range a.kinds {
+			counts[kind]++
+		}
+	}
+	delete(h.anomalies, symbol)
+	h.quarantined[symbol] = types.QuarantinedSymbol{
+		StockSymbol: symbol,
+		Since:       now,
+		Until:       now.Add(h.duration),
+		Anomalies:   counts,
+	}
+	log.Printf("🚧 Quarantined %s for %s after %d anomalous evaluations in %s: %v",
+		symbol, h.duration, len(recent), h.window, counts)
+}
+
+// healthy clears a symbol's anomaly history after an evaluation with clean data
+func (h *symbolHealth) healthy(symbol string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.anomalies, symbol)
+}
+
+// isQuarantined reports whether symbol is currently quarantined, releasing it once the
+// quarantine has expired so the next evaluation can show whether its data has recovered
+func (h *symbolHealth) isQuarantined(symbol string, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	q, ok := h.quarantined[symbol]
+	if !ok {
+		return false
+	}
+	if now.Before(q.Until) {
+		return true
+	}
+	delete(h.quarantined, symbol)
+	log.Printf("✅ Released %s from quarantine", symbol)
+	return false
+}
+
+// release ends a symbol's quarantine early, returning false if it wasn't quarantined
+func (h *symbolHealth) release(symbol string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.quarantined[symbol]; !ok {
+		return false
+	}
+	delete(h.quarantined, symbol)
+	delete(h.anomalies, symbol)
+	log.Printf("✅ Released %s from quarantine (manual)", symbol)
+	return true
+}
+
+// list returns the active quarantines ordered by symbol
+func (h *symbolHealth) list(now time.Time) []types.QuarantinedSymbol {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]types.QuarantinedSymbol, 0, len(h.quarantined))
+	for _, q := range h.quarantined {
+		if now.Before(q.Until) {
+			result = append(result, q)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StockSymbol < result[j].StockSymbol })
+	return result
+}
+
+// appendAnomaly adds kind to kinds unless it's already there
+func appendAnomaly(kinds []string, kind string) []string {
+	if slices.Contains(kinds, kind) {
+		return kinds
+	}
+	return append(kinds, kind)
+}
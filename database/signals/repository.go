@@ -3,14 +3,19 @@ package signals
 import (
 	"fmt"
 	"log"
+	"math"
+	"slices"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"stockbit-haka-haki/database/analytics"
 	models "stockbit-haka-haki/database/models_pkg"
 	"stockbit-haka-haki/database/trades"
 	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/indicators"
 
+	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
 
@@ -19,6 +24,172 @@ type Repository struct {
 	db        *gorm.DB
 	analytics *analytics.Repository
 	trades    *trades.Repository
+
+	// Baseline z-score guards (see SetZScoreLimits)
+	zScoreClamp  float64
+	minStdDevPct float64
+	clampCount   atomic.Int64
+
+	// Confidence decay for old baselines (see SetBaselineDecay); 0 disables
+	baselineDecayAfter   time.Duration
+	baselineDecayPerHour float64
+
+	// Calibrated confidence per strategy (see SetConfidenceModels); empty means linear confidence
+	confidenceModels map[string]types.ConfidenceModel
+
+	perfRefreshedAt atomic.Int64 // UnixNano of the last strategy_performance_daily refresh
+
+	signalWorkers int // Symbols evaluated concurrently by GetStrategySignals (see SetSignalWorkers)
+
+	squeezeBoost float64 // VOLUME_BREAKOUT confidence multiplier after a SQUEEZE (see SetSqueezeBreakoutBoost); <= 1 disables
+
+	// RSI confirmation for MEAN_REVERSION (see SetMeanReversionRSI); period 0 disables
+	mrRSITimeframe  string
+	mrRSIPeriod     int
+	mrRSIOverbought float64
+	mrRSIOversold   float64
+
+	cache *signalCache // Signals by ID for GetSignalByID / GetSignalsByIDs
+
+	health *symbolHealth // Per-symbol data anomaly quarantine (see SetSymbolQuarantine); nil disables
+}
+
+// Default z-score guards used until SetZScoreLimits is called
+const (
+	defaultZScoreClamp = 100.0
+	minAbsStdDev       = 0.0001 // Division-by-zero guard
+
+	minBaselineDecayFactor = 0.5 // Stale baselines never cut confidence by more than half
+)
+
+// SetZScoreLimits configures the z-score clamp bound and the minimum baseline stddev
+// (as % of the mean) below which a baseline is too flat to generate signals from
+func (r *Repository) SetZScoreLimits(clamp, minStdDevPct float64) {
+	if clamp <= 0 {
+		clamp = defaultZScoreClamp
+	}
+	r.zScoreClamp = clamp
+	r.minStdDevPct = minStdDevPct
+}
+
+// clampZScore bounds z to ±zScoreClamp, logging each time the bound is hit
+func (r *Repository) clampZScore(symbol, kind string, z float64) (float64, bool) {
+	bound := r.zScoreClamp
+	if bound <= 0 {
+		bound = defaultZScoreClamp
+	}
+	if math.Abs(z) <= bound {
+		return z, false
+	}
+	clamped := math.Copysign(bound, z)
+	log.Printf("⚠️ Clamped %s %s z-score %.1f → %.0f (total clamps: %d)", symbol, kind, z, clamped, r.clampCount.Add(1))
+	return clamped, true
+}
+
+// baselineTooFlat reports whether stddev is below the configured % of mean
+func (r *Repository) baselineTooFlat(stdDev, mean float64) bool {
+	if stdDev <= minAbsStdDev {
+		return true
+	}
+	return r.minStdDevPct > 0 && mean > 0 && stdDev/mean*100 < r.minStdDevPct
+}
+
+// SetSymbolQuarantine skips a symbol's alerts for quarantineMinutes once anomalies (flat
+// baselines, clamped z-scores, missing data) show up in that many evaluations within
+// windowMinutes. A clean evaluation resets the count; anomalies <= 0 disables the quarantine.
+func (r *Repository) SetSymbolQuarantine(anomalies, windowMinutes, quarantineMinutes int) {
+	if anomalies <= 0 || windowMinutes <= 0 || quarantineMinutes <= 0 {
+		r.health = nil
+		return
+	}
+	r.health = newSymbolHealth(anomalies, time.Duration(windowMinutes)*time.Minute, time.Duration(quarantineMinutes)*time.Minute)
+}
+
+// GetQuarantinedSymbols returns the symbols currently excluded from signal generation
+func (r *Repository) GetQuarantinedSymbols() []types.QuarantinedSymbol {
+	if r.health == nil {
+		return []types.QuarantinedSymbol{}
+	}
+	return r.health.list(time.Now())
+}
+
+// ReleaseQuarantinedSymbol ends a symbol's quarantine early, returning false if it wasn't quarantined
+func (r *Repository) ReleaseQuarantinedSymbol(symbol string) bool {
+	return r.health != nil && r.health.release(symbol)
+}
+
+// SetBaselineDecay makes signals scored against a baseline older than afterMinutes lose
+// perHour of their confidence for each further hour of baseline age
+func (r *Repository) SetBaselineDecay(afterMinutes int, perHour float64) {
+	r.baselineDecayAfter = time.Duration(afterMinutes) * time.Minute
+	r.baselineDecayPerHour = perHour
+}
+
+// baselineDecayFactor returns the confidence multiplier for a baseline calculated at calculatedAt
+func (r *Repository) baselineDecayFactor(calculatedAt time.Time) float64 {
+	if r.baselineDecayAfter <= 0 || r.baselineDecayPerHour <= 0 {
+		return 1.0
+	}
+	overdue := time.Since(calculatedAt) - r.baselineDecayAfter
+	if overdue <= 0 {
+		return 1.0
+	}
+	return max(1-r.baselineDecayPerHour*overdue.Hours(), minBaselineDecayFactor)
+}
+
+// SetConfidenceModels switches the given strategies to calibrated confidence, where a
+// BUY/SELL confidence is the logistic model's P(win) instead of the linear z-score mapping
+func (r *Repository) SetConfidenceModels(byStrategy map[string]types.ConfidenceModel) {
+	r.confidenceModels = byStrategy
+}
+
+// calibrateConfidence replaces an actionable signal's confidence with the strategy's modelled P(win)
+func (r *Repository) calibrateConfidence(signal *models.TradingSignal, orderFlow *models.OrderFlowImbalance) {
+	model, ok := r.confidenceModels[signal.Strategy]
+	if !ok || (signal.Decision != "BUY" && signal.Decision != "SELL") {
+		return
+	}
+
+	aggressiveBuy := 0.5 // Neutral when order flow is unknown
+	if orderFlow != nil && orderFlow.AggressiveBuyPct != nil {
+		aggressiveBuy = *orderFlow.AggressiveBuyPct / 100
+	}
+
+	logit := model.Intercept +
+		model.PriceZ*signal.PriceZScore +
+		model.VolumeZ*signal.VolumeZScore +
+		model.AggressiveBuy*aggressiveBuy +
+		model.PriceChange*signal.Change
+	signal.Confidence = 1 / (1 + math.Exp(-logit))
+	signal.Reason += fmt.Sprintf(" (Calibrated P(win): %.0f%%)", signal.Confidence*100)
+}
+
+// SetSignalWorkers bounds how many symbols GetStrategySignals evaluates concurrently
+func (r *Repository) SetSignalWorkers(n int) {
+	r.signalWorkers = n
+}
+
+// SetSqueezeBreakoutBoost multiplies VOLUME_BREAKOUT confidence when the symbol had a SQUEEZE
+// pattern in the last two hours; 1 or less disables the boost
+func (r *Repository) SetSqueezeBreakoutBoost(multiplier float64) {
+	r.squeezeBoost = multiplier
+}
+
+// SetMeanReversionRSI requires MEAN_REVERSION entries to be confirmed by RSI over timeframe candles:
+// above overbought for SELL, below oversold for BUY. A period of 0 disables the check.
+func (r *Repository) SetMeanReversionRSI(timeframe string, period int, overbought, oversold float64) {
+	r.mrRSITimeframe = timeframe
+	r.mrRSIPeriod = period
+	r.mrRSIOverbought = overbought
+	r.mrRSIOversold = oversold
+}
+
+// signalWorkerLimit returns the configured worker count, or 1 (serial) when unset
+func (r *Repository) signalWorkerLimit() int {
+	if r.signalWorkers <= 0 {
+		return 1
+	}
+	return r.signalWorkers
 }
 
 // SetAnalyticsRepository sets the analytics repository for strategy evaluation
@@ -33,14 +204,23 @@ func (r *Repository) SetTradesRepository(tradesRepo *trades.Repository) {
 
 // NewRepository creates a new signals repository
 func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: db, zScoreClamp: defaultZScoreClamp, cache: newSignalCache(signalCacheSize, signalCacheTTL)}
 }
 
 // SaveTradingSignal persists a trading signal to the database
 func (r *Repository) SaveTradingSignal(signal *models.TradingSignalDB) error {
+	// Persisted confidence must always be on the 0-1 scale
+	if signal.Confidence < 0 || signal.Confidence > 1 {
+		normalized := models.NormalizeConfidence(signal.Confidence)
+		log.Printf("⚠️ Signal confidence out of range for %s (%s): %.2f → %.2f",
+			signal.StockSymbol, signal.Strategy, signal.Confidence, normalized)
+		signal.Confidence = normalized
+	}
+
 	if err := r.db.Create(signal).Error; err != nil {
 		return fmt.Errorf("SaveTradingSignal: %w", err)
 	}
+	r.cache.put(signal)
 	return nil
 }
 
@@ -79,6 +259,10 @@ func (r *Repository) GetTradingSignals(symbol string, strategy string, decision
 
 // GetSignalByID retrieves a specific signal by ID
 func (r *Repository) GetSignalByID(id int64) (*models.TradingSignalDB, error) {
+	if signal, ok := r.cache.get(id); ok {
+		return signal, nil
+	}
+
 	var signal models.TradingSignalDB
 	err := r.db.First(&signal, id).Error
 	if err == gorm.ErrRecordNotFound {
@@ -88,29 +272,51 @@ func (r *Repository) GetSignalByID(id int64) (*models.TradingSignalDB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("GetSignalByID: %w", err)
 	}
+	r.cache.put(&signal)
 	return &signal, nil
 }
 
 // OPTIMIZATION: GetSignalsByIDs retrieves multiple signals by IDs in a single query
-// Eliminates N+1 query problem when fetching signals for multiple outcomes
+// Eliminates N+1 query problem when fetching signals for multiple outcomes; cached signals skip the query
 func (r *Repository) GetSignalsByIDs(ids []int64) (map[int64]*models.TradingSignalDB, error) {
-	if len(ids) == 0 {
-		return make(map[int64]*models.TradingSignalDB), nil
+	result := make(map[int64]*models.TradingSignalDB, len(ids))
+	var missing []int64
+	for _, id := range ids {
+		if _, done := result[id]; done {
+			continue
+		}
+		if signal, ok := r.cache.get(id); ok {
+			result[id] = signal
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
 	}
 
 	var signals []models.TradingSignalDB
-	err := r.db.Where("id IN ?", ids).Find(&signals).Error
+	err := r.db.Where("id IN ?", missing).Find(&signals).Error
 	if err != nil {
 		return nil, fmt.Errorf("GetSignalsByIDs: %w", err)
 	}
 
-	result := make(map[int64]*models.TradingSignalDB, len(signals))
 	for i := range signals {
+		r.cache.put(&signals[i])
 		result[signals[i].ID] = &signals[i]
 	}
 	return result, nil
 }
 
+// UpdateSignalAnalysisData replaces the analysis_data JSON of a signal
+func (r *Repository) UpdateSignalAnalysisData(id int64, data string) error {
+	if err := r.db.Model(&models.TradingSignalDB{}).Where("id = ?", id).Update("analysis_data", data).Error; err != nil {
+		return fmt.Errorf("UpdateSignalAnalysisData: %w", err)
+	}
+	r.cache.invalidate(id)
+	return nil
+}
+
 // SaveSignalOutcome creates a new signal outcome record
 func (r *Repository) SaveSignalOutcome(outcome *models.SignalOutcome) error {
 	if err := r.db.Create(outcome).Error; err != nil {
@@ -127,6 +333,17 @@ func (r *Repository) UpdateSignalOutcome(outcome *models.SignalOutcome) error {
 	return nil
 }
 
+// UpdateOpenSignalOutcome saves an outcome only while its stored row is still OPEN, so a
+// concurrent close (manual or automatic) is never overwritten by a stale copy. Returns false
+// when the row was already closed.
+func (r *Repository) UpdateOpenSignalOutcome(outcome *models.SignalOutcome) (bool, error) {
+	result := r.db.Model(outcome).Where("outcome_status = ?", "OPEN").Select("*").Updates(outcome)
+	if result.Error != nil {
+		return false, fmt.Errorf("UpdateOpenSignalOutcome: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // GetSignalOutcomes retrieves signal outcomes with filters
 func (r *Repository) GetSignalOutcomes(symbol string, status string, startTime, endTime time.Time, limit, offset int) ([]models.SignalOutcome, error) {
 	var outcomes []models.SignalOutcome
@@ -171,6 +388,77 @@ func (r *Repository) GetSignalOutcomeBySignalID(signalID int64) (*models.SignalO
 	return &outcome, nil
 }
 
+// GetSignalOutcomeByID retrieves a signal outcome (position) by its own ID
+func (r *Repository) GetSignalOutcomeByID(id int64) (*models.SignalOutcome, error) {
+	var outcome models.SignalOutcome
+	err := r.db.Where("id = ?", id).First(&outcome).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("GetSignalOutcomeByID: %w", err)
+	}
+	return &outcome, nil
+}
+
+// GetLastOutcomeEntryTime returns the entry time of the most recent outcome for a symbol+strategy
+// Used as a DB-backed cooldown when Redis is unavailable. Returns nil if no outcome exists.
+func (r *Repository) GetLastOutcomeEntryTime(symbol, strategy string) (*time.Time, error) {
+	var entryTimes []time.Time
+	err := r.db.Table("signal_outcomes so").
+		Select("so.entry_time").
+		Joins("JOIN trading_signals ts ON ts.id = so.signal_id").
+		Where("so.stock_symbol = ? AND ts.strategy = ?", symbol, strategy).
+		Order("so.entry_time DESC").
+		Limit(1).
+		Pluck("so.entry_time", &entryTimes).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetLastOutcomeEntryTime: %w", err)
+	}
+	if len(entryTimes) == 0 {
+		return nil, nil
+	}
+	return &entryTimes[0], nil
+}
+
+// GetLastLossExitTime returns the exit time of the most recent LOSS outcome for a symbol
+// Used as the post-loss cooldown fallback when Redis is unavailable. Returns nil if none exists.
+func (r *Repository) GetLastLossExitTime(symbol string) (*time.Time, error) {
+	var exitTimes []time.Time
+	err := r.db.Model(&models.SignalOutcome{}).
+		Where("stock_symbol = ? AND outcome_status = ? AND exit_time IS NOT NULL", symbol, "LOSS").
+		Order("exit_time DESC").
+		Limit(1).
+		Pluck("exit_time", &exitTimes).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetLastLossExitTime: %w", err)
+	}
+	if len(exitTimes) == 0 {
+		return nil, nil
+	}
+	return &exitTimes[0], nil
+}
+
+// CloseOrphanedOutcomes closes OPEN outcomes whose trading signal no longer exists
+// (e.g. deleted by retention), marking them ORPHANED with exit reason SIGNAL_DELETED
+// so they stop counting toward open-position limits. Returns the number closed.
+func (r *Repository) CloseOrphanedOutcomes() (int64, error) {
+	result := r.db.Exec(`
+		UPDATE signal_outcomes so
+		SET outcome_status = 'ORPHANED',
+			exit_reason = ?,
+			exit_time = NOW(),
+			holding_period_minutes = GREATEST(EXTRACT(EPOCH FROM (NOW() - so.entry_time)) / 60, 0)::INT
+		WHERE so.outcome_status = 'OPEN'
+			AND NOT EXISTS (SELECT 1 FROM trading_signals ts WHERE ts.id = so.signal_id)
+	`, models.ExitReasonSignalDeleted)
+	if result.Error != nil {
+		return 0, fmt.Errorf("CloseOrphanedOutcomes: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // GetOpenSignals retrieves signals that don't have outcomes yet
 // Only retrieves recent BUY signals to avoid processing stale or non-actionable signals over and over
 func (r *Repository) GetOpenSignals(limit int) ([]models.TradingSignalDB, error) {
@@ -316,14 +604,28 @@ func (r *Repository) GetGlobalPerformanceStats() (*types.PerformanceStats, error
 	return &stats, nil
 }
 
-// GetDailyStrategyPerformance retrieves daily aggregated performance data
-func (r *Repository) GetDailyStrategyPerformance(strategy, symbol string, limit int) ([]map[string]interface{}, error) {
-	// Refresh materialized view to ensure latest data
+// RefreshStrategyPerformanceDaily rebuilds the strategy_performance_daily view and records when
+// Runs on a schedule (PerformanceRefresher) rather than per read
+func (r *Repository) RefreshStrategyPerformanceDaily() error {
 	if err := r.db.Exec(`REFRESH MATERIALIZED VIEW strategy_performance_daily`).Error; err != nil {
-		// Log but don't fail - use existing data
-		fmt.Printf("⚠️ Failed to refresh performance view: %v\n", err)
+		return fmt.Errorf("RefreshStrategyPerformanceDaily: %w", err)
+	}
+	r.perfRefreshedAt.Store(time.Now().UnixNano())
+	return nil
+}
+
+// StrategyPerformanceRefreshedAt returns when this process last refreshed strategy_performance_daily
+// (zero before the first refresh)
+func (r *Repository) StrategyPerformanceRefreshedAt() time.Time {
+	nanos := r.perfRefreshedAt.Load()
+	if nanos == 0 {
+		return time.Time{}
 	}
+	return time.Unix(0, nanos)
+}
 
+// GetDailyStrategyPerformance retrieves daily aggregated performance data as of the last view refresh
+func (r *Repository) GetDailyStrategyPerformance(strategy, symbol string, limit int) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
 	query := r.db.Table("strategy_performance_daily").Order("day DESC")
 
@@ -414,7 +716,8 @@ func (r *Repository) EvaluateVolumeBreakoutStrategy(alert *models.WhaleAlert, zs
 // EvaluateMeanReversionStrategy implements Mean Reversion (Contrarian) strategy
 // Logic: Extreme price (z-score > 3.5) + declining volume = SELL signal (overbought)
 // ENHANCEMENT: Uses VWAP deviation and Order Flow Aggressive Buy for entry confidence
-func (r *Repository) EvaluateMeanReversionStrategy(alert *models.WhaleAlert, zscores *types.ZScoreData, prevVolumeZScore float64, vwap float64, orderFlow *models.OrderFlowImbalance) *models.TradingSignal {
+// rsi is the RSI as of the alert (nil when unavailable); see applyRSIConfirmation
+func (r *Repository) EvaluateMeanReversionStrategy(alert *models.WhaleAlert, zscores *types.ZScoreData, prevVolumeZScore float64, vwap float64, orderFlow *models.OrderFlowImbalance, rsi *float64) *models.TradingSignal {
 	signal := &models.TradingSignal{
 		StockSymbol:  alert.StockSymbol,
 		Timestamp:    alert.DetectedAt,
@@ -483,9 +786,32 @@ func (r *Repository) EvaluateMeanReversionStrategy(alert *models.WhaleAlert, zsc
 		signal.Reason = "Price within normal range"
 	}
 
+	r.applyRSIConfirmation(signal, rsi)
 	return signal
 }
 
+// applyRSIConfirmation checks a mean-reversion entry against RSI: an extreme z-score while momentum
+// is still strong tends to keep running, so SELL needs RSI above overbought and BUY below oversold.
+// Agreement boosts confidence; disagreement downgrades the entry to WAIT. Without RSI data the
+// z-score decision stands.
+func (r *Repository) applyRSIConfirmation(signal *models.TradingSignal, rsi *float64) {
+	if rsi == nil || (signal.Decision != "BUY" && signal.Decision != "SELL") {
+		return
+	}
+
+	confirmed := (signal.Decision == "SELL" && *rsi > r.mrRSIOverbought) ||
+		(signal.Decision == "BUY" && *rsi < r.mrRSIOversold)
+	if confirmed {
+		signal.Confidence = min(signal.Confidence*1.15, 1.0)
+		signal.Reason += fmt.Sprintf(" (RSI %.1f confirms)", *rsi)
+		return
+	}
+
+	signal.Reason += fmt.Sprintf(" (RSI %.1f does not confirm %s: momentum still strong)", *rsi, signal.Decision)
+	signal.Decision = "WAIT"
+	signal.Confidence *= 0.5
+}
+
 // EvaluateFakeoutFilterStrategy implements Fakeout Filter (Defense) strategy
 // Logic: Price breakout + low volume (z-score < 1) = NO_TRADE (likely bull trap)
 func (r *Repository) EvaluateFakeoutFilterStrategy(alert *models.WhaleAlert, zscores *types.ZScoreData, vwap float64) *models.TradingSignal {
@@ -549,14 +875,129 @@ func (r *Repository) generateAIReasoning(signal *models.TradingSignal, coreReaso
 }
 
 // GetStrategySignals evaluates recent whale alerts and generates trading signals
-func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float64, strategyFilter string, alerts []models.WhaleAlert) ([]models.TradingSignal, error) {
+// prefs narrows the strategies evaluated for individual symbols (see symbolStrategies)
+func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float64, strategyFilter string, alerts []models.WhaleAlert, prefs []models.SymbolStrategyPreference) ([]models.TradingSignal, error) {
+	// Group alerts per symbol, keeping their newest-first order
+	alertsBySymbol := make(map[string][]models.WhaleAlert, len(alerts))
+	var symbols []string
+	for _, alert := range alerts {
+		if _, seen := alertsBySymbol[alert.StockSymbol]; !seen {
+			symbols = append(symbols, alert.StockSymbol)
+		}
+		alertsBySymbol[alert.StockSymbol] = append(alertsBySymbol[alert.StockSymbol], alert)
+	}
+
+	// OPTIMIZATION: Batch fetch latest order flow for all alert symbols (avoids N+1)
+	orderFlows, err := r.analytics.GetLatestOrderFlowForSymbols(symbols)
+	if err != nil {
+		log.Printf("⚠️ Failed to batch fetch order flow: %v", err)
+		orderFlows = map[string]*models.OrderFlowImbalance{}
+	}
+
+	// Evaluate each strategy
+	strategies := []string{"VOLUME_BREAKOUT", "MEAN_REVERSION", "FAKEOUT_FILTER"}
+	if strategyFilter != "" && strategyFilter != "ALL" {
+		strategies = []string{strategyFilter}
+	}
+
+	prefsBySymbol := make(map[string]map[string]string)
+	for _, p := range prefs {
+		if prefsBySymbol[p.StockSymbol] == nil {
+			prefsBySymbol[p.StockSymbol] = make(map[string]string)
+		}
+		prefsBySymbol[p.StockSymbol][p.Strategy] = p.Preference
+	}
+
+	// Evaluate symbols on a bounded worker pool; each worker only writes its own slot
+	results := make([][]models.TradingSignal, len(symbols))
+	var g errgroup.Group
+	g.SetLimit(r.signalWorkerLimit())
+	now := time.Now()
+	for i, symbol := range symbols {
+		symbolStrats := symbolStrategies(strategies, prefsBySymbol[symbol])
+		if len(symbolStrats) == 0 {
+			continue
+		}
+		if r.health != nil && r.health.isQuarantined(symbol, now) {
+			log.Printf("🚧 Skipping %s: quarantined after repeated data anomalies", symbol)
+			continue
+		}
+		g.Go(func() error {
+			results[i] = r.evaluateSymbolAlerts(alertsBySymbol[symbol], orderFlows[symbol], symbolStrats, minConfidence)
+			return nil
+		})
+	}
+	_ = g.Wait() // Workers never fail; per-symbol problems are logged and skipped
+
 	var signals []models.TradingSignal
+	for _, symbolSignals := range results {
+		signals = append(signals, symbolSignals...)
+	}
+
+	// Sort signals by timestamp DESC (newest first), then by strategy name for consistency
+	sort.Slice(signals, func(i, j int) bool {
+		// First, sort by timestamp (newest first)
+		if !signals[i].Timestamp.Equal(signals[j].Timestamp) {
+			return signals[i].Timestamp.After(signals[j].Timestamp)
+		}
+		// If timestamps are equal, sort by strategy name alphabetically
+		return signals[i].Strategy < signals[j].Strategy
+	})
 
-	// Track previous volume z-scores for divergence detection
-	prevVolumeZScores := make(map[string]float64)
+	return signals, nil
+}
 
-	// Fetch recent patterns for potential confirmation (global fetch or per symbol)
-	// For efficiency we could pre-fetch, but for now strict per-symbol checking is safer
+// symbolStrategies applies a symbol's preferences (strategy -> PREFER/AVOID) to the strategies
+// being evaluated: AVOID drops a strategy, and any PREFER restricts the symbol to its preferred ones
+func symbolStrategies(strategies []string, prefs map[string]string) []string {
+	if len(prefs) == 0 {
+		return strategies
+	}
+
+	hasPreferred := false
+	for _, pref := range prefs {
+		if pref == "PREFER" {
+			hasPreferred = true
+			break
+		}
+	}
+
+	kept := make([]string, 0, len(strategies))
+	for _, strategy := range strategies {
+		pref := prefs[strategy]
+		if pref == "AVOID" || (hasPreferred && pref != "PREFER") {
+			continue
+		}
+		kept = append(kept, strategy)
+	}
+	return kept
+}
+
+// evaluateSymbolAlerts runs every strategy over one symbol's alerts (newest first) and returns
+// the signals meeting minConfidence. Symbols are independent, so this runs concurrently per symbol.
+func (r *Repository) evaluateSymbolAlerts(alerts []models.WhaleAlert, orderFlow *models.OrderFlowImbalance, strategies []string, minConfidence float64) []models.TradingSignal {
+	var signals []models.TradingSignal
+
+	// Previous volume z-score for divergence detection
+	var prevVolumeZScore float64
+
+	var rsiAt func(time.Time) *float64
+	if slices.Contains(strategies, "MEAN_REVERSION") {
+		rsiAt = r.meanReversionRSI(alerts)
+	}
+
+	// Data anomalies seen across this symbol's alerts, reported once per evaluation
+	var anomalies []string
+	defer func() {
+		if r.health == nil || len(alerts) == 0 {
+			return
+		}
+		if len(anomalies) > 0 {
+			r.health.record(alerts[0].StockSymbol, anomalies, time.Now())
+		} else {
+			r.health.healthy(alerts[0].StockSymbol)
+		}
+	}()
 
 	for _, alert := range alerts {
 		// Fetch baseline for this specific symbol
@@ -564,42 +1005,42 @@ func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float
 
 		// Initialize zscores container
 		var zscores *types.ZScoreData
+		decay := 1.0 // Confidence multiplier for an old baseline
 
 		// STRATEGY 1: Use persistent baseline (Most Accurate)
 		if err == nil && baseline != nil && baseline.SampleSize > 10 {
+			// A near-zero stddev makes every print look extreme; such baselines are
+			// statistically meaningless, so don't fall back or clamp - skip the alert
+			if r.baselineTooFlat(baseline.StdDevPrice, baseline.MeanPrice) ||
+				r.baselineTooFlat(baseline.StdDevVolume, baseline.MeanVolumeLots) {
+				log.Printf("⏭️ Skipping %s: baseline stddev too small (price σ %.4f, volume σ %.4f, floor %.2f%% of mean)",
+					alert.StockSymbol, baseline.StdDevPrice, baseline.StdDevVolume, r.minStdDevPct)
+				anomalies = appendAnomaly(anomalies, anomalyFlatBaseline)
+				continue
+			}
+
 			// Calculate Z-Score using persistent baseline
-			// Prevent division by zero
-			if baseline.StdDevPrice > 0.0001 && baseline.StdDevVolume > 0.0001 {
-				priceZ := (alert.TriggerPrice - baseline.MeanPrice) / baseline.StdDevPrice
-				volZ := (alert.TriggerVolumeLots - baseline.MeanVolumeLots) / baseline.StdDevVolume
-
-				// Clamp z-scores
-				if priceZ > 100 {
-					priceZ = 100
-				} else if priceZ < -100 {
-					priceZ = -100
-				}
-				if volZ > 100 {
-					volZ = 100
-				} else if volZ < -100 {
-					volZ = -100
-				}
+			priceZ, priceClamped := r.clampZScore(alert.StockSymbol, "price", (alert.TriggerPrice-baseline.MeanPrice)/baseline.StdDevPrice)
+			volZ, volClamped := r.clampZScore(alert.StockSymbol, "volume", (alert.TriggerVolumeLots-baseline.MeanVolumeLots)/baseline.StdDevVolume)
+			if priceClamped || volClamped {
+				anomalies = appendAnomaly(anomalies, anomalyClampedZScore)
+			}
 
-				// Calculate % change
-				var priceChangePct float64
-				if baseline.MeanPrice > 0 {
-					priceChangePct = (alert.TriggerPrice - baseline.MeanPrice) / baseline.MeanPrice * 100
-				}
+			// Calculate % change
+			var priceChangePct float64
+			if baseline.MeanPrice > 0 {
+				priceChangePct = (alert.TriggerPrice - baseline.MeanPrice) / baseline.MeanPrice * 100
+			}
 
-				zscores = &types.ZScoreData{
-					PriceZScore:  priceZ,
-					VolumeZScore: volZ,
-					SampleCount:  int64(baseline.SampleSize),
-					PriceChange:  priceChangePct,
-					MeanPrice:    baseline.MeanPrice,
-					MeanVolume:   baseline.MeanVolumeLots,
-				}
+			zscores = &types.ZScoreData{
+				PriceZScore:  priceZ,
+				VolumeZScore: volZ,
+				SampleCount:  int64(baseline.SampleSize),
+				PriceChange:  priceChangePct,
+				MeanPrice:    baseline.MeanPrice,
+				MeanVolume:   baseline.MeanVolumeLots,
 			}
+			decay = r.baselineDecayFactor(baseline.CalculatedAt)
 		}
 
 		// STRATEGY 2: Fallback to real-time calculation if baseline missing (Robustness)
@@ -617,6 +1058,7 @@ func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float
 		if zscores == nil {
 			// Only log occasionally to avoid spam
 			// log.Printf("⚠️ No baseline or fallback data for %s, skipping", alert.StockSymbol)
+			anomalies = appendAnomaly(anomalies, anomalyMissingData)
 			continue
 		}
 
@@ -630,15 +1072,6 @@ func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float
 			vwap = zscores.MeanPrice
 		}
 
-		// Fetch Latest Order Flow for Confirmation
-		orderFlow, _ := r.analytics.GetLatestOrderFlow(alert.StockSymbol)
-
-		// Evaluate each strategy
-		strategies := []string{"VOLUME_BREAKOUT", "MEAN_REVERSION", "FAKEOUT_FILTER"}
-		if strategyFilter != "" && strategyFilter != "ALL" {
-			strategies = []string{strategyFilter}
-		}
-
 		for _, strategy := range strategies {
 			var signal *models.TradingSignal
 
@@ -646,8 +1079,12 @@ func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float
 			case "VOLUME_BREAKOUT":
 				signal = r.EvaluateVolumeBreakoutStrategy(&alert, zscores, vwap, orderFlow)
 			case "MEAN_REVERSION":
-				prevZScore := prevVolumeZScores[alert.StockSymbol]
-				signal = r.EvaluateMeanReversionStrategy(&alert, zscores, prevZScore, vwap, orderFlow)
+				prevZScore := prevVolumeZScore
+				var rsi *float64
+				if rsiAt != nil {
+					rsi = rsiAt(alert.DetectedAt)
+				}
+				signal = r.EvaluateMeanReversionStrategy(&alert, zscores, prevZScore, vwap, orderFlow, rsi)
 			case "FAKEOUT_FILTER":
 				signal = r.EvaluateFakeoutFilterStrategy(&alert, zscores, vwap)
 			}
@@ -657,7 +1094,7 @@ func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float
 				for _, p := range patterns {
 					if p.StockSymbol == alert.StockSymbol && p.PatternType == "RANGE_BREAKOUT" && p.PatternDirection != nil {
 						if *p.PatternDirection == signal.Decision {
-							signal.Confidence *= 1.3 // Strong confirmation
+							signal.Confidence = min(signal.Confidence*1.3, 1.0) // Strong confirmation
 							signal.Reason += fmt.Sprintf(" (Confirmed by %s)", p.PatternType)
 							break
 						}
@@ -665,6 +1102,28 @@ func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float
 				}
 			}
 
+			// A breakout out of a recent squeeze is the volatility expansion the squeeze anticipated
+			if signal != nil && strategy == "VOLUME_BREAKOUT" && r.squeezeBoost > 1 {
+				for _, p := range patterns {
+					if p.PatternType == "SQUEEZE" {
+						signal.Confidence = min(signal.Confidence*r.squeezeBoost, 1.0)
+						signal.Reason += fmt.Sprintf(" (Breaking out of a squeeze: confidence ×%.2f)", r.squeezeBoost)
+						break
+					}
+				}
+			}
+
+			// Calibrated mode: confidence becomes the modelled win probability
+			if signal != nil {
+				r.calibrateConfidence(signal, orderFlow)
+			}
+
+			// Z-scores from an old baseline are less trustworthy
+			if signal != nil && decay < 1.0 {
+				signal.Confidence *= decay
+				signal.Reason += fmt.Sprintf(" (Baseline %.1fh old: confidence ×%.2f)", time.Since(baseline.CalculatedAt).Hours(), decay)
+			}
+
 			// Only include signals meeting confidence threshold
 			if signal != nil && signal.Confidence >= minConfidence && signal.Decision != "NO_TRADE" {
 				signals = append(signals, *signal)
@@ -672,20 +1131,42 @@ func (r *Repository) GetStrategySignals(lookbackMinutes int, minConfidence float
 		}
 
 		// Update previous volume z-score
-		prevVolumeZScores[alert.StockSymbol] = zscores.VolumeZScore
+		prevVolumeZScore = zscores.VolumeZScore
 	}
 
-	// Sort signals by timestamp DESC (newest first), then by strategy name for consistency
-	sort.Slice(signals, func(i, j int) bool {
-		// First, sort by timestamp (newest first)
-		if !signals[i].Timestamp.Equal(signals[j].Timestamp) {
-			return signals[i].Timestamp.After(signals[j].Timestamp)
-		}
-		// If timestamps are equal, sort by strategy name alphabetically
-		return signals[i].Strategy < signals[j].Strategy
-	})
+	return signals
+}
 
-	return signals, nil
+// meanReversionRSI computes RSI over the candles spanning one symbol's alerts (newest first) and
+// returns a lookup of the RSI as of a time, or nil when the check is disabled or candles are missing
+func (r *Repository) meanReversionRSI(alerts []models.WhaleAlert) func(time.Time) *float64 {
+	if r.mrRSIPeriod <= 0 || r.trades == nil || len(alerts) == 0 {
+		return nil
+	}
+
+	// Wilder smoothing needs a few periods before it settles
+	oldest := alerts[len(alerts)-1].DetectedAt
+	closes, err := r.trades.GetCandleCloses(r.mrRSITimeframe, alerts[0].StockSymbol, oldest, r.mrRSIPeriod*3+1)
+	if err != nil {
+		log.Printf("⚠️ RSI candles unavailable for %s: %v", alerts[0].StockSymbol, err)
+		return nil
+	}
+
+	values := make([]float64, len(closes))
+	for i, c := range closes {
+		values[i] = c.Close
+	}
+	series := indicators.RSI(values, r.mrRSIPeriod)
+
+	return func(t time.Time) *float64 {
+		// Latest bucket starting at or before t
+		i := sort.Search(len(closes), func(i int) bool { return closes[i].Bucket.After(t) }) - 1
+		if i < 0 || math.IsNaN(series[i]) {
+			return nil
+		}
+		rsi := series[i]
+		return &rsi
+	}
 }
 
 // getWhaleAlertsForStrategy fetches whale alerts for strategy evaluation
@@ -860,6 +1341,31 @@ func (r *Repository) GetStrategyEffectiveness(daysBack int) ([]types.StrategyEff
 	return results, nil
 }
 
+// GetStrategyEffectivenessByRegime reads win rate for a strategy × regime pair from the
+// strategy_regime_effectiveness materialized view. Returns nil when there is no row.
+func (r *Repository) GetStrategyEffectivenessByRegime(strategy, regime string) (*types.StrategyEffectiveness, error) {
+	var results []types.StrategyEffectiveness
+	err := r.db.Table("strategy_regime_effectiveness").
+		Where("strategy = ? AND market_regime = ?", strategy, regime).
+		Limit(1).
+		Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetStrategyEffectivenessByRegime: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// RefreshStrategyRegimeEffectiveness rebuilds the strategy_regime_effectiveness view without blocking readers
+func (r *Repository) RefreshStrategyRegimeEffectiveness() error {
+	if err := r.db.Exec(`REFRESH MATERIALIZED VIEW CONCURRENTLY strategy_regime_effectiveness`).Error; err != nil {
+		return fmt.Errorf("RefreshStrategyRegimeEffectiveness: %w", err)
+	}
+	return nil
+}
+
 // GetOptimalConfidenceThresholds calculates optimal confidence thresholds per strategy
 // Returns the minimum confidence level where historical win rate exceeds 50%
 func (r *Repository) GetOptimalConfidenceThresholds(daysBack int) ([]types.OptimalThreshold, error) {
@@ -939,6 +1445,172 @@ func (r *Repository) GetTimeOfDayEffectiveness(daysBack int) ([]types.TimeEffect
 	return results, nil
 }
 
+// GetHourlyExpectedValue returns win rate and expected value of closed outcomes per strategy and
+// WIB hour of signal generation; an empty strategy returns every strategy
+func (r *Repository) GetHourlyExpectedValue(strategy string, daysBack int) ([]types.HourlyExpectedValue, error) {
+	var results []types.HourlyExpectedValue
+
+	query := `
+		WITH hourly AS (
+			SELECT
+				ts.strategy,
+				EXTRACT(HOUR FROM ts.generated_at AT TIME ZONE 'Asia/Jakarta')::INT as hour,
+				COUNT(*) as closed_signals,
+				SUM(CASE WHEN so.outcome_status = 'WIN' THEN 1 ELSE 0 END) as wins,
+				COALESCE(AVG(CASE WHEN so.outcome_status = 'WIN' THEN so.profit_loss_pct END), 0) as avg_win_pct,
+				ABS(COALESCE(AVG(CASE WHEN so.outcome_status = 'LOSS' THEN so.profit_loss_pct END), 0)) as avg_loss_pct,
+				COALESCE(AVG(so.profit_loss_pct), 0) as avg_profit_pct
+			FROM trading_signals ts
+			JOIN signal_outcomes so ON ts.id = so.signal_id
+			WHERE so.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN')
+			  AND ts.generated_at >= NOW() - INTERVAL '1 day' * ?
+			  AND (? = '' OR ts.strategy = ?)
+			GROUP BY 1, 2
+		)
+		SELECT
+			strategy,
+			hour,
+			closed_signals,
+			wins,
+			ROUND(wins::DECIMAL / closed_signals * 100, 2) as win_rate,
+			ROUND(avg_win_pct, 4) as avg_win_pct,
+			ROUND(avg_loss_pct, 4) as avg_loss_pct,
+			ROUND(avg_profit_pct, 4) as avg_profit_pct,
+			ROUND((wins::DECIMAL / closed_signals * avg_win_pct) - ((1 - wins::DECIMAL / closed_signals) * avg_loss_pct), 4) as expected_value
+		FROM hourly
+		ORDER BY strategy, hour
+	`
+
+	if err := r.db.Raw(query, daysBack, strategy, strategy).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("GetHourlyExpectedValue: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetExitReasonStats aggregates outcomes closed within daysBack by exit reason, most frequent first.
+// Outcomes closed before exit reasons were recorded are grouped under UNKNOWN.
+func (r *Repository) GetExitReasonStats(daysBack int, strategy string) ([]types.ExitReasonStats, error) {
+	var results []types.ExitReasonStats
+
+	query := `
+		SELECT
+			COALESCE(NULLIF(so.exit_reason, ''), 'UNKNOWN') as exit_reason,
+			COUNT(*) as count,
+			SUM(CASE WHEN so.outcome_status = 'WIN' THEN 1 ELSE 0 END) as wins,
+			SUM(CASE WHEN so.outcome_status = 'LOSS' THEN 1 ELSE 0 END) as losses,
+			ROUND(SUM(CASE WHEN so.outcome_status = 'WIN' THEN 1 ELSE 0 END)::DECIMAL / COUNT(*) * 100, 2) as win_rate,
+			ROUND(COALESCE(AVG(so.profit_loss_pct), 0), 4) as avg_profit_pct,
+			ROUND(COALESCE(AVG(so.max_favorable_excursion), 0), 4) as avg_mfe_pct,
+			ROUND(COALESCE(AVG(so.max_favorable_excursion - so.profit_loss_pct), 0), 4) as avg_giveback_pct,
+			ROUND(COALESCE(AVG(so.holding_period_minutes), 0), 1) as avg_holding_minutes
+		FROM signal_outcomes so
+		JOIN trading_signals ts ON ts.id = so.signal_id
+		WHERE so.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN')
+		  AND so.exit_time >= NOW() - INTERVAL '1 day' * ?
+		  AND (? = '' OR ts.strategy = ?)
+		GROUP BY 1
+		ORDER BY count DESC
+	`
+
+	if err := r.db.Raw(query, daysBack, strategy, strategy).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("GetExitReasonStats: %w", err)
+	}
+
+	for i := range results {
+		results[i].Category = models.ExitReasonCategory(results[i].ExitReason)
+	}
+	return results, nil
+}
+
+// GetSignalLatency returns percentiles of the delay from signal generation to outcome entry
+// (first tracker update) and to exit, per strategy plus an "ALL" row
+func (r *Repository) GetSignalLatency(daysBack int, strategy string) ([]types.SignalLatency, error) {
+	var results []types.SignalLatency
+
+	query := `
+		WITH lat AS (
+			SELECT
+				ts.strategy,
+				GREATEST(EXTRACT(EPOCH FROM (so.entry_time - ts.generated_at)), 0) AS entry_lag_sec,
+				CASE WHEN so.exit_time IS NOT NULL
+					THEN GREATEST(EXTRACT(EPOCH FROM (so.exit_time - ts.generated_at)), 0) / 60
+				END AS close_lat_min
+			FROM trading_signals ts
+			JOIN signal_outcomes so ON ts.id = so.signal_id
+			WHERE so.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN', 'OPEN')
+			  AND ts.generated_at >= NOW() - INTERVAL '1 day' * ?
+			  AND (? = '' OR ts.strategy = ?)
+		)
+		SELECT
+			COALESCE(strategy, 'ALL') AS strategy,
+			COUNT(*) AS outcomes,
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY entry_lag_sec) AS entry_lag_p50_sec,
+			PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY entry_lag_sec) AS entry_lag_p90_sec,
+			PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY entry_lag_sec) AS entry_lag_p99_sec,
+			MAX(entry_lag_sec) AS entry_lag_max_sec,
+			COUNT(close_lat_min) AS closed,
+			COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY close_lat_min), 0) AS close_lat_p50_min,
+			COALESCE(PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY close_lat_min), 0) AS close_lat_p90_min,
+			COALESCE(PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY close_lat_min), 0) AS close_lat_p99_min,
+			COUNT(*) FILTER (WHERE close_lat_min < 5) AS fast_closes
+		FROM lat
+		GROUP BY GROUPING SETS ((strategy), ())
+		ORDER BY GROUPING(strategy) DESC, outcomes DESC
+	`
+
+	if err := r.db.Raw(query, daysBack, strategy, strategy).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("GetSignalLatency: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetStrategyComparison returns closed-outcome metrics per strategy over the lookback, sorted by expectancy
+func (r *Repository) GetStrategyComparison(daysBack int) ([]types.StrategyComparison, error) {
+	var results []types.StrategyComparison
+
+	query := `
+		WITH strategy_stats AS (
+			SELECT
+				ts.strategy,
+				COUNT(*) as total_signals,
+				SUM(CASE WHEN so.outcome_status = 'WIN' THEN 1 ELSE 0 END) as wins,
+				SUM(CASE WHEN so.outcome_status = 'LOSS' THEN 1 ELSE 0 END) as losses,
+				SUM(CASE WHEN so.outcome_status = 'WIN' THEN 1 ELSE 0 END)::DECIMAL / NULLIF(COUNT(*), 0) as win_rate,
+				COALESCE(AVG(so.profit_loss_pct), 0) as avg_profit_pct,
+				COALESCE(AVG(CASE WHEN so.outcome_status = 'WIN' THEN so.profit_loss_pct END), 0) as avg_win_pct,
+				ABS(COALESCE(AVG(CASE WHEN so.outcome_status = 'LOSS' THEN so.profit_loss_pct END), 0)) as avg_loss_pct,
+				COALESCE(AVG(so.holding_period_minutes), 0) as avg_holding_minutes,
+				COALESCE(AVG(so.risk_reward_ratio), 0) as avg_risk_reward
+			FROM trading_signals ts
+			JOIN signal_outcomes so ON ts.id = so.signal_id
+			WHERE so.outcome_status IN ('WIN', 'LOSS', 'BREAKEVEN')
+			  AND ts.generated_at >= NOW() - INTERVAL '1 day' * ?
+			GROUP BY ts.strategy
+		)
+		SELECT
+			strategy,
+			total_signals,
+			wins,
+			losses,
+			ROUND(COALESCE(win_rate, 0) * 100, 2) as win_rate,
+			ROUND(avg_profit_pct, 4) as avg_profit_pct,
+			ROUND(avg_profit_pct * COALESCE(win_rate, 0), 4) as expectancy,
+			ROUND(avg_holding_minutes, 1) as avg_holding_minutes,
+			ROUND(avg_risk_reward, 4) as avg_risk_reward,
+			ROUND((COALESCE(win_rate, 0) * avg_win_pct) - ((1 - COALESCE(win_rate, 0)) * avg_loss_pct), 4) as expected_value
+		FROM strategy_stats
+		ORDER BY expectancy DESC
+	`
+
+	if err := r.db.Raw(query, daysBack).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("GetStrategyComparison: %w", err)
+	}
+
+	return results, nil
+}
+
 // GetSignalExpectedValues returns expected value calculations for all strategies
 // EV = (Win Rate × Avg Win) - ((1 - Win Rate) × |Avg Loss|)
 func (r *Repository) GetSignalExpectedValues(daysBack int) ([]types.SignalExpectedValue, error) {
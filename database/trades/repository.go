@@ -2,6 +2,7 @@ package trades
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"stockbit-haka-haki/database/types"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository handles database operations for trade data
@@ -36,32 +38,21 @@ func (r *Repository) SaveTrade(trade *models.Trade) error {
 	return nil
 }
 
-// BatchSaveTrades saves multiple trade records in a single transaction
-// Handles duplicate trade numbers by catching and ignoring duplicate key errors
+// BatchSaveTrades saves multiple trade records using multi-row INSERTs
+// Duplicate trade numbers are skipped per row via ON CONFLICT DO NOTHING, so one duplicate
+// no longer discards the rest of its batch
 func (r *Repository) BatchSaveTrades(trades []*models.Trade) error {
 	if len(trades) == 0 {
 		return nil
 	}
 
-	// Use CreateInBatches directly with the model slice
-	// Process trades in smaller batches to avoid memory issues
+	// Process trades in smaller batches to keep statement size bounded
 	batchSize := 100
-	for i := 0; i < len(trades); i += batchSize {
-		end := i + batchSize
-		if end > len(trades) {
-			end = len(trades)
-		}
-		batch := trades[i:end]
-
-		if err := r.db.Table("running_trades").CreateInBatches(batch, len(batch)).Error; err != nil {
-			// Check if it's a duplicate key error
-			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") ||
-				strings.Contains(err.Error(), "idx_running_trades_unique_trade") {
-				// Ignore duplicate trade numbers - continue with next batch
-				continue
-			}
-			return fmt.Errorf("BatchSaveTrades batch %d: %w", i/batchSize, err)
-		}
+	err := r.db.Table("running_trades").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(trades, batchSize).Error
+	if err != nil {
+		return fmt.Errorf("BatchSaveTrades: %w", err)
 	}
 
 	return nil
@@ -69,23 +60,37 @@ func (r *Repository) BatchSaveTrades(trades []*models.Trade) error {
 
 // GetRecentTrades retrieves recent trades with filters
 func (r *Repository) GetRecentTrades(stockSymbol string, limit int, actionFilter string) ([]models.Trade, error) {
+	return r.GetTrades(stockSymbol, time.Time{}, time.Time{}, actionFilter, "", limit)
+}
+
+// GetTrades retrieves the trade tape newest first, optionally bounded by time, action and board
+func (r *Repository) GetTrades(stockSymbol string, startTime, endTime time.Time, actionFilter, board string, limit int) ([]models.Trade, error) {
 	var trades []models.Trade
 	query := r.db.Order("timestamp DESC")
 
 	if stockSymbol != "" {
 		query = query.Where("stock_symbol = ?", stockSymbol)
 	}
+	if !startTime.IsZero() {
+		query = query.Where("timestamp >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("timestamp <= ?", endTime)
+	}
 
 	if actionFilter != "" {
 		query = query.Where("action = ?", actionFilter)
 	}
+	if board != "" {
+		query = query.Where("market_board = ?", board)
+	}
 
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
 
 	if err := query.Find(&trades).Error; err != nil {
-		return nil, fmt.Errorf("GetRecentTrades: %w", err)
+		return nil, fmt.Errorf("GetTrades: %w", err)
 	}
 	return trades, nil
 }
@@ -170,24 +175,13 @@ func (r *Repository) GetLatestCandle(stockSymbol string) (*models.Candle, error)
 // GetCandlesByTimeframe returns candles for a specific timeframe and symbol
 // Supported timeframes: 1min/1m, 5min/5m, 15min/15m, 1hour/1h, 1day/1d
 func (r *Repository) GetCandlesByTimeframe(timeframe string, symbol string, limit int) ([]map[string]interface{}, error) {
-	var viewName string
-	switch timeframe {
-	case "1min", "1m":
-		viewName = "candle_1min"
-	case "5min", "5m":
-		viewName = "candle_5min"
-	case "15min", "15m":
-		viewName = "candle_15min"
-	case "1hour", "1h", "60min", "60m":
-		viewName = "candle_1hour"
-	case "1day", "1d", "daily":
-		viewName = "candle_1day"
-	default:
-		return nil, fmt.Errorf("unsupported timeframe: %s (supported: 1min/1m, 5min/5m, 15min/15m, 1hour/1h, 1day/1d)", timeframe)
+	viewName, err := CandleView(timeframe)
+	if err != nil {
+		return nil, err
 	}
 
 	var results []map[string]interface{}
-	err := r.db.Table(viewName).
+	err = r.db.Table(viewName).
 		Where("stock_symbol = ?", symbol).
 		Order("bucket DESC").
 		Limit(limit).
@@ -212,6 +206,67 @@ func (r *Repository) GetCandlesByTimeframe(timeframe string, symbol string, limi
 	return results, nil
 }
 
+// GetCandleCloses returns a symbol's candle closes (oldest first) from since, plus warmupBars
+// earlier buckets so indicators are settled by the first bucket of interest
+func (r *Repository) GetCandleCloses(timeframe, symbol string, since time.Time, warmupBars int) ([]types.CandleClose, error) {
+	viewName, err := CandleView(timeframe)
+	if err != nil {
+		return nil, err
+	}
+	interval := strings.TrimPrefix(viewName, "candle_")
+
+	var closes []types.CandleClose
+	err = r.db.Table(viewName).
+		Select("bucket, close").
+		Where("stock_symbol = ?", symbol).
+		Where("bucket >= ?::timestamptz - INTERVAL '"+interval+"' * ?", since, warmupBars).
+		Order("bucket ASC").
+		Scan(&closes).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetCandleCloses: %w", err)
+	}
+	return closes, nil
+}
+
+// GetRecentCandleCloses returns a symbol's last n candle closes, oldest first
+func (r *Repository) GetRecentCandleCloses(timeframe, symbol string, n int) ([]types.CandleClose, error) {
+	viewName, err := CandleView(timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	var closes []types.CandleClose
+	err = r.db.Table(viewName).
+		Select("bucket, close").
+		Where("stock_symbol = ?", symbol).
+		Order("bucket DESC").
+		Limit(n).
+		Scan(&closes).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetRecentCandleCloses: %w", err)
+	}
+	slices.Reverse(closes)
+	return closes, nil
+}
+
+// CandleView maps a timeframe alias to its candle view name (candle_5min etc.)
+// The suffix after "candle_" is also the time_bucket interval of the view
+func CandleView(timeframe string) (string, error) {
+	switch timeframe {
+	case "1min", "1m":
+		return "candle_1min", nil
+	case "5min", "5m":
+		return "candle_5min", nil
+	case "15min", "15m":
+		return "candle_15min", nil
+	case "1hour", "1h", "60min", "60m":
+		return "candle_1hour", nil
+	case "1day", "1d", "daily":
+		return "candle_1day", nil
+	}
+	return "", fmt.Errorf("unsupported timeframe: %s (supported: 1min/1m, 5min/5m, 15min/15m, 1hour/1h, 1day/1d)", timeframe)
+}
+
 // GetActiveSymbols retrieves symbols that had trades in the specified lookback duration
 func (r *Repository) GetActiveSymbols(since time.Time) ([]string, error) {
 	var symbols []string
@@ -226,6 +281,62 @@ func (r *Repository) GetActiveSymbols(since time.Time) ([]string, error) {
 	return symbols, nil
 }
 
+// GetPriceBreadth counts symbols whose price rose, fell, or stayed flat since the given time
+// Compares the first open and last close per symbol from 1-minute candles
+func (r *Repository) GetPriceBreadth(since time.Time) (advancers, decliners, unchanged int64, err error) {
+	var result struct {
+		Advancers int64
+		Decliners int64
+		Unchanged int64
+	}
+
+	query := `
+		WITH symbol_moves AS (
+			SELECT
+				stock_symbol,
+				first(open, bucket) as open_price,
+				last(close, bucket) as close_price
+			FROM candle_1min
+			WHERE bucket >= ?
+			GROUP BY stock_symbol
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE close_price > open_price) as advancers,
+			COUNT(*) FILTER (WHERE close_price < open_price) as decliners,
+			COUNT(*) FILTER (WHERE close_price = open_price) as unchanged
+		FROM symbol_moves
+	`
+
+	if err := r.db.Raw(query, since).Scan(&result).Error; err != nil {
+		return 0, 0, 0, fmt.Errorf("GetPriceBreadth: %w", err)
+	}
+	return result.Advancers, result.Decliners, result.Unchanged, nil
+}
+
+// GetAverageDailyValue returns a symbol's mean daily traded value (Rupiah) over the last N completed days
+// Today's partial session is excluded so it doesn't drag the average down intraday
+func (r *Repository) GetAverageDailyValue(symbol string, days int) (avgValue float64, sampleDays int64, err error) {
+	var result struct {
+		AvgValue   float64
+		SampleDays int64
+	}
+
+	query := `
+		SELECT
+			COALESCE(AVG(total_value), 0) as avg_value,
+			COUNT(*) as sample_days
+		FROM candle_1day
+		WHERE stock_symbol = ?
+		  AND bucket >= time_bucket('1 day', NOW()) - INTERVAL '1 day' * ?
+		  AND bucket < time_bucket('1 day', NOW())
+	`
+
+	if err := r.db.Raw(query, symbol, days).Scan(&result).Error; err != nil {
+		return 0, 0, fmt.Errorf("GetAverageDailyValue: %w", err)
+	}
+	return result.AvgValue, result.SampleDays, nil
+}
+
 // GetTradesByTimeRange retrieves trades for a symbol within a time range
 func (r *Repository) GetTradesByTimeRange(symbol string, startTime, endTime time.Time) ([]models.Trade, error) {
 	var trades []models.Trade
@@ -239,12 +350,33 @@ func (r *Repository) GetTradesByTimeRange(symbol string, startTime, endTime time
 	return trades, nil
 }
 
+// candle1MinLive is candle_1min_rg with the most recent buckets aggregated straight from running_trades
+// Only regular-board trades are included so NG/TN prints don't pollute stats and z-scores
+// The continuous aggregate refreshes with a 1 minute end_offset, so without this the newest
+// minute (or two) is missing and real-time z-scores/VWAP lag behind the tape
+const candle1MinLive = `(
+		SELECT bucket, stock_symbol, close, volume_lots, total_value
+		FROM candle_1min_rg
+		WHERE bucket < time_bucket('1 minute', NOW() - INTERVAL '2 minutes')
+		UNION ALL
+		SELECT
+			time_bucket('1 minute', timestamp) AS bucket,
+			stock_symbol,
+			LAST(price, timestamp) AS close,
+			SUM(volume_lot) AS volume_lots,
+			SUM(total_amount) AS total_value
+		FROM running_trades
+		WHERE timestamp >= time_bucket('1 minute', NOW() - INTERVAL '2 minutes')
+		  AND market_board = 'RG'
+		GROUP BY 1, 2
+	) c1m`
+
 // GetStockStats calculates statistics based on recent history
-// Uses the candle_1min materialized view for efficient aggregation
+// Uses the candle_1min materialized view, topped up with live buckets from running_trades
 func (r *Repository) GetStockStats(symbol string, lookbackMinutes int) (*types.StockStats, error) {
 	var stats types.StockStats
 
-	// Query candle_1min view for more efficient stats
+	// Query candle_1min view (plus live recent buckets) for more efficient stats
 	query := `
 		SELECT 
 			COALESCE(AVG(volume_lots), 0) as mean_volume_lots,
@@ -253,12 +385,16 @@ func (r *Repository) GetStockStats(symbol string, lookbackMinutes int) (*types.S
 			COALESCE(STDDEV(total_value), 0) as std_dev_value,
 			COALESCE(AVG(close), 0) as mean_price, 
 			COUNT(*) as sample_count
-		FROM candle_1min
+		FROM ` + candle1MinLive + `
 		WHERE stock_symbol = ? 
 		AND bucket >= NOW() - INTERVAL '1 minute' * ?
+		AND bucket >= COALESCE((
+			SELECT MAX(effective_date) FROM corporate_actions
+			WHERE stock_symbol = ? AND effective_date <= NOW()
+		), '-infinity'::timestamptz)
 	`
 
-	err := r.db.Raw(query, symbol, lookbackMinutes).Scan(&stats).Error
+	err := r.db.Raw(query, symbol, lookbackMinutes, symbol).Scan(&stats).Error
 	if err != nil {
 		return nil, fmt.Errorf("GetStockStats: %w", err)
 	}
@@ -266,6 +402,71 @@ func (r *Repository) GetStockStats(symbol string, lookbackMinutes int) (*types.S
 	return &stats, nil
 }
 
+// GetStockStatsAsOf calculates the same statistics as GetStockStats for the window ending at asOf
+// Reads candle_1min_rg only, so imported history must be refreshed into it first (see RefreshCandles)
+func (r *Repository) GetStockStatsAsOf(symbol string, lookbackMinutes int, asOf time.Time) (*types.StockStats, error) {
+	var stats types.StockStats
+
+	query := `
+		SELECT 
+			COALESCE(AVG(volume_lots), 0) as mean_volume_lots,
+			COALESCE(STDDEV(volume_lots), 0) as std_dev_volume,
+			COALESCE(AVG(total_value), 0) as mean_value,
+			COALESCE(STDDEV(total_value), 0) as std_dev_value,
+			COALESCE(AVG(close), 0) as mean_price, 
+			COUNT(*) as sample_count
+		FROM candle_1min_rg
+		WHERE stock_symbol = ? 
+		AND bucket >= ?::timestamptz - INTERVAL '1 minute' * ?
+		AND bucket < ?
+		AND bucket >= COALESCE((
+			SELECT MAX(effective_date) FROM corporate_actions
+			WHERE stock_symbol = ? AND effective_date <= ?
+		), '-infinity'::timestamptz)
+	`
+
+	err := r.db.Raw(query, symbol, asOf, lookbackMinutes, asOf, symbol, asOf).Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetStockStatsAsOf: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// GetReplayTradeNumbers returns the synthetic (negative) trade numbers of replayed trades
+// stored between from and to inclusive, so a re-import can skip rows it already wrote
+func (r *Repository) GetReplayTradeNumbers(from, to time.Time) (map[int64]bool, error) {
+	var numbers []int64
+	err := r.db.Table("running_trades").
+		Where("timestamp BETWEEN ? AND ? AND trade_number < 0", from, to).
+		Pluck("trade_number", &numbers).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetReplayTradeNumbers: %w", err)
+	}
+
+	existing := make(map[int64]bool, len(numbers))
+	for _, n := range numbers {
+		existing[n] = true
+	}
+	return existing, nil
+}
+
+// RefreshCandles materializes every candle aggregate over [from, to)
+// The refresh policies only look back a few minutes, so backfilled trades are invisible until this runs
+func (r *Repository) RefreshCandles(from, to time.Time) error {
+	// Widen to whole UTC days: a window narrower than one bucket is rejected for candle_1day
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	views := []string{"candle_1min", "candle_1min_rg", "candle_5min", "candle_15min", "candle_1hour", "candle_1day"}
+	for _, view := range views {
+		if err := r.db.Exec("CALL refresh_continuous_aggregate(?, ?::timestamptz, ?::timestamptz)", view, from, to).Error; err != nil {
+			return fmt.Errorf("RefreshCandles %s: %w", view, err)
+		}
+	}
+	return nil
+}
+
 // GetPriceVolumeZScores calculates real-time z-scores for a stock
 // Returns z-scores for current price and volume compared to historical baseline
 func (r *Repository) GetPriceVolumeZScores(symbol string, currentPrice, currentVolume float64, lookbackMinutes int) (*types.ZScoreData, error) {
@@ -279,7 +480,7 @@ func (r *Repository) GetPriceVolumeZScores(symbol string, currentPrice, currentV
 		MaxPrice     float64
 	}
 
-	// Calculate statistics from candle_1min view
+	// Calculate statistics from candle_1min view (plus live recent buckets)
 	query := `
 		SELECT 
 			COALESCE(AVG(close), 0) as mean_price,
@@ -289,7 +490,7 @@ func (r *Repository) GetPriceVolumeZScores(symbol string, currentPrice, currentV
 			COUNT(*) as sample_count,
 			COALESCE(MIN(close), 0) as min_price,
 			COALESCE(MAX(close), 0) as max_price
-		FROM candle_1min
+		FROM ` + candle1MinLive + `
 		WHERE stock_symbol = ? 
 		AND bucket >= NOW() - INTERVAL '1 minute' * ?
 	`
@@ -1,6 +1,28 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	models "stockbit-haka-haki/database/models_pkg"
+)
+
+// IngestStats counts websocket messages and trades seen by the running trade handler since startup
+type IngestStats struct {
+	Messages       map[string]int64 `json:"messages"`        // Per message channel type
+	Unknown        int64            `json:"unknown"`         // Message channel types the handler doesn't process
+	Malformed      int64            `json:"malformed"`       // Known message types with a nil payload
+	TradesAccepted int64            `json:"trades_accepted"` // Trades that passed validation
+	TradesInvalid  map[string]int64 `json:"trades_invalid"`  // Failed validation, per reason
+	RejectInvalid  bool             `json:"reject_invalid"`  // Whether invalid trades are dropped or only counted
+}
+
+// QuarantinedSymbol is a symbol excluded from signal generation after repeated data anomalies
+type QuarantinedSymbol struct {
+	StockSymbol string         `json:"stock_symbol"`
+	Since       time.Time      `json:"since"`
+	Until       time.Time      `json:"until"`
+	Anomalies   map[string]int `json:"anomalies"` // Anomalies in the window that triggered the quarantine, per kind
+}
 
 // StockStats holds aggregated statistical data for a stock
 type StockStats struct {
@@ -48,8 +70,25 @@ type AccumulationDistributionSummary struct {
 	TotalValue     float64 `json:"total_value"`
 	BuyPercentage  float64 `json:"buy_percentage"`
 	SellPercentage float64 `json:"sell_percentage"`
-	Status         string  `json:"status"`
+	Status         string  `json:"status"` // Per the requested classification basis
 	NetValue       float64 `json:"net_value"`
+
+	BuyValuePercentage float64 `json:"buy_value_percentage"`
+	CountStatus        string  `json:"count_status"` // ACCUMULATION/DISTRIBUTION/NEUTRAL by alert count share
+	ValueStatus        string  `json:"value_status"` // ACCUMULATION/DISTRIBUTION/NEUTRAL by Rupiah value share
+
+	// Net BUY minus SELL impact (bps of free float / market cap); nil when no alert had symbol meta
+	NetImpactBps *float64 `json:"net_impact_bps,omitempty"`
+}
+
+// WhaleFlowBucket is net whale value for one symbol and time bucket, with the running total
+type WhaleFlowBucket struct {
+	Bucket        time.Time `json:"bucket"`
+	AlertCount    int64     `json:"alert_count"`
+	BuyValue      float64   `json:"buy_value"`
+	SellValue     float64   `json:"sell_value"`
+	NetValue      float64   `json:"net_value"`      // BuyValue - SellValue
+	CumulativeNet float64   `json:"cumulative_net"` // Running NetValue from the first bucket
 }
 
 // TimeBasedStat represents whale activity statistics by time bucket
@@ -62,6 +101,17 @@ type TimeBasedStat struct {
 	SellCount  int64   `json:"sell_count"`
 }
 
+// AlertQuality measures how well whale alerts predicted the subsequent move
+// Changes are signed in the alert's favor (a SELL followed by a drop is positive)
+type AlertQuality struct {
+	Key                  string  `json:"key"` // Alert type or stock symbol, depending on grouping
+	AlertCount           int64   `json:"alert_count"`
+	AvgFavorable5MinPct  float64 `gorm:"column:avg_favorable_5min_pct" json:"avg_favorable_5min_pct"`
+	AvgFavorable15MinPct float64 `gorm:"column:avg_favorable_15min_pct" json:"avg_favorable_15min_pct"`
+	AvgFavorable60MinPct float64 `gorm:"column:avg_favorable_60min_pct" json:"avg_favorable_60min_pct"`
+	HitRate              float64 `json:"hit_rate"` // % of alerts whose 60-minute move went in their favor
+}
+
 // PerformanceStats holds aggregated performance metrics
 type PerformanceStats struct {
 	Strategy       string  `json:"strategy"`
@@ -93,6 +143,20 @@ type StrategyEffectiveness struct {
 	ExpectedValue float64 `json:"expected_value"`
 }
 
+// StrategyComparison holds side-by-side performance metrics for a single strategy
+type StrategyComparison struct {
+	Strategy          string  `json:"strategy"`
+	TotalSignals      int64   `json:"total_signals"`
+	Wins              int64   `json:"wins"`
+	Losses            int64   `json:"losses"`
+	WinRate           float64 `json:"win_rate"`
+	AvgProfitPct      float64 `json:"avg_profit_pct"`
+	Expectancy        float64 `json:"expectancy"`
+	AvgHoldingMinutes float64 `json:"avg_holding_minutes"`
+	AvgRiskReward     float64 `json:"avg_risk_reward"`
+	ExpectedValue     float64 `json:"expected_value"` // (WinRate × AvgWin) - ((1 - WinRate) × |AvgLoss|)
+}
+
 // OptimalThreshold represents the optimal confidence threshold for a strategy
 type OptimalThreshold struct {
 	Strategy           string  `json:"strategy"`
@@ -111,6 +175,63 @@ type TimeEffectiveness struct {
 	AvgProfitPct float64 `json:"avg_profit_pct"`
 }
 
+// HourlyExpectedValue is one cell of the strategy × hour-of-day (WIB) expected value heatmap
+type HourlyExpectedValue struct {
+	Strategy      string  `json:"strategy"`
+	Hour          int     `json:"hour"`
+	ClosedSignals int64   `json:"closed_signals"`
+	Wins          int64   `json:"wins"`
+	WinRate       float64 `json:"win_rate"` // Percent of closed outcomes
+	AvgWinPct     float64 `json:"avg_win_pct"`
+	AvgLossPct    float64 `json:"avg_loss_pct"` // Absolute value
+	AvgProfitPct  float64 `json:"avg_profit_pct"`
+	ExpectedValue float64 `json:"expected_value"` // (WinRate × AvgWin) - ((1 - WinRate) × |AvgLoss|), in %
+}
+
+// PreOpenImbalance is a pre-opening auction snapshot with the whale alerts seen during the auction
+type PreOpenImbalance struct {
+	models.PreOpenSnapshot
+	WhaleAlerts int64 `json:"whale_alerts"`
+}
+
+// ExitReasonStats aggregates closed outcomes sharing an exit reason
+type ExitReasonStats struct {
+	ExitReason        string  `json:"exit_reason"`
+	Category          string  `json:"category"` // See models.ExitReasonCategory
+	Count             int64   `json:"count"`
+	Wins              int64   `json:"wins"`
+	Losses            int64   `json:"losses"`
+	WinRate           float64 `json:"win_rate"` // Percent
+	AvgProfitPct      float64 `json:"avg_profit_pct"`
+	AvgMFEPct         float64 `json:"avg_mfe_pct"`      // Best unrealized P/L before the exit
+	AvgGivebackPct    float64 `json:"avg_giveback_pct"` // MFE minus realized P/L: profit left on the table
+	AvgHoldingMinutes float64 `json:"avg_holding_minutes"`
+}
+
+// SignalLatency is the distribution of tracking delays for one strategy ("ALL" for every strategy):
+// entry lag is signal generation to the outcome's first update, close latency is generation to exit
+type SignalLatency struct {
+	Strategy       string  `json:"strategy"`
+	Outcomes       int64   `json:"outcomes"`
+	EntryLagP50Sec float64 `json:"entry_lag_p50_sec"`
+	EntryLagP90Sec float64 `json:"entry_lag_p90_sec"`
+	EntryLagP99Sec float64 `json:"entry_lag_p99_sec"`
+	EntryLagMaxSec float64 `json:"entry_lag_max_sec"`
+	Closed         int64   `json:"closed"`
+	CloseLatP50Min float64 `json:"close_latency_p50_min"`
+	CloseLatP90Min float64 `json:"close_latency_p90_min"`
+	CloseLatP99Min float64 `json:"close_latency_p99_min"`
+	FastCloses     int64   `json:"fast_closes"` // Closed within 5 minutes of generation, where tracker lag matters most
+}
+
+// FollowupReliability is how often a symbol's whale alerts were followed by a move in their direction
+type FollowupReliability struct {
+	Samples      int64   `json:"samples"`        // Alerts with a 60-minute followup price
+	Hits         int64   `json:"hits"`           // Moved in the alert's direction (BUY up, SELL down)
+	HitRate      float64 `json:"hit_rate"`       // Hits / Samples * 100
+	AvgChangePct float64 `json:"avg_change_pct"` // Mean 60-minute change, signed in the alert's direction
+}
+
 // SignalExpectedValue represents EV calculation for signal prioritization
 type SignalExpectedValue struct {
 	Strategy       string  `json:"strategy"`
@@ -121,3 +242,147 @@ type SignalExpectedValue struct {
 	TotalSignals   int64   `json:"total_signals"`
 	Recommendation string  `json:"recommendation"` // "STRONG", "MODERATE", "WEAK", "AVOID"
 }
+
+// MarketBreadth represents aggregate market sentiment across all active symbols
+type MarketBreadth struct {
+	Advancers      int64   `json:"advancers"`
+	Decliners      int64   `json:"decliners"`
+	Unchanged      int64   `json:"unchanged"`
+	BreadthRatio   float64 `json:"breadth_ratio"` // advancers / (advancers + decliners)
+	WhaleBuyValue  float64 `json:"whale_buy_value"`
+	WhaleSellValue float64 `json:"whale_sell_value"`
+	WhaleBuyRatio  float64 `json:"whale_buy_ratio"` // buy value / total whale value
+	Sentiment      string  `json:"sentiment"`       // RISK_ON, RISK_OFF, NEUTRAL
+}
+
+// ScorecardComponent is the result of a single filter in the signal evaluation pipeline
+type ScorecardComponent struct {
+	Name       string  `json:"name"`
+	Passed     bool    `json:"passed"`
+	Reason     string  `json:"reason,omitempty"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// SignalScorecard breaks down why a signal was accepted or rejected for tracking
+type SignalScorecard struct {
+	SignalID    int64                `json:"signal_id"`
+	Components  []ScorecardComponent `json:"components"`
+	Multiplier  float64              `json:"multiplier"` // Product of passing component multipliers
+	Passed      bool                 `json:"passed"`
+	EvaluatedAt time.Time            `json:"evaluated_at"`
+}
+
+// SkipAudit records why the tracker declined to open a position for a signal
+type SkipAudit struct {
+	SignalID  int64                `json:"signal_id"`
+	Code      string               `json:"code"`               // Stable name of the check or filter that stopped the signal
+	Reason    string               `json:"reason"`             // Why it stopped the signal, with the values at the time
+	Verdicts  []ScorecardComponent `json:"verdicts,omitempty"` // Every filter's verdict, in pipeline order
+	SkippedAt time.Time            `json:"skipped_at"`
+}
+
+// OrderFlowTrend summarizes the direction of per-minute delta volume over a window
+type OrderFlowTrend struct {
+	StockSymbol     string  `json:"stock_symbol"`
+	Minutes         int     `json:"minutes"`
+	Buckets         int     `json:"buckets"`
+	CumulativeDelta float64 `json:"cumulative_delta"` // Sum of buy - sell lots over the window
+	PriorDelta      float64 `json:"prior_delta"`      // First half of the window
+	RecentDelta     float64 `json:"recent_delta"`     // Second half of the window
+	Direction       string  `json:"direction"`        // RISING, FALLING, MIXED, INSUFFICIENT_DATA
+}
+
+// OrderFlowSummary aggregates a symbol's order flow buckets over a recent window
+type OrderFlowSummary struct {
+	StockSymbol    string  `json:"stock_symbol"`
+	BuyLots        float64 `json:"buy_lots"`
+	SellLots       float64 `json:"sell_lots"`
+	TotalLots      float64 `json:"total_lots"`
+	DeltaLots      float64 `json:"delta_lots"`
+	BuyValue       float64 `json:"buy_value"`
+	SellValue      float64 `json:"sell_value"`
+	ImbalanceRatio float64 `json:"imbalance_ratio"` // (buy - sell) / (buy + sell), -1..1
+	Buckets        int     `json:"buckets"`
+	BuyRank        int     `json:"-"`
+	SellRank       int     `json:"-"`
+}
+
+// OpeningGap compares a symbol's open today with its previous daily close
+type OpeningGap struct {
+	StockSymbol    string  `json:"stock_symbol"`
+	Open           float64 `json:"open"`
+	PrevClose      float64 `json:"prev_close"`
+	GapPct         float64 `json:"gap_pct"` // (open - prev close) / prev close * 100
+	LastPrice      float64 `json:"last_price"`
+	VolumeLots     float64 `json:"volume_lots"`      // Traded since the open
+	AvgDailyVolume float64 `json:"avg_daily_volume"` // 20-day average daily volume (lots)
+}
+
+// BollingerSqueeze is a symbol whose latest Bollinger band width is the narrowest of its lookback
+type BollingerSqueeze struct {
+	StockSymbol   string    `json:"stock_symbol"`
+	Bucket        time.Time `json:"bucket"` // Latest 5-minute candle
+	Close         float64   `json:"close"`
+	Middle        float64   `json:"middle"`          // SMA of closes
+	Upper         float64   `json:"upper"`           // Middle + 2σ
+	Lower         float64   `json:"lower"`           // Middle - 2σ
+	WidthPct      float64   `json:"width_pct"`       // (upper - lower) / middle * 100
+	MinPriorWidth float64   `json:"min_prior_width"` // Narrowest width over the lookback before this candle
+	AvgPriorWidth float64   `json:"avg_prior_width"`
+	Samples       int       `json:"samples"` // Prior candles with a full band
+}
+
+// CandleClose is the close of one candle bucket
+type CandleClose struct {
+	Bucket time.Time `json:"bucket"`
+	Close  float64   `json:"close"`
+}
+
+// CandleFlow is buy/sell volume from order_flow_imbalance aggregated to a candle bucket
+type CandleFlow struct {
+	Bucket         time.Time `json:"bucket"`
+	BuyVolumeLots  float64   `json:"buy_volume_lots"`
+	SellVolumeLots float64   `json:"sell_volume_lots"`
+	DeltaVolume    float64   `json:"delta_volume"`
+}
+
+// ConfidenceModel holds logistic regression coefficients, fit offline on closed signals,
+// that map signal features to an empirical win probability
+type ConfidenceModel struct {
+	Intercept     float64
+	PriceZ        float64
+	VolumeZ       float64
+	AggressiveBuy float64 // Per unit of aggressive buy fraction (0-1)
+	PriceChange   float64 // Per % price change vs baseline mean
+}
+
+// SectorSweep is a cluster of correlated symbols being bought by whales in the same window
+type SectorSweep struct {
+	Symbols        []string  `json:"symbols"`
+	AlertCount     int64     `json:"alert_count"`
+	TotalValue     float64   `json:"total_value"`
+	AvgCorrelation float64   `json:"avg_correlation"` // Mean coefficient of the correlated pairs linking the cluster
+	FirstAlertAt   time.Time `json:"first_alert_at"`
+	LastAlertAt    time.Time `json:"last_alert_at"`
+}
+
+// TopOfBookCachePrefix is the Redis key prefix (+ symbol) for the latest best bid/offer snapshot
+const TopOfBookCachePrefix = "orderbook:top:"
+
+// TopOfBook is the best bid/offer from the most recent orderbook update for a symbol
+type TopOfBook struct {
+	StockSymbol string    `json:"stock_symbol"`
+	Bid         float64   `json:"bid"`
+	Ask         float64   `json:"ask"`
+	BidLots     float64   `json:"bid_lots"` // Queued at the best bid
+	AskLots     float64   `json:"ask_lots"` // Queued at the best offer
+	Time        time.Time `json:"time"`
+}
+
+// SpreadBps returns the bid-ask spread in basis points of the mid price, or 0 without a two-sided quote
+func (t TopOfBook) SpreadBps() float64 {
+	if t.Bid <= 0 || t.Ask <= 0 {
+		return 0
+	}
+	return (t.Ask - t.Bid) / ((t.Ask + t.Bid) / 2) * 10000
+}
@@ -1,7 +1,9 @@
 package whales
 
 import (
+	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 
 	models "stockbit-haka-haki/database/models_pkg"
@@ -28,10 +30,16 @@ func (r *Repository) SaveWhaleAlert(alert *models.WhaleAlert) error {
 	return nil
 }
 
-// GetHistoricalWhales retrieves whale alerts with filters
+// GetHistoricalWhales retrieves whale alerts with filters, newest first
 func (r *Repository) GetHistoricalWhales(stockSymbol string, startTime, endTime time.Time, alertType string, action string, board string, minAmount float64, limit, offset int) ([]models.WhaleAlert, error) {
+	return r.GetHistoricalWhalesOrdered(stockSymbol, startTime, endTime, alertType, action, board, minAmount, limit, offset, "detected_at DESC")
+}
+
+// GetHistoricalWhalesOrdered is GetHistoricalWhales with a caller-chosen ORDER BY clause
+// orderBy must be a trusted constant, never user input
+func (r *Repository) GetHistoricalWhalesOrdered(stockSymbol string, startTime, endTime time.Time, alertType string, action string, board string, minAmount float64, limit, offset int, orderBy string) ([]models.WhaleAlert, error) {
 	var whales []models.WhaleAlert
-	query := r.db.Order("detected_at DESC")
+	query := r.db.Order(orderBy)
 
 	if stockSymbol != "" {
 		query = query.Where("stock_symbol = ?", stockSymbol)
@@ -160,6 +168,52 @@ func (r *Repository) GetWhaleStats(stockSymbol string, startTime, endTime time.T
 	return &stats, nil
 }
 
+// GetWhaleFlowTotals returns total whale BUY and SELL value since the given time
+func (r *Repository) GetWhaleFlowTotals(since time.Time) (buyValue, sellValue float64, err error) {
+	var result struct {
+		BuyValue  float64
+		SellValue float64
+	}
+
+	err = r.db.Model(&models.WhaleAlert{}).
+		Select("COALESCE(SUM(CASE WHEN action = 'BUY' THEN trigger_value ELSE 0 END), 0) as buy_value, "+
+			"COALESCE(SUM(CASE WHEN action = 'SELL' THEN trigger_value ELSE 0 END), 0) as sell_value").
+		Where("detected_at >= ?", since).
+		Scan(&result).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("GetWhaleFlowTotals: %w", err)
+	}
+	return result.BuyValue, result.SellValue, nil
+}
+
+// GetWhaleFlowBuckets sums whale BUY and SELL value per time bucket of the given interval (e.g. "15 minutes"),
+// oldest first with a cumulative net. Buckets without alerts are omitted.
+func (r *Repository) GetWhaleFlowBuckets(symbol, interval string, startTime, endTime time.Time) ([]types.WhaleFlowBucket, error) {
+	var buckets []types.WhaleFlowBucket
+	err := r.db.Raw(`
+		SELECT
+			time_bucket(?::interval, detected_at) AS bucket,
+			COUNT(*) AS alert_count,
+			COALESCE(SUM(CASE WHEN action = 'BUY' THEN trigger_value ELSE 0 END), 0) AS buy_value,
+			COALESCE(SUM(CASE WHEN action = 'SELL' THEN trigger_value ELSE 0 END), 0) AS sell_value
+		FROM whale_alerts
+		WHERE stock_symbol = ? AND detected_at >= ? AND detected_at < ?
+		GROUP BY 1
+		ORDER BY 1 ASC
+	`, interval, symbol, startTime, endTime).Scan(&buckets).Error
+	if err != nil {
+		return nil, fmt.Errorf("GetWhaleFlowBuckets: %w", err)
+	}
+
+	cumulative := 0.0
+	for i := range buckets {
+		buckets[i].NetValue = buckets[i].BuyValue - buckets[i].SellValue
+		cumulative += buckets[i].NetValue
+		buckets[i].CumulativeNet = cumulative
+	}
+	return buckets, nil
+}
+
 // GetAccumulationPattern detects BUY/SELL sequences (accumulation/distribution)
 // Identifies repeated whale activity grouped by stock and action
 func (r *Repository) GetAccumulationPattern(hoursBack int, minAlerts int) ([]types.AccumulationPattern, error) {
@@ -188,9 +242,30 @@ func (r *Repository) GetAccumulationPattern(hoursBack int, minAlerts int) ([]typ
 	return patterns, nil
 }
 
+// Accumulation/distribution classification bases
+const (
+	classifyCombined = "combined" // Count dominance confirmed by net value sign (default)
+	classifyCount    = "count"
+	classifyValue    = "value"
+)
+
+// dominanceStatus labels a buy share (%) as ACCUMULATION above 55%, DISTRIBUTION below 45%, else NEUTRAL
+func dominanceStatus(buyPct float64) string {
+	switch {
+	case buyPct > 55:
+		return "ACCUMULATION"
+	case buyPct < 45:
+		return "DISTRIBUTION"
+	default:
+		return "NEUTRAL"
+	}
+}
+
 // GetAccumulationDistributionSummary returns top 20 accumulation and top 20 distribution separately
-// Data is calculated from startTime
-func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time) (accumulation []types.AccumulationDistributionSummary, distribution []types.AccumulationDistributionSummary, err error) {
+// Data is calculated from startTime; basis picks which classification drives Status and the lists
+// ("combined", "count" or "value"). Both count and value statuses are always filled in.
+// rankBy "impact" orders each list by net impact (value relative to free float) instead of net value.
+func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time, basis, rankBy string) (accumulation []types.AccumulationDistributionSummary, distribution []types.AccumulationDistributionSummary, err error) {
 	// Default to 24 hours if zero
 	if startTime.IsZero() {
 		startTime = time.Now().Add(-24 * time.Hour)
@@ -205,7 +280,8 @@ func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time) (ac
 			SUM(CASE WHEN action = 'BUY' THEN trigger_value ELSE 0 END) as buy_value,
 			SUM(CASE WHEN action = 'SELL' THEN trigger_value ELSE 0 END) as sell_value,
 			COUNT(*) as total_count,
-			SUM(trigger_value) as total_value
+			SUM(trigger_value) as total_value,
+			SUM(CASE WHEN action = 'BUY' THEN impact_bps WHEN action = 'SELL' THEN -impact_bps END) as net_impact_bps
 		FROM whale_alerts
 		WHERE detected_at >= ?
 		GROUP BY stock_symbol
@@ -226,8 +302,9 @@ func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time) (ac
 		var symbol string
 		var buyCount, sellCount, totalCount int64
 		var buyValue, sellValue, totalValue float64
+		var netImpact sql.NullFloat64 // NULL when no alert had symbol meta
 
-		if err := rows.Scan(&symbol, &buyCount, &sellCount, &buyValue, &sellValue, &totalCount, &totalValue); err != nil {
+		if err := rows.Scan(&symbol, &buyCount, &sellCount, &buyValue, &sellValue, &totalCount, &totalValue, &netImpact); err != nil {
 			continue // Skip malformed rows
 		}
 
@@ -239,6 +316,9 @@ func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time) (ac
 		s.TotalCount = totalCount
 		s.TotalValue = totalValue
 		s.NetValue = buyValue - sellValue
+		if netImpact.Valid {
+			s.NetImpactBps = &netImpact.Float64
+		}
 
 		// Safe division for percentages
 		if totalCount > 0 {
@@ -246,17 +326,42 @@ func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time) (ac
 			s.SellPercentage = float64(sellCount) / float64(totalCount) * 100
 		}
 
-		// Apply Logic:
-		// Accumulation: Buy Count Dominance (>55%) AND Positive Net Value
-		// Distribution: Sell Count Dominance (>55%) AND Negative Net Value
-		if s.BuyPercentage > 55 && s.NetValue > 0 {
-			s.Status = "ACCUMULATION"
-			allStats = append(allStats, s)
-		} else if s.SellPercentage > 55 && s.NetValue < 0 {
-			s.Status = "DISTRIBUTION"
-			allStats = append(allStats, s)
+		if buyValue+sellValue > 0 {
+			s.BuyValuePercentage = buyValue / (buyValue + sellValue) * 100
 		}
+
+		// A few huge prints can outweigh many small ones, so count and value can disagree
+		s.CountStatus = "NEUTRAL"
+		if s.BuyPercentage > 55 {
+			s.CountStatus = "ACCUMULATION"
+		} else if s.SellPercentage > 55 {
+			s.CountStatus = "DISTRIBUTION"
+		}
+		s.ValueStatus = "NEUTRAL"
+		if buyValue+sellValue > 0 {
+			s.ValueStatus = dominanceStatus(s.BuyValuePercentage)
+		}
+
+		switch basis {
+		case classifyCount:
+			s.Status = s.CountStatus
+		case classifyValue:
+			s.Status = s.ValueStatus
+		default:
+			// Accumulation: Buy Count Dominance (>55%) AND Positive Net Value
+			// Distribution: Sell Count Dominance (>55%) AND Negative Net Value
+			s.Status = "NEUTRAL"
+			if s.CountStatus == "ACCUMULATION" && s.NetValue > 0 {
+				s.Status = "ACCUMULATION"
+			} else if s.CountStatus == "DISTRIBUTION" && s.NetValue < 0 {
+				s.Status = "DISTRIBUTION"
+			}
+		}
+
 		// Neutral is ignored for the summary lists
+		if s.Status != "NEUTRAL" {
+			allStats = append(allStats, s)
+		}
 	}
 
 	// Split into two lists
@@ -268,33 +373,10 @@ func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time) (ac
 		}
 	}
 
-	// Sort Accumulation: Highest Positive Net Value first
-	// We need to implement sort, or just bubble sort since list is small, or use sort.Slice
-	// Since we can't import "sort" easily inside a function without modifying the file imports,
-	// I will check if "sort" is imported. If not, I'll add it in a separate tool call.
-	// Wait, I can't see the imports in this Replace call.
-	// I will use a simple bubble sort here since it's likely < 100 items per category, which is negligible.
-	// ACTUALLY, "sort" is a standard library, but if not imported, it breaks.
-	// Safe bet: Bubble sort (easy to write inline) or request import.
-	// Let's use a simple inline sort for safety to avoid import errors.
-
-	// Sort Accumulation (Descending NetValue)
-	for i := 0; i < len(accumulation); i++ {
-		for j := i + 1; j < len(accumulation); j++ {
-			if accumulation[j].NetValue > accumulation[i].NetValue {
-				accumulation[i], accumulation[j] = accumulation[j], accumulation[i]
-			}
-		}
-	}
-
-	// Sort Distribution (Ascending NetValue - most negative first)
-	for i := 0; i < len(distribution); i++ {
-		for j := i + 1; j < len(distribution); j++ {
-			if distribution[j].NetValue < distribution[i].NetValue {
-				distribution[i], distribution[j] = distribution[j], distribution[i]
-			}
-		}
-	}
+	// Rank by net value, or by net impact when requested; symbols without meta fall back to value
+	// and are ranked after those with an impact figure
+	sortSummaries(accumulation, rankBy, 1)
+	sortSummaries(distribution, rankBy, -1)
 
 	// Limit to top 20
 	if len(accumulation) > 20 {
@@ -307,6 +389,23 @@ func (r *Repository) GetAccumulationDistributionSummary(startTime time.Time) (ac
 	return accumulation, distribution, nil
 }
 
+// sortSummaries orders summaries strongest first: direction 1 for accumulation (most positive),
+// -1 for distribution (most negative)
+func sortSummaries(list []types.AccumulationDistributionSummary, rankBy string, direction float64) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if rankBy == "impact" {
+			if (a.NetImpactBps != nil) != (b.NetImpactBps != nil) {
+				return a.NetImpactBps != nil
+			}
+			if a.NetImpactBps != nil {
+				return *a.NetImpactBps*direction > *b.NetImpactBps*direction
+			}
+		}
+		return a.NetValue*direction > b.NetValue*direction
+	})
+}
+
 // GetExtremeAnomalies returns alerts with Z-Score > minZScore
 func (r *Repository) GetExtremeAnomalies(minZScore float64, hoursBack int) ([]models.WhaleAlert, error) {
 	var anomalies []models.WhaleAlert
@@ -346,6 +445,42 @@ func (r *Repository) GetTimeBasedStats(daysBack int) ([]types.TimeBasedStat, err
 	return stats, nil
 }
 
+// GetAlertQuality aggregates followup price changes per alert type or per symbol
+// groupBy must be "alert_type" or "stock_symbol"; groups with fewer than minAlerts are omitted
+func (r *Repository) GetAlertQuality(daysBack int, groupBy string, minAlerts int) ([]types.AlertQuality, error) {
+	if groupBy != "alert_type" && groupBy != "stock_symbol" {
+		return nil, fmt.Errorf("GetAlertQuality: unsupported grouping %q", groupBy)
+	}
+
+	var results []types.AlertQuality
+	query := `
+		SELECT
+			wa.` + groupBy + ` AS key,
+			COUNT(*) AS alert_count,
+			COALESCE(AVG(f.change_5min_pct * d.dir), 0) AS avg_favorable_5min_pct,
+			COALESCE(AVG(f.change_15min_pct * d.dir), 0) AS avg_favorable_15min_pct,
+			COALESCE(AVG(f.change_60min_pct * d.dir), 0) AS avg_favorable_60min_pct,
+			COALESCE(
+				SUM(CASE WHEN f.change_60min_pct * d.dir > 0 THEN 1 ELSE 0 END)::DECIMAL /
+				NULLIF(COUNT(f.change_60min_pct), 0) * 100,
+				0
+			) AS hit_rate
+		FROM whale_alerts wa
+		JOIN whale_alert_followup f ON f.whale_alert_id = wa.id
+		CROSS JOIN LATERAL (SELECT CASE WHEN wa.action = 'SELL' THEN -1 ELSE 1 END AS dir) d
+		WHERE wa.detected_at >= NOW() - INTERVAL '1 day' * ?
+		  AND wa.action IN ('BUY', 'SELL')
+		GROUP BY wa.` + groupBy + `
+		HAVING COUNT(*) >= ?
+		ORDER BY hit_rate DESC, alert_count DESC
+	`
+
+	if err := r.db.Raw(query, daysBack, minAlerts).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("GetAlertQuality: %w", err)
+	}
+	return results, nil
+}
+
 // GetRecentAlertsBySymbol returns recent alerts for a specific stock (for LLM context)
 func (r *Repository) GetRecentAlertsBySymbol(symbol string, limit int) ([]models.WhaleAlert, error) {
 	var alerts []models.WhaleAlert
@@ -454,6 +589,31 @@ func (r *Repository) GetWhaleFollowups(symbol, status string, limit int) ([]mode
 	return followups, nil
 }
 
+// GetFollowupReliability summarizes completed 60-minute followups of a symbol's whale alerts
+// for one action over the last daysBack days
+func (r *Repository) GetFollowupReliability(symbol, action string, daysBack int) (*types.FollowupReliability, error) {
+	var result types.FollowupReliability
+
+	query := `
+		SELECT
+			COUNT(*) AS samples,
+			COUNT(*) FILTER (WHERE CASE WHEN alert_action = 'SELL' THEN change_60min_pct < 0 ELSE change_60min_pct > 0 END) AS hits,
+			COALESCE(COUNT(*) FILTER (WHERE CASE WHEN alert_action = 'SELL' THEN change_60min_pct < 0 ELSE change_60min_pct > 0 END)::DECIMAL
+				/ NULLIF(COUNT(*), 0) * 100, 0) AS hit_rate,
+			COALESCE(AVG(CASE WHEN alert_action = 'SELL' THEN -change_60min_pct ELSE change_60min_pct END), 0) AS avg_change_pct
+		FROM whale_alert_followup
+		WHERE stock_symbol = ?
+		  AND alert_action = ?
+		  AND change_60min_pct IS NOT NULL
+		  AND alert_time >= NOW() - INTERVAL '1 day' * ?
+	`
+
+	if err := r.db.Raw(query, symbol, action, daysBack).Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("GetFollowupReliability: %w", err)
+	}
+	return &result, nil
+}
+
 // GetActiveWebhooks retrieves all active webhooks
 func (r *Repository) GetActiveWebhooks() ([]models.WhaleWebhook, error) {
 	var webhooks []models.WhaleWebhook
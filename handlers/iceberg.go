@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"stockbit-haka-haki/database"
+	"stockbit-haka-haki/helpers"
+)
+
+// Iceberg detection thresholds
+// A hidden order shows up as a run of near-identical clips filling at a single price
+const (
+	icebergMinTrades     = 5               // Identical clips required before alerting
+	icebergWindow        = 2 * time.Minute // Clips must all print within this window
+	icebergSizeTolerance = 0.05            // Clip volume may differ from the first by ±5%
+	icebergMinClipLots   = 10              // Ignore odd-lot retail noise
+)
+
+// icebergCluster tracks repeated same-size trades at one price for a symbol/side
+type icebergCluster struct {
+	clipLots   float64 // Volume of the first clip; later trades are compared to it
+	count      int
+	totalLots  float64
+	totalValue float64
+	firstSeen  time.Time
+	lastSeen   time.Time
+	alerted    bool
+}
+
+// icebergDetector holds per symbol/board/side/price clusters shared by the whale workers
+type icebergDetector struct {
+	mu        sync.Mutex
+	clusters  map[string]*icebergCluster
+	lastSweep time.Time
+}
+
+func newIcebergDetector() *icebergDetector {
+	return &icebergDetector{
+		clusters:  make(map[string]*icebergCluster),
+		lastSweep: time.Now(),
+	}
+}
+
+// observe adds a trade and returns a snapshot of its cluster when it first qualifies as an iceberg
+func (d *icebergDetector) observe(trade *database.Trade) *icebergCluster {
+	if trade.VolumeLot < icebergMinClipLots || trade.Action == "UNKNOWN" {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := trade.Timestamp
+	d.sweep(now)
+
+	key := fmt.Sprintf("%s:%s:%s:%.2f", trade.StockSymbol, trade.MarketBoard, trade.Action, trade.Price)
+	c, ok := d.clusters[key]
+	sameSize := ok && math.Abs(trade.VolumeLot-c.clipLots) <= c.clipLots*icebergSizeTolerance
+	if ok && !sameSize && c.count > 1 && now.Sub(c.firstSeen) <= icebergWindow {
+		// Other participants trading at the same price don't break an established run
+		return nil
+	}
+	if !ok || !sameSize || now.Sub(c.firstSeen) > icebergWindow {
+		// Start a new run with this trade as the reference clip
+		d.clusters[key] = &icebergCluster{
+			clipLots:   trade.VolumeLot,
+			count:      1,
+			totalLots:  trade.VolumeLot,
+			totalValue: trade.TotalAmount,
+			firstSeen:  now,
+			lastSeen:   now,
+		}
+		return nil
+	}
+
+	c.count++
+	c.totalLots += trade.VolumeLot
+	c.totalValue += trade.TotalAmount
+	c.lastSeen = now
+
+	if c.alerted || c.count < icebergMinTrades || c.totalValue < minSafeValue {
+		return nil
+	}
+	c.alerted = true
+	snapshot := *c
+	return &snapshot
+}
+
+// sweep drops clusters whose window has passed; runs at most once per window
+func (d *icebergDetector) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < icebergWindow {
+		return
+	}
+	for key, c := range d.clusters {
+		if now.Sub(c.firstSeen) > icebergWindow {
+			delete(d.clusters, key)
+		}
+	}
+	d.lastSweep = now
+}
+
+// icebergConfidence grows with the number of clips beyond the minimum (60-95%)
+func icebergConfidence(count int) float64 {
+	return math.Min(95.0, 60.0+float64(count-icebergMinTrades)*5.0)
+}
+
+// detectIceberg flags repeated same-size trades at one price as an ICEBERG whale alert
+// The executed clips so far are reported as the inferred hidden size
+func (h *RunningTradeHandler) detectIceberg(trade *database.Trade) {
+	if h.iceberg == nil || h.tradeRepo == nil {
+		return
+	}
+
+	c := h.iceberg.observe(trade)
+	if c == nil {
+		return
+	}
+
+	durationSec := int(c.lastSeen.Sub(c.firstSeen).Seconds())
+	alert := &database.WhaleAlert{
+		DetectedAt:         time.Now(),
+		StockSymbol:        trade.StockSymbol,
+		AlertType:          "ICEBERG",
+		Action:             trade.Action,
+		TriggerPrice:       trade.Price,
+		TriggerVolumeLots:  c.clipLots,
+		TriggerValue:       trade.TotalAmount,
+		ConfidenceScore:    icebergConfidence(c.count),
+		MarketBoard:        trade.MarketBoard,
+		PatternDurationSec: &durationSec,
+		PatternTradeCount:  ptrInt(c.count),
+		TotalPatternVolume: ptr(c.totalLots),
+		TotalPatternValue:  ptr(c.totalValue),
+		ImpactBps:          h.getSymbolMeta(trade.StockSymbol).ImpactBps(c.totalValue, trade.Price),
+		Severity:           database.SeverityWarn, // No z-score for a pattern of clips
+	}
+
+	if err := h.tradeRepo.SaveWhaleAlert(alert); err != nil {
+		log.Printf("⚠️  Failed to save iceberg alert: %v", err)
+		return
+	}
+
+	log.Printf("🧊 ICEBERG! %s %s @ %.0f | %d clips of ~%.0f lots in %ds | Hidden size ≥ %.0f lots (%s)",
+		trade.StockSymbol, trade.Action, trade.Price, c.count, c.clipLots, durationSec,
+		c.totalLots, helpers.FormatRupiah(c.totalValue))
+
+	h.publishAlert(alert)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"stockbit-haka-haki/database/types"
+	pb "stockbit-haka-haki/proto"
+)
+
+// Trade validation failure reasons
+const (
+	invalidEmptySymbol = "empty_symbol"
+	invalidPrice       = "non_positive_price"
+	invalidVolume      = "non_positive_volume"
+)
+
+// invalidLogEvery limits invalid trade logging to the first occurrence per reason and every Nth after
+const invalidLogEvery = 1000
+
+// ingestMetrics counts incoming messages and trade validation results
+type ingestMetrics struct {
+	mu             sync.Mutex
+	messages       map[string]int64
+	unknown        int64
+	malformed      int64
+	tradesAccepted int64
+	tradesInvalid  map[string]int64
+}
+
+func newIngestMetrics() *ingestMetrics {
+	return &ingestMetrics{
+		messages:      make(map[string]int64),
+		tradesInvalid: make(map[string]int64),
+	}
+}
+
+// message counts a message of the given channel type; a nil payload also counts as malformed
+func (m *ingestMetrics) message(kind string, nilPayload bool) {
+	m.mu.Lock()
+	m.messages[kind]++
+	if nilPayload {
+		m.malformed++
+	}
+	m.mu.Unlock()
+}
+
+func (m *ingestMetrics) unknownMessage() {
+	m.mu.Lock()
+	m.unknown++
+	m.mu.Unlock()
+}
+
+func (m *ingestMetrics) tradeAccepted() {
+	m.mu.Lock()
+	m.tradesAccepted++
+	m.mu.Unlock()
+}
+
+// tradeInvalid counts a failed trade and returns the running total for the reason
+func (m *ingestMetrics) tradeInvalid(reason string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tradesInvalid[reason]++
+	return m.tradesInvalid[reason]
+}
+
+func (m *ingestMetrics) snapshot() types.IngestStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := types.IngestStats{
+		Messages:       make(map[string]int64, len(m.messages)),
+		Unknown:        m.unknown,
+		Malformed:      m.malformed,
+		TradesAccepted: m.tradesAccepted,
+		TradesInvalid:  make(map[string]int64, len(m.tradesInvalid)),
+	}
+	for k, v := range m.messages {
+		stats.Messages[k] = v
+	}
+	for k, v := range m.tradesInvalid {
+		stats.TradesInvalid[k] = v
+	}
+	return stats
+}
+
+// validateTrade checks the fields every downstream consumer relies on. A zero price or volume
+// would store a zero-value trade and drag down the per-symbol statistics.
+func validateTrade(t *pb.RunningTrade) (string, bool) {
+	switch {
+	case strings.TrimSpace(t.Stock) == "":
+		return invalidEmptySymbol, false
+	case !(t.Price > 0) || math.IsInf(t.Price, 0):
+		return invalidPrice, false
+	case !(t.Volume > 0) || math.IsInf(t.Volume, 0):
+		return invalidVolume, false
+	}
+	return "", true
+}
+
+// IngestStats returns message and trade validation counters since startup
+func (h *RunningTradeHandler) IngestStats() types.IngestStats {
+	stats := h.metrics.snapshot()
+	stats.RejectInvalid = !h.opts.AcceptInvalidTrades
+	return stats
+}
+
+// checkTrade validates a trade and records the result; false means the trade must be dropped
+func (h *RunningTradeHandler) checkTrade(t *pb.RunningTrade) bool {
+	reason, ok := validateTrade(t)
+	if ok {
+		h.metrics.tradeAccepted()
+		return true
+	}
+
+	if n := h.metrics.tradeInvalid(reason); n == 1 || n%invalidLogEvery == 0 {
+		log.Printf("⚠️ Invalid trade (%s) for %q: price %.2f volume %.0f (%d so far)", reason, t.Stock, t.Price, t.Volume, n)
+	}
+	return h.opts.AcceptInvalidTrades
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"stockbit-haka-haki/database"
+	pb "stockbit-haka-haki/proto"
+)
+
+// preOpenFlushInterval is how often the latest pre-opening snapshots are written
+// Books update many times a second during the auction; only the latest state per symbol matters
+const preOpenFlushInterval = 15 * time.Second
+
+// preOpenCollector keeps the latest pre-opening auction snapshot per symbol until the next flush
+type preOpenCollector struct {
+	repo    *database.TradeRepository
+	mu      sync.Mutex
+	pending map[string]database.PreOpenSnapshot
+}
+
+func newPreOpenCollector(repo *database.TradeRepository) *preOpenCollector {
+	return &preOpenCollector{
+		repo:    repo,
+		pending: make(map[string]database.PreOpenSnapshot),
+	}
+}
+
+// observe records the auction state implied by a pre-opening order book
+func (c *preOpenCollector) observe(ob *pb.OrderBookBody, at time.Time) {
+	snapshot, ok := indicativeAuction(ob.GetBid(), ob.GetOffer())
+	if !ok {
+		return
+	}
+	snapshot.StockSymbol = ob.GetStockSymbol()
+	snapshot.TradeDate = at.In(wibLocation).Format("2006-01-02")
+	snapshot.UpdatedAt = at
+
+	c.mu.Lock()
+	c.pending[snapshot.StockSymbol] = snapshot
+	c.mu.Unlock()
+}
+
+// run flushes pending snapshots until done is closed
+func (c *preOpenCollector) run(done <-chan struct{}) {
+	ticker := time.NewTicker(preOpenFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-done:
+			c.flush()
+			return
+		}
+	}
+}
+
+func (c *preOpenCollector) flush() {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	snapshots := make([]database.PreOpenSnapshot, 0, len(c.pending))
+	for _, s := range c.pending {
+		snapshots = append(snapshots, s)
+	}
+	c.pending = make(map[string]database.PreOpenSnapshot)
+	c.mu.Unlock()
+
+	if err := c.repo.UpsertPreOpenSnapshots(snapshots); err != nil {
+		log.Printf("⚠️  Failed to save %d pre-opening snapshots: %v", len(snapshots), err)
+	}
+}
+
+// indicativeAuction estimates the pre-opening equilibrium from the visible book: the price that
+// maximizes matched lots, ties broken by the smallest surplus, then the lower price. The imbalance
+// is the unmatched demand (+) or supply (-) at that price. When bids and offers don't cross there
+// is no indicative price and the imbalance compares total visible bid and offer lots.
+func indicativeAuction(bids []*pb.Bid, offers []*pb.Offer) (database.PreOpenSnapshot, bool) {
+	var totalBid, totalOffer float64
+	prices := make(map[float64]bool)
+	for _, b := range bids {
+		if b.GetPrice() > 0 && b.GetLot() > 0 {
+			totalBid += b.GetLot()
+			prices[b.GetPrice()] = true
+		}
+	}
+	for _, o := range offers {
+		if o.GetPrice() > 0 && o.GetLot() > 0 {
+			totalOffer += o.GetLot()
+			prices[o.GetPrice()] = true
+		}
+	}
+	if totalBid+totalOffer == 0 {
+		return database.PreOpenSnapshot{}, false
+	}
+
+	var best database.PreOpenSnapshot
+	for price := range prices {
+		var demand, supply float64
+		for _, b := range bids {
+			if b.GetPrice() >= price {
+				demand += b.GetLot()
+			}
+		}
+		for _, o := range offers {
+			if o.GetPrice() > 0 && o.GetPrice() <= price {
+				supply += o.GetLot()
+			}
+		}
+		matched := math.Min(demand, supply)
+		if matched == 0 {
+			continue
+		}
+
+		surplus := demand - supply
+		better := matched > best.MatchedLots ||
+			(matched == best.MatchedLots && math.Abs(surplus) < math.Abs(best.ImbalanceLots)) ||
+			(matched == best.MatchedLots && math.Abs(surplus) == math.Abs(best.ImbalanceLots) && price < best.IndicativePrice)
+		if better {
+			best = database.PreOpenSnapshot{
+				IndicativePrice: price,
+				MatchedLots:     matched,
+				BidLots:         demand,
+				OfferLots:       supply,
+				ImbalanceLots:   surplus,
+			}
+		}
+	}
+
+	if best.MatchedLots == 0 {
+		best = database.PreOpenSnapshot{BidLots: totalBid, OfferLots: totalOffer, ImbalanceLots: totalBid - totalOffer}
+	} else {
+		best.Crossed = true
+	}
+	best.ImbalancePct = best.ImbalanceLots / (best.BidLots + best.OfferLots) * 100
+	return best, true
+}
@@ -3,11 +3,13 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"sync"
 	"time"
 
 	"stockbit-haka-haki/cache"
+	"stockbit-haka-haki/config"
 	"stockbit-haka-haki/database"
 	"stockbit-haka-haki/database/types"
 	"stockbit-haka-haki/helpers"
@@ -22,6 +24,11 @@ type VolatilityProvider interface {
 	GetVolatilityPercent(symbol string) (float64, error)
 }
 
+// SymbolFilter decides whether a symbol should be processed (whitelist/blacklist)
+type SymbolFilter interface {
+	IsAllowed(symbol string) bool
+}
+
 // Detection thresholds
 const (
 	minSafeValue          = 100_000_000.0   // 100 Million IDR - Safety floor to avoid penny stock noise
@@ -29,24 +36,54 @@ const (
 	zScoreThreshold       = 3.0             // Statistical anomaly threshold
 	volumeSpikeMultiplier = 5.0             // 5x average volume
 	fallbackLotThreshold  = 2500            // Fallback threshold for lots (for stocks without historical data)
-	statsLookbackMinutes  = 60              // 1 hour lookback for statistics
+	statsLookbackMinutes  = 60              // Default lookback for statistics (1 hour)
+	statsMinSamples       = 10              // Default minimum 1-minute candles before statistics are trusted
 	statsCacheDuration    = 5 * time.Minute // Cache stats for 5 minutes
+	topOfBookCacheTTL     = 2 * time.Minute // Older quotes are not trusted for mark pricing
+	symbolMetaCacheTTL    = 10 * time.Minute
 )
 
 // Cache key prefixes
 const (
 	cacheKeyStatsPrefix = "stats:stock:"
+	cacheKeyTradePrefix = "trade:" // trade:{symbol}:{board}:{wibdate}:{tradeNumber}
+	cacheKeyMetaPrefix  = "symbol_meta:"
 )
 
+// wibLocation is the exchange timezone; trade numbers reset per WIB trading day
+var wibLocation = func() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}()
+
 // Config constants
 const (
 	tradeChanSize   = 10000
 	whaleChanSize   = 1000
-	batchSize       = 500
-	batchTimeout    = 500 * time.Millisecond
-	whaleWorkerPool = 5
+	batchSize       = 500                    // Default flush size when not configured
+	batchTimeout    = 500 * time.Millisecond // Default flush interval when not configured
+	whaleWorkerPool = 5                      // Default when no pool size is configured
 )
 
+// ProcessingOptions tunes trade ingestion; zero values fall back to the defaults above
+type ProcessingOptions struct {
+	WorkerPoolSize      int                  // Whale detection workers (symbol-sharded)
+	BatchSize           int                  // Trades buffered before a multi-row INSERT
+	BatchFlushInterval  time.Duration        // Max time a trade waits in the buffer
+	DedupTTL            time.Duration        // Lifetime of Redis trade-number dedup keys (0 disables)
+	SeverityAlertZ      float64              // Z-score from which a whale alert is ALERT rather than WARN
+	SeverityCriticalZ   float64              // Z-score from which a whale alert is CRITICAL
+	IncludeOddLots      bool                 // Let odd-lot trades into whale detection and order flow (they are always stored)
+	StatsLookback       int                  // Minutes of 1-minute candles behind the whale statistics
+	StatsMinSamples     int                  // Candles required before statistics are used; fewer falls back to hard thresholds
+	PreOpenCapture      bool                 // Record pre-opening auction imbalance from order book updates
+	AcceptInvalidTrades bool                 // Count trades that fail validation but still process them
+	Sessions            config.SessionConfig // Trading schedule, used to recognize PRE_OPENING
+}
+
 // RunningTradeHandler mengelola pesan RunningTrade dari protobuf
 type RunningTradeHandler struct {
 	tradeRepo      *database.TradeRepository     // Repository untuk menyimpan data trade
@@ -54,14 +91,27 @@ type RunningTradeHandler struct {
 	redis          *cache.RedisClient            // Redis client for config caching
 	broker         *realtime.Broker              // Realtime SSE broker
 	volatilityProv VolatilityProvider            // Provider for adaptive thresholds
+	symbolFilter   SymbolFilter                  // Optional whitelist/blacklist for whale detection
 
 	// Async Processing Channels
+	// Each whale worker owns one channel; symbols are hashed to a worker so a symbol's
+	// trades are always processed in order by the same goroutine
 	ingestChan chan *database.Trade
-	whaleChan  chan *database.Trade
+	whaleChans []chan *database.Trade
 	done       chan struct{}
+	opts       ProcessingOptions
 
 	// Order Flow Aggregation (Phase 1 Enhancement)
 	flowAggregator *OrderFlowAggregator
+
+	// Repeated same-size prints at one price (hidden orders)
+	iceberg *icebergDetector
+
+	// Pre-opening auction snapshots; nil unless PreOpenCapture is set
+	preOpen *preOpenCollector
+
+	// Message and trade validation counters
+	metrics *ingestMetrics
 }
 
 // OrderFlowAggregator aggregates buy/sell volume per minute
@@ -92,16 +142,36 @@ type OrderFlowData struct {
 }
 
 // NewRunningTradeHandler membuat instance handler baru
-func NewRunningTradeHandler(tradeRepo *database.TradeRepository, webhookManager *notifications.WebhookManager, redis *cache.RedisClient, broker *realtime.Broker, volProv VolatilityProvider) *RunningTradeHandler {
+func NewRunningTradeHandler(tradeRepo *database.TradeRepository, webhookManager *notifications.WebhookManager, redis *cache.RedisClient, broker *realtime.Broker, volProv VolatilityProvider, symbolFilter SymbolFilter, opts ProcessingOptions) *RunningTradeHandler {
+	if opts.WorkerPoolSize <= 0 {
+		opts.WorkerPoolSize = whaleWorkerPool
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = batchSize
+	}
+	if opts.BatchFlushInterval <= 0 {
+		opts.BatchFlushInterval = batchTimeout
+	}
+	if opts.StatsLookback <= 0 {
+		opts.StatsLookback = statsLookbackMinutes
+	}
+	if opts.StatsMinSamples <= 0 {
+		opts.StatsMinSamples = statsMinSamples
+	}
+
 	handler := &RunningTradeHandler{
 		tradeRepo:      tradeRepo,
 		webhookManager: webhookManager,
 		redis:          redis,
 		broker:         broker,
 		volatilityProv: volProv,
+		symbolFilter:   symbolFilter,
 		ingestChan:     make(chan *database.Trade, tradeChanSize),
-		whaleChan:      make(chan *database.Trade, whaleChanSize),
+		whaleChans:     make([]chan *database.Trade, opts.WorkerPoolSize),
+		opts:           opts,
 		done:           make(chan struct{}),
+		iceberg:        newIcebergDetector(),
+		metrics:        newIngestMetrics(),
 	}
 
 	// Initialize order flow aggregator
@@ -110,11 +180,19 @@ func NewRunningTradeHandler(tradeRepo *database.TradeRepository, webhookManager
 		go handler.flowAggregator.Start() // Start background aggregation
 	}
 
+	if opts.PreOpenCapture && tradeRepo != nil {
+		handler.preOpen = newPreOpenCollector(tradeRepo)
+		go handler.preOpen.run(handler.done)
+	}
+
 	// Start workers
 	go handler.batchSaverWorker()
-	for i := 0; i < whaleWorkerPool; i++ {
-		go handler.whaleDetectionWorker()
+	for i := range handler.whaleChans {
+		handler.whaleChans[i] = make(chan *database.Trade, whaleChanSize)
+		go handler.whaleDetectionWorker(handler.whaleChans[i])
 	}
+	log.Printf("⚙️ Trade processing started (%d whale workers, batch %d trades / %v)",
+		opts.WorkerPoolSize, opts.BatchSize, opts.BatchFlushInterval)
 
 	return handler
 }
@@ -122,7 +200,7 @@ func NewRunningTradeHandler(tradeRepo *database.TradeRepository, webhookManager
 // batchSaverWorker handles batch insertion of trades
 func (h *RunningTradeHandler) batchSaverWorker() {
 	var batch []*database.Trade
-	ticker := time.NewTicker(batchTimeout)
+	ticker := time.NewTicker(h.opts.BatchFlushInterval)
 	defer ticker.Stop()
 
 	flush := func() {
@@ -140,7 +218,7 @@ func (h *RunningTradeHandler) batchSaverWorker() {
 		select {
 		case trade := <-h.ingestChan:
 			batch = append(batch, trade)
-			if len(batch) >= batchSize {
+			if len(batch) >= h.opts.BatchSize {
 				flush()
 			}
 		case <-ticker.C:
@@ -153,16 +231,25 @@ func (h *RunningTradeHandler) batchSaverWorker() {
 }
 
 // whaleDetectionWorker processes trades for whale alerts
-func (h *RunningTradeHandler) whaleDetectionWorker() {
-	for trade := range h.whaleChan {
+func (h *RunningTradeHandler) whaleDetectionWorker(trades <-chan *database.Trade) {
+	for trade := range trades {
 		h.detectWhale(trade)
 	}
 }
 
+// whaleChanFor picks the worker channel for a symbol (FNV-1a hash, stable per symbol)
+func (h *RunningTradeHandler) whaleChanFor(symbol string) chan *database.Trade {
+	hash := fnv.New32a()
+	hash.Write([]byte(symbol))
+	return h.whaleChans[hash.Sum32()%uint32(len(h.whaleChans))]
+}
+
 // Close gracefully shuts down the handler
 func (h *RunningTradeHandler) Close() {
 	close(h.done)
-	close(h.whaleChan) // ingestChan is not closed to avoid panic on send, but loop above has simple exit
+	for _, ch := range h.whaleChans {
+		close(ch) // ingestChan is not closed to avoid panic on send, but loop above has simple exit
+	}
 }
 
 // Handle adalah method legacy - tidak digunakan dengan implementasi protobuf baru
@@ -180,27 +267,36 @@ func (h *RunningTradeHandler) HandleProto(wrapper interface{}) error {
 	// Proses berbagai tipe pesan dari wrapper
 	switch v := msg.MessageChannel.(type) {
 	case *pb.WebsocketWrapMessageChannel_RunningTrade:
+		h.metrics.message("running_trade", v.RunningTrade == nil)
 		if v.RunningTrade != nil {
 			h.ProcessTrade(v.RunningTrade)
 		}
 
 	case *pb.WebsocketWrapMessageChannel_RunningTradeBatch:
+		h.metrics.message("running_trade_batch", v.RunningTradeBatch == nil)
 		if v.RunningTradeBatch != nil {
 			for _, trade := range v.RunningTradeBatch.Trades {
+				if trade == nil {
+					h.metrics.message("running_trade", true)
+					continue
+				}
 				h.ProcessTrade(trade)
 			}
 		}
 
 	case *pb.WebsocketWrapMessageChannel_Ping:
 		// Ping response - silent
+		h.metrics.message("ping", false)
 
 	case *pb.WebsocketWrapMessageChannel_OrderbookBody:
+		h.metrics.message("orderbook_body", v.OrderbookBody == nil)
 		if v.OrderbookBody != nil {
 			h.ProcessOrderBookBody(v.OrderbookBody)
 		}
 
 	default:
-		return fmt.Errorf("unknown message channel type")
+		h.metrics.unknownMessage()
+		return fmt.Errorf("unknown message channel type %T", msg.MessageChannel)
 	}
 
 	return nil
@@ -224,7 +320,7 @@ func (h *RunningTradeHandler) getStockStats(stock string) *types.StockStats {
 
 	// Cache miss - fetch from database
 	if h.tradeRepo != nil {
-		dbStats, err := h.tradeRepo.GetStockStats(stock, statsLookbackMinutes)
+		dbStats, err := h.tradeRepo.GetStockStats(stock, h.opts.StatsLookback)
 		if err != nil {
 			return nil
 		}
@@ -240,8 +336,44 @@ func (h *RunningTradeHandler) getStockStats(stock string) *types.StockStats {
 	return nil
 }
 
+// getSymbolMeta returns the symbol's free float / market cap, or nil if unknown
+// Misses are cached as an empty entry so unknown symbols don't hit the DB on every alert
+func (h *RunningTradeHandler) getSymbolMeta(stock string) *database.SymbolMeta {
+	if h.tradeRepo == nil {
+		return nil
+	}
+
+	cacheKey := cacheKeyMetaPrefix + stock
+	var meta database.SymbolMeta
+	if h.redis != nil {
+		if err := h.redis.Get(context.Background(), cacheKey, &meta); err == nil {
+			if meta.StockSymbol == "" {
+				return nil
+			}
+			return &meta
+		}
+	}
+
+	found, err := h.tradeRepo.GetSymbolMeta(stock)
+	if err != nil {
+		return nil
+	}
+	if h.redis != nil {
+		if found != nil {
+			meta = *found
+		}
+		_ = h.redis.Set(context.Background(), cacheKey, meta, symbolMetaCacheTTL)
+	}
+	return found
+}
+
 // ProcessTrade memproses satu pesan trade individual
 func (h *RunningTradeHandler) ProcessTrade(t *pb.RunningTrade) {
+	// Reject trades that would be stored with a missing symbol or zero price/volume
+	if !h.checkTrade(t) {
+		return
+	}
+
 	// Tentukan action berdasarkan tipe trade
 	var actionDb string
 
@@ -274,8 +406,9 @@ func (h *RunningTradeHandler) ProcessTrade(t *pb.RunningTrade) {
 	}
 
 	// PENTING: Volume dalam protobuf adalah SHARES (saham)
-	// Konversi ke LOT: 1 lot = 100 shares
-	volumeLot := t.Volume / 100
+	// Konversi ke LOT sesuai lot size (default 1 lot = 100 shares); odd-lot menghasilkan pecahan
+	volumeLot := helpers.SharesToLots(t.Volume)
+	oddLot := helpers.IsOddLot(t.Volume)
 
 	// Hitung total nilai transaksi dalam Rupiah
 	totalAmount := t.Price * t.Volume
@@ -299,6 +432,11 @@ func (h *RunningTradeHandler) ProcessTrade(t *pb.RunningTrade) {
 		TradeNumber: tradeNumber,
 	}
 
+	// 0. Drop duplicates before they reach the DB unique constraint
+	if h.isDuplicateTrade(trade) {
+		return
+	}
+
 	// 1. Send to Batch Saver (Non-blocking if buffered)
 	select {
 	case h.ingestChan <- trade:
@@ -306,15 +444,21 @@ func (h *RunningTradeHandler) ProcessTrade(t *pb.RunningTrade) {
 		log.Printf("⚠️ Ingest channel full, dropping trade for %s", trade.StockSymbol)
 	}
 
+	// Odd-lot prints are a separate retail book; their lot counts would distort whale stats and flow
+	countable := !oddLot || h.opts.IncludeOddLots
+
 	// 2. Send to Whale Detector (Non-blocking)
-	select {
-	case h.whaleChan <- trade:
-	default:
-		// Drop is acceptable for whale detection under extreme load
+	if countable {
+		select {
+		case h.whaleChanFor(trade.StockSymbol) <- trade:
+		default:
+			// Drop is acceptable for whale detection under extreme load
+		}
 	}
 
 	// 3. Send to Order Flow Aggregator (Non-blocking)
-	if h.flowAggregator != nil {
+	// Only regular-board trades: NG crosses and TN cash trades aren't aggressor-driven flow
+	if h.flowAggregator != nil && boardType == "RG" && countable {
 		h.flowAggregator.inputChan <- &orderFlowInput{
 			stock:      t.Stock,
 			action:     actionDb,
@@ -337,14 +481,50 @@ func (h *RunningTradeHandler) ProcessTrade(t *pb.RunningTrade) {
 			"time":       trade.Timestamp,
 			"change_pct": changePercentage, // can be nil
 			"trade_num":  tradeNumber,      // can be nil
+			"odd_lot":    oddLot,
 		}
 
 		h.broker.Broadcast("trade", payload)
 	}
 }
 
+// isDuplicateTrade claims trade:{symbol}:{board}:{wibdate}:{tradeNumber} with SETNX
+// Trade numbers reset each WIB day, so the date is taken in WIB rather than UTC
+// Fails open (treats as new) when Redis is unavailable; the DB constraint remains the backstop
+func (h *RunningTradeHandler) isDuplicateTrade(trade *database.Trade) bool {
+	if h.redis == nil || trade.TradeNumber == nil || h.opts.DedupTTL <= 0 {
+		return false
+	}
+
+	key := fmt.Sprintf("%s%s:%s:%s:%d", cacheKeyTradePrefix, trade.StockSymbol, trade.MarketBoard,
+		trade.Timestamp.In(wibLocation).Format("20060102"), *trade.TradeNumber)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	isNew, err := h.redis.SetNX(ctx, key, 1, h.opts.DedupTTL)
+	if err != nil {
+		return false
+	}
+	return !isNew
+}
+
 // detectWhale performs the whale detection logic directly (now async)
 func (h *RunningTradeHandler) detectWhale(trade *database.Trade) {
+	// Skip symbols excluded by the operator's whitelist/blacklist
+	if h.symbolFilter != nil && !h.symbolFilter.IsAllowed(trade.StockSymbol) {
+		return
+	}
+
+	h.detectIceberg(trade)
+
+	// Get stats using helper method (handles caching internally)
+	h.evaluateWhale(trade, h.getStockStats(trade.StockSymbol), time.Now())
+}
+
+// evaluateWhale applies the whale thresholds to a trade against the given baseline stats,
+// saving and publishing an alert stamped detectedAt. Reports whether an alert was saved.
+func (h *RunningTradeHandler) evaluateWhale(trade *database.Trade, stats *types.StockStats, detectedAt time.Time) bool {
 	// Start benchmarking timer
 	startTime := time.Now()
 
@@ -358,10 +538,8 @@ func (h *RunningTradeHandler) detectWhale(trade *database.Trade) {
 	adaptiveThreshold := zScoreThreshold
 	atrPct := 0.0
 
-	// Get stats using helper method (handles caching internally)
-	stats := h.getStockStats(trade.StockSymbol)
-
-	if stats != nil && stats.MeanVolumeLots > 0 {
+	// A handful of candles gives a meaningless stddev, so thin or new symbols use the hard thresholds
+	if stats != nil && stats.MeanVolumeLots > 0 && stats.SampleCount >= int64(h.opts.StatsMinSamples) {
 		// We have statistics, use Statistical Detection
 		volVsAvgPct = (trade.VolumeLot / stats.MeanVolumeLots) * 100
 		if stats.StdDevVolume > 0 {
@@ -402,7 +580,7 @@ func (h *RunningTradeHandler) detectWhale(trade *database.Trade) {
 			}
 		}
 	} else {
-		// Fallback: No statistics available (New Listing / No History)
+		// Fallback: No statistics or too few samples (New Listing / Thin History)
 		// Use Hard Thresholds with minimum value safety floor
 		// Require: (High Volume AND Min Value) OR (Very High Value)
 		if trade.TotalAmount >= minSafeValue {
@@ -415,7 +593,7 @@ func (h *RunningTradeHandler) detectWhale(trade *database.Trade) {
 
 	if isWhale {
 		whaleAlert := &database.WhaleAlert{
-			DetectedAt:        time.Now(),
+			DetectedAt:        detectedAt,
 			StockSymbol:       trade.StockSymbol,
 			AlertType:         "SINGLE_TRADE",
 			Action:            trade.Action,
@@ -434,6 +612,8 @@ func (h *RunningTradeHandler) detectWhale(trade *database.Trade) {
 			// Adaptive Threshold Tracking
 			AdaptiveThreshold: ptr(adaptiveThreshold),
 			VolatilityPct:     ptr(atrPct),
+			ImpactBps:         h.getSymbolMeta(trade.StockSymbol).ImpactBps(trade.TotalAmount, trade.Price),
+			Severity:          h.severityFor(zScore),
 		}
 
 		// Save whale alert to database
@@ -448,34 +628,103 @@ func (h *RunningTradeHandler) detectWhale(trade *database.Trade) {
 			}
 
 			// Log whale detection to console
-			log.Printf("🐋 WHALE ALERT! %s %s [%s] | Vol: %.0f (%.0f%% Avg) | Z-Score: %.2f | Value: %s | Price: %s",
-				trade.StockSymbol, trade.Action, detectionType, trade.VolumeLot, volVsAvgPct, zScore, helpers.FormatRupiah(trade.TotalAmount), priceInfo)
+			log.Printf("🐋 WHALE ALERT! [%s] %s %s [%s] | Vol: %.0f (%.0f%% Avg) | Z-Score: %.2f | Value: %s | Price: %s",
+				whaleAlert.Severity, trade.StockSymbol, trade.Action, detectionType, trade.VolumeLot, volVsAvgPct, zScore, helpers.FormatRupiah(trade.TotalAmount), priceInfo)
 
-			// Trigger Webhook if manager is available
-			if h.webhookManager != nil {
-				h.webhookManager.SendAlert(whaleAlert)
-			}
-
-			// Broadcast Realtime Event
-			if h.broker != nil && h.webhookManager != nil {
-				// Use WebhookPayload for consistent frontend data (includes Message)
-				payload := h.webhookManager.CreatePayload(whaleAlert)
-				h.broker.Broadcast("whale_alert", payload)
-			} else if h.broker != nil {
-				// Fallback if no webhook manager
-				h.broker.Broadcast("whale_alert", whaleAlert)
-			}
+			h.publishAlert(whaleAlert)
 
 			// Benchmark Latency
 			latency := time.Since(startTime)
 			log.Printf("⏱️ Detection Latency: %v", latency)
+			return true
 		}
 	}
+	return false
+}
+
+// severityFor tiers a whale alert by z-score; every alert is at least WARN
+func (h *RunningTradeHandler) severityFor(zScore float64) string {
+	switch {
+	case h.opts.SeverityCriticalZ > 0 && zScore >= h.opts.SeverityCriticalZ:
+		return database.SeverityCritical
+	case h.opts.SeverityAlertZ > 0 && zScore >= h.opts.SeverityAlertZ:
+		return database.SeverityAlert
+	}
+	return database.SeverityWarn
+}
+
+// publishAlert sends a saved whale alert to webhooks and the realtime stream
+// Alerts matching an active suppression rule are logged but not delivered
+func (h *RunningTradeHandler) publishAlert(whaleAlert *database.WhaleAlert) {
+	if h.tradeRepo != nil {
+		rule, err := h.tradeRepo.GetActiveSuppressionRule(whaleAlert.DetectedAt, whaleAlert.StockSymbol, whaleAlert.AlertType)
+		if err != nil {
+			log.Printf("⚠️  Suppression rule check failed for %s: %v", whaleAlert.StockSymbol, err)
+		} else if rule != nil {
+			log.Printf("🔇 Whale alert %d (%s %s) suppressed until %s: %s",
+				whaleAlert.ID, whaleAlert.StockSymbol, whaleAlert.AlertType, rule.Until.In(wibLocation).Format("2006-01-02 15:04"), rule.Reason)
+			return
+		}
+	}
+
+	// Trigger Webhook if manager is available
+	if h.webhookManager != nil {
+		h.webhookManager.SendAlert(whaleAlert)
+	}
+
+	// Broadcast Realtime Event
+	if h.broker != nil && h.webhookManager != nil {
+		// Use WebhookPayload for consistent frontend data (includes Message)
+		payload := h.webhookManager.CreatePayload(whaleAlert)
+		h.broker.Broadcast("whale_alert", payload)
+	} else if h.broker != nil {
+		// Fallback if no webhook manager
+		h.broker.Broadcast("whale_alert", whaleAlert)
+	}
 }
 
 // ProcessOrderBookBody memproses update orderbook protobuf murni
+// Only the best bid/offer is kept (in Redis) for side-aware outcome pricing
 func (h *RunningTradeHandler) ProcessOrderBookBody(ob *pb.OrderBookBody) {
+	// During the pre-opening auction the book also reveals the indicative price and imbalance
+	if h.preOpen != nil && ob.GetStockSymbol() != "" {
+		now := time.Now()
+		if h.opts.Sessions.SessionAt(now.In(wibLocation)) == "PRE_OPENING" {
+			h.preOpen.observe(ob, now)
+		}
+	}
+
 	// Menampilkan orderbook dinonaktifkan agar console bersih
+	if h.redis == nil || ob.GetStockSymbol() == "" {
+		return
+	}
+
+	top := types.TopOfBook{StockSymbol: ob.GetStockSymbol(), Time: time.Now()}
+	if ob.GetTime() != nil {
+		top.Time = ob.GetTime().AsTime()
+	}
+	for _, b := range ob.GetBid() {
+		switch {
+		case b.GetPrice() > top.Bid:
+			top.Bid, top.BidLots = b.GetPrice(), b.GetLot()
+		case b.GetPrice() == top.Bid:
+			top.BidLots += b.GetLot()
+		}
+	}
+	for _, o := range ob.GetOffer() {
+		switch {
+		case o.GetPrice() <= 0:
+		case top.Ask == 0 || o.GetPrice() < top.Ask:
+			top.Ask, top.AskLots = o.GetPrice(), o.GetLot()
+		case o.GetPrice() == top.Ask:
+			top.AskLots += o.GetLot()
+		}
+	}
+	if top.Bid == 0 && top.Ask == 0 {
+		return
+	}
+
+	_ = h.redis.Set(context.Background(), types.TopOfBookCachePrefix+top.StockSymbol, top, topOfBookCacheTTL)
 }
 
 // GetMessageType returns the message type
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"stockbit-haka-haki/database"
+	"stockbit-haka-haki/database/types"
+	"stockbit-haka-haki/helpers"
+)
+
+// replayChunkSize is how many imported trades are written per BatchSaveTrades call
+const replayChunkSize = 5000
+
+// ReplayOptions controls a historical trade import
+type ReplayOptions struct {
+	// DetectionOnly skips saving trades (they are already stored) and only regenerates whale alerts
+	DetectionOnly bool
+}
+
+// ReplayResult summarizes a historical trade import
+type ReplayResult struct {
+	Rows       int       // Data rows read from the CSV
+	Skipped    int       // Rows that failed to parse
+	Duplicates int       // Rows already stored by an earlier import
+	Imported   int       // Trades written (0 in detection-only mode)
+	Alerts     int       // Whale alerts generated
+	From       time.Time // Earliest trade timestamp
+	To         time.Time // Latest trade timestamp
+}
+
+// ReplayCSV ingests historical trades from CSV (timestamp, symbol, action, price, volume, board)
+// through the same storage and whale detection stages as live trades.
+// Volume is in shares like the live feed; a header row is optional.
+// Timestamps are RFC3339 or "2006-01-02 15:04:05" in WIB.
+//
+// Unlike live processing, trades keep their CSV timestamps, baselines are taken as of each trade,
+// and order flow / iceberg detection are skipped since both bucket by wall-clock time.
+// Webhooks and SSE only fire if the handler was built with a webhook manager or broker.
+func (h *RunningTradeHandler) ReplayCSV(r io.Reader, opts ReplayOptions) (*ReplayResult, error) {
+	if h.tradeRepo == nil {
+		return nil, errors.New("replay requires a trade repository")
+	}
+
+	trades, result, err := parseReplayCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(trades) == 0 {
+		return result, nil
+	}
+
+	// 1. Store trades and materialize candles so baselines can see them
+	if !opts.DetectionOnly {
+		// Rows imported before are dropped up front: their trades, candles and whale alerts already exist
+		existing, err := h.tradeRepo.GetReplayTradeNumbers(result.From, result.To)
+		if err != nil {
+			return result, err
+		}
+		fresh := trades[:0]
+		for _, trade := range trades {
+			if existing[*trade.TradeNumber] {
+				result.Duplicates++
+				continue
+			}
+			fresh = append(fresh, trade)
+		}
+		trades = fresh
+		if len(trades) == 0 {
+			return result, nil
+		}
+
+		for start := 0; start < len(trades); start += replayChunkSize {
+			end := min(start+replayChunkSize, len(trades))
+			if err := h.tradeRepo.BatchSaveTrades(trades[start:end]); err != nil {
+				return result, err
+			}
+			result.Imported = end
+			log.Printf("📥 Imported %d/%d trades", end, len(trades))
+		}
+
+		if err := h.tradeRepo.RefreshCandles(result.From, result.To); err != nil {
+			return result, err
+		}
+	}
+
+	// 2. Whale detection, with stats as of each trade's minute
+	statsCache := make(map[string]*types.StockStats)
+	for _, trade := range trades {
+		if h.symbolFilter != nil && !h.symbolFilter.IsAllowed(trade.StockSymbol) {
+			continue
+		}
+		if !h.opts.IncludeOddLots && helpers.IsOddLot(trade.Volume) {
+			continue
+		}
+
+		minute := trade.Timestamp.Truncate(time.Minute)
+		key := trade.StockSymbol + "|" + minute.Format(time.RFC3339)
+		stats, ok := statsCache[key]
+		if !ok {
+			stats, err = h.tradeRepo.GetStockStatsAsOf(trade.StockSymbol, h.opts.StatsLookback, minute)
+			if err != nil {
+				log.Printf("⚠️  Replay stats failed for %s at %s: %v", trade.StockSymbol, minute.Format(time.RFC3339), err)
+				stats = nil
+			}
+			statsCache[key] = stats
+		}
+
+		if h.evaluateWhale(trade, stats, trade.Timestamp) {
+			result.Alerts++
+		}
+	}
+
+	return result, nil
+}
+
+// parseReplayCSV reads replay rows sorted by timestamp; malformed rows are logged and skipped
+func parseReplayCSV(r io.Reader) ([]*database.Trade, *ReplayResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	result := &ReplayResult{}
+	var trades []*database.Trade
+	occurrences := make(map[string]int) // Identical rows are distinct trades; their position tells them apart
+	line := 0
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, nil, fmt.Errorf("parseReplayCSV line %d: %w", line, err)
+		}
+
+		// Header row
+		if line == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "timestamp") {
+			continue
+		}
+
+		result.Rows++
+		trade, err := parseReplayRecord(record)
+		if err != nil {
+			result.Skipped++
+			log.Printf("⚠️  Skipping replay line %d: %v", line, err)
+			continue
+		}
+		number := replayTradeNumber(trade, occurrences)
+		trade.TradeNumber = &number
+		trades = append(trades, trade)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp.Before(trades[j].Timestamp)
+	})
+	if len(trades) > 0 {
+		result.From = trades[0].Timestamp
+		result.To = trades[len(trades)-1].Timestamp
+	}
+
+	return trades, result, nil
+}
+
+// parseReplayRecord converts one CSV record into a trade, mirroring ProcessTrade's field mapping
+func parseReplayRecord(record []string) (*database.Trade, error) {
+	if len(record) < 6 {
+		return nil, fmt.Errorf("expected 6 columns, got %d", len(record))
+	}
+
+	timestamp, err := parseReplayTime(strings.TrimSpace(record[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(record[1]))
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+
+	action := strings.ToUpper(strings.TrimSpace(record[2]))
+	switch action {
+	case "BUY", "SELL", "UNKNOWN":
+	default:
+		return nil, fmt.Errorf("invalid action %q", record[2])
+	}
+
+	price, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+	if err != nil || price <= 0 {
+		return nil, fmt.Errorf("invalid price %q", record[3])
+	}
+
+	volume, err := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+	if err != nil || volume <= 0 {
+		return nil, fmt.Errorf("invalid volume %q", record[4])
+	}
+
+	board := strings.ToUpper(strings.TrimSpace(record[5]))
+	if board == "" {
+		board = "RG"
+	}
+
+	return &database.Trade{
+		Timestamp:   timestamp,
+		StockSymbol: symbol,
+		Action:      action,
+		Price:       price,
+		Volume:      volume,
+		VolumeLot:   helpers.SharesToLots(volume),
+		TotalAmount: price * volume,
+		MarketBoard: board,
+	}, nil
+}
+
+// replayTradeNumber derives a stable trade number from a row's contents and how many identical rows
+// came before it, so re-importing a file hits the running_trades unique index instead of duplicating.
+// Replayed numbers are negative to stay clear of the feed's own (positive) trade numbers.
+func replayTradeNumber(trade *database.Trade, occurrences map[string]int) int64 {
+	row := fmt.Sprintf("%d|%s|%s|%g|%g|%s",
+		trade.Timestamp.UnixNano(), trade.StockSymbol, trade.Action, trade.Price, trade.Volume, trade.MarketBoard)
+	occurrences[row]++
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%d", row, occurrences[row])
+	return -int64(h.Sum64()>>1) - 1
+}
+
+// parseReplayTime accepts RFC3339 or a WIB wall-clock timestamp
+func parseReplayTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, wibLocation); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TickBand is one IDX price fraction band: prices below UpTo move in multiples of Tick
+// (UpTo 0 means no upper bound)
+type TickBand struct {
+	UpTo float64
+	Tick float64
+}
+
+// DefaultTickSchedule is the IDX equity price fraction schedule
+var DefaultTickSchedule = []TickBand{
+	{UpTo: 200, Tick: 1},
+	{UpTo: 500, Tick: 2},
+	{UpTo: 2000, Tick: 5},
+	{UpTo: 5000, Tick: 10},
+	{UpTo: 0, Tick: 25},
+}
+
+// DefaultLotSize is the number of shares in one IDX board lot
+const DefaultLotSize = 100
+
+// Set once at startup (see SetTickSchedule / SetLotSize), read-only afterwards
+var (
+	tickSchedule = DefaultTickSchedule
+	lotSize      = DefaultLotSize
+)
+
+// SetTickSchedule replaces the tick schedule; an empty schedule restores the IDX default
+func SetTickSchedule(bands []TickBand) {
+	if len(bands) == 0 {
+		bands = DefaultTickSchedule
+	}
+	tickSchedule = bands
+}
+
+// SetLotSize sets the shares per lot; non-positive values restore the IDX default
+func SetLotSize(shares int) {
+	if shares <= 0 {
+		shares = DefaultLotSize
+	}
+	lotSize = shares
+}
+
+// LotSize returns the configured shares per lot
+func LotSize() int {
+	return lotSize
+}
+
+// ParseTickSchedule parses "200:1,500:2,2000:5,5000:10,*:25" (upper bound:tick, "*" = no bound)
+// into bands sorted by upper bound with the unbounded band last
+func ParseTickSchedule(spec string) ([]TickBand, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	var bands []TickBand
+	unbounded := false
+	for _, part := range strings.Split(spec, ",") {
+		upTo, tick, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid tick band %q (want upTo:tick)", part)
+		}
+
+		band := TickBand{}
+		var err error
+		if band.Tick, err = strconv.ParseFloat(strings.TrimSpace(tick), 64); err != nil || band.Tick <= 0 {
+			return nil, fmt.Errorf("invalid tick in %q", part)
+		}
+		if upTo = strings.TrimSpace(upTo); upTo != "*" {
+			if band.UpTo, err = strconv.ParseFloat(upTo, 64); err != nil || band.UpTo <= 0 {
+				return nil, fmt.Errorf("invalid upper bound in %q", part)
+			}
+		} else {
+			unbounded = true
+		}
+		bands = append(bands, band)
+	}
+	if !unbounded {
+		return nil, fmt.Errorf("tick schedule needs a \"*:tick\" band for prices above the last bound")
+	}
+
+	sort.SliceStable(bands, func(i, j int) bool {
+		if bands[i].UpTo == 0 || bands[j].UpTo == 0 {
+			return bands[j].UpTo == 0 && bands[i].UpTo != 0
+		}
+		return bands[i].UpTo < bands[j].UpTo
+	})
+	return bands, nil
+}
+
+// TickSize returns the price fraction for a price
+func TickSize(price float64) float64 {
+	for _, band := range tickSchedule {
+		if band.UpTo == 0 || price < band.UpTo {
+			return band.Tick
+		}
+	}
+	return tickSchedule[len(tickSchedule)-1].Tick
+}
+
+// RoundToTick rounds a price to the nearest valid price for its band, so computed levels are orderable
+func RoundToTick(price float64) float64 {
+	if price <= 0 {
+		return price
+	}
+	tick := TickSize(price)
+	return math.Max(math.Round(price/tick)*tick, tick)
+}
+
+// SharesToLots converts a share volume to lots; odd-lot volumes give a fractional count
+func SharesToLots(shares float64) float64 {
+	return shares / float64(lotSize)
+}
+
+// IsOddLot reports whether a share volume is not a whole number of lots (odd-lot board trades)
+func IsOddLot(shares float64) bool {
+	return shares > 0 && math.Mod(shares, float64(lotSize)) != 0
+}
+
+// WholeLots returns how many full lots a Rupiah amount buys at price
+func WholeLots(amount, price float64) int64 {
+	if amount <= 0 || price <= 0 {
+		return 0
+	}
+	return int64(amount / (price * float64(lotSize)))
+}
@@ -0,0 +1,203 @@
+// Package indicators implements standard technical indicators over price series.
+// Series are chronological (oldest first) and results are aligned with the input:
+// positions before an indicator has enough data hold NaN.
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SMA returns the simple moving average of values over period
+func SMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 || len(values) < period {
+		return out
+	}
+
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA returns the exponential moving average of values over period, seeded with the SMA of the first period values
+func EMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 || len(values) < period {
+		return out
+	}
+
+	k := 2.0 / float64(period+1)
+	seed := 0.0
+	for _, v := range values[:period] {
+		seed += v
+	}
+	out[period-1] = seed / float64(period)
+	for i := period; i < len(values); i++ {
+		out[i] = values[i]*k + out[i-1]*(1-k)
+	}
+	return out
+}
+
+// RSI returns Wilder's relative strength index (0-100) of values over period
+func RSI(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	if period <= 0 || len(values) < period+1 {
+		return out
+	}
+
+	avgGain, avgLoss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		gain, loss := change(values[i-1], values[i])
+		avgGain += gain
+		avgLoss += loss
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	out[period] = rsiValue(avgGain, avgLoss)
+
+	for i := period + 1; i < len(values); i++ {
+		gain, loss := change(values[i-1], values[i])
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		out[i] = rsiValue(avgGain, avgLoss)
+	}
+	return out
+}
+
+// RealizedVolatility returns the sample standard deviation of log returns between consecutive
+// closes (per period, not annualized) and the number of returns used. Non-positive closes are
+// skipped. ok is false with fewer than two returns.
+func RealizedVolatility(closes []float64) (vol float64, returns int, ok bool) {
+	var logReturns []float64
+	prev := 0.0
+	for _, c := range closes {
+		if c <= 0 {
+			continue
+		}
+		if prev > 0 {
+			logReturns = append(logReturns, math.Log(c/prev))
+		}
+		prev = c
+	}
+	if len(logReturns) < 2 {
+		return 0, len(logReturns), false
+	}
+
+	mean := 0.0
+	for _, r := range logReturns {
+		mean += r
+	}
+	mean /= float64(len(logReturns))
+
+	variance := 0.0
+	for _, r := range logReturns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(logReturns) - 1)
+	return math.Sqrt(variance), len(logReturns), true
+}
+
+// Last returns the most recent value of a series and whether it is defined
+func Last(series []float64) (float64, bool) {
+	if len(series) == 0 || math.IsNaN(series[len(series)-1]) {
+		return 0, false
+	}
+	return series[len(series)-1], true
+}
+
+// Spec is a parsed indicator request such as "sma20" or "rsi14"
+type Spec struct {
+	Name   string // Lowercase key as requested, e.g. "ema50"
+	Kind   string // sma, ema or rsi
+	Period int
+}
+
+// MaxPeriod bounds indicator periods accepted by ParseSpecs
+const MaxPeriod = 500
+
+// ParseSpecs parses a comma-separated list like "sma20,ema50,rsi14"
+func ParseSpecs(list string) ([]Spec, error) {
+	var specs []Spec
+	seen := make(map[string]bool)
+	for _, raw := range strings.Split(list, ",") {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" || seen[name] {
+			continue
+		}
+		if len(name) < 4 {
+			return nil, fmt.Errorf("invalid indicator %q (want e.g. sma20, ema50, rsi14)", raw)
+		}
+
+		kind := name[:3]
+		if kind != "sma" && kind != "ema" && kind != "rsi" {
+			return nil, fmt.Errorf("unsupported indicator %q (supported: sma, ema, rsi)", raw)
+		}
+		period, err := strconv.Atoi(name[3:])
+		if err != nil || period < 1 || period > MaxPeriod {
+			return nil, fmt.Errorf("invalid period in %q (1-%d)", raw, MaxPeriod)
+		}
+
+		seen[name] = true
+		specs = append(specs, Spec{Name: name, Kind: kind, Period: period})
+	}
+	return specs, nil
+}
+
+// Compute evaluates the indicator over values
+func (s Spec) Compute(values []float64) []float64 {
+	switch s.Kind {
+	case "sma":
+		return SMA(values, s.Period)
+	case "ema":
+		return EMA(values, s.Period)
+	case "rsi":
+		return RSI(values, s.Period)
+	}
+	return nanSeries(len(values))
+}
+
+// Warmup is how many earlier values the indicator needs before its first stable output
+func (s Spec) Warmup() int {
+	if s.Kind == "sma" {
+		return s.Period - 1
+	}
+	// EMA and Wilder smoothing carry memory of the seed; a few periods make it negligible
+	return s.Period * 3
+}
+
+func nanSeries(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+func change(prev, cur float64) (gain, loss float64) {
+	d := cur - prev
+	if d > 0 {
+		return d, 0
+	}
+	return 0, -d
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
@@ -1,18 +1,33 @@
 package main
 
 import (
+	"flag"
 	"log"
 
 	"stockbit-haka-haki/app"
 	"stockbit-haka-haki/config"
+	"stockbit-haka-haki/handlers"
 )
 
 func main() {
+	replayPath := flag.String("replay", "", "Import historical trades from a CSV (timestamp,symbol,action,price,volume,board) and exit")
+	detectionOnly := flag.Bool("detection-only", false, "With -replay: trades are already stored, only regenerate whale alerts")
+	notify := flag.Bool("notify", false, "With -replay: send generated whale alerts to webhooks")
+	flag.Parse()
+
 	// Load config from .env file
 	cfg := config.LoadFromEnv()
 
 	// Create and start app
 	application := app.New(cfg)
+
+	if *replayPath != "" {
+		if err := application.Replay(*replayPath, handlers.ReplayOptions{DetectionOnly: *detectionOnly}, *notify); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err := application.Start(); err != nil {
 		log.Fatal(err)
 	}
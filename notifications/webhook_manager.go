@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strings"
@@ -17,9 +18,10 @@ import (
 
 // WebhookManager handles webhook notifications
 type WebhookManager struct {
-	repo   *database.TradeRepository
-	redis  *cache.RedisClient
-	client *http.Client
+	repo          *database.TradeRepository
+	redis         *cache.RedisClient
+	client        *http.Client
+	minConfidence float64 // Global confidence floor (0-100), applied before per-webhook filters
 }
 
 // WebhookPayload represents the JSON payload sent to webhooks
@@ -35,15 +37,18 @@ type WebhookPayload struct {
 	AvgPrice        float64                `json:"avg_price"`
 	ConfidenceScore float64                `json:"confidence_score"`
 	MarketBoard     string                 `json:"market_board"`
+	Severity        string                 `json:"severity,omitempty"`
 	Message         string                 `json:"message"`
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // NewWebhookManager creates a new webhook manager
-func NewWebhookManager(repo *database.TradeRepository, redis *cache.RedisClient) *WebhookManager {
+// minConfidence suppresses alerts below the given ConfidenceScore (0-100) for every webhook
+func NewWebhookManager(repo *database.TradeRepository, redis *cache.RedisClient, minConfidence float64) *WebhookManager {
 	return &WebhookManager{
-		repo:  repo,
-		redis: redis,
+		repo:          repo,
+		redis:         redis,
+		minConfidence: minConfidence,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -52,6 +57,11 @@ func NewWebhookManager(repo *database.TradeRepository, redis *cache.RedisClient)
 
 // SendAlert processes and sends the alert to matching webhooks
 func (wm *WebhookManager) SendAlert(alert *database.WhaleAlert) {
+	// Global floor: drop low-conviction alerts before touching webhooks
+	if alert.ConfidenceScore < wm.minConfidence {
+		return
+	}
+
 	// 1. Get all active webhooks
 	webhooks, err := wm.getActiveWebhooks()
 	if err != nil {
@@ -126,7 +136,12 @@ func (wm *WebhookManager) CreatePayload(alert *database.WhaleAlert) WebhookPaylo
 		priceInfo = fmt.Sprintf("%.0f (Avg: %.0f, %+0.1f%%)", alert.TriggerPrice, avgPriceVal, diffPct)
 	}
 
-	message := fmt.Sprintf("🐋 WHALE ALERT! %s %s | Vol: %.0f (%.0f%% Avg) | Value: %s | Price: %s | Z-Score: %.2f",
+	severity := ""
+	if alert.Severity != "" {
+		severity = "[" + alert.Severity + "] "
+	}
+	message := fmt.Sprintf("🐋 WHALE ALERT! %s%s %s | Vol: %.0f (%.0f%% Avg) | Value: %s | Price: %s | Z-Score: %.2f",
+		severity,
 		alert.StockSymbol,
 		alert.Action,
 		alert.TriggerVolumeLots,
@@ -148,6 +163,7 @@ func (wm *WebhookManager) CreatePayload(alert *database.WhaleAlert) WebhookPaylo
 		AvgPrice:        avgPriceVal,
 		ConfidenceScore: alert.ConfidenceScore,
 		MarketBoard:     alert.MarketBoard,
+		Severity:        alert.Severity,
 		Message:         message,
 		Metadata: map[string]interface{}{
 			"z_score":        alert.ZScore,
@@ -157,23 +173,36 @@ func (wm *WebhookManager) CreatePayload(alert *database.WhaleAlert) WebhookPaylo
 	}
 }
 
-func (wm *WebhookManager) shouldSend(hook database.WhaleWebhook, alert *database.WhaleAlert) bool {
-	// Check Alert Type filter
-	if hook.AlertTypes != "" && hook.AlertTypes != "null" {
-		// Lenient check: matches if the type is present in the string (JSON or CSV)
-		if !strings.Contains(hook.AlertTypes, alert.AlertType) {
-			return false
-		}
+// filterListMatches reports whether value is an element of a webhook filter list, stored as a JSON
+// array (CSV in rows saved before lists were validated). An empty list matches everything.
+func filterListMatches(raw, value string) bool {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return true
 	}
 
-	// Check Stock Symbol filter
-	if hook.StockSymbols != "" && hook.StockSymbols != "null" {
-		if !strings.Contains(hook.StockSymbols, alert.StockSymbol) {
-			return false
+	var items []string
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		items = strings.Split(raw, ",")
+	}
+	if len(items) == 0 {
+		return true
+	}
+	for _, item := range items {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
 		}
 	}
+	return false
+}
 
-	// Check thresholds
+func (wm *WebhookManager) shouldSend(hook database.WhaleWebhook, alert *database.WhaleAlert) bool {
+	// Check Alert Type and Stock Symbol filters
+	if !filterListMatches(hook.AlertTypes, alert.AlertType) || !filterListMatches(hook.StockSymbols, alert.StockSymbol) {
+		return false
+	}
+
+	// Check thresholds (MinConfidence uses the same 0-100 scale as ConfidenceScore)
 	if hook.MinConfidence != nil && alert.ConfidenceScore < *hook.MinConfidence {
 		return false
 	}
@@ -182,9 +211,42 @@ func (wm *WebhookManager) shouldSend(hook database.WhaleWebhook, alert *database
 		return false
 	}
 
+	if hook.MinSeverity != "" && database.SeverityRank(alert.Severity) < database.SeverityRank(hook.MinSeverity) {
+		return false
+	}
+
 	return true
 }
 
+// newRequest builds the outgoing webhook request including auth headers
+func (wm *WebhookManager) newRequest(hook database.WhaleWebhook, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequest(hook.Method, hook.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Stockbit-Whale-Alert/1.0")
+
+	// Custom headers (validated JSON object, see the webhook config API)
+	if hook.CustomHeaders != "" && hook.CustomHeaders != "null" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(hook.CustomHeaders), &headers); err != nil {
+			return nil, fmt.Errorf("invalid custom_headers on webhook %d: %w", hook.ID, err)
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+	}
+
+	// Auth headers (set last so custom headers can't override them)
+	if hook.AuthType == "BEARER" {
+		req.Header.Set("Authorization", "Bearer "+hook.AuthValue)
+	} else if hook.AuthHeader != "" {
+		req.Header.Set(hook.AuthHeader, hook.AuthValue)
+	}
+	return req, nil
+}
+
 func (wm *WebhookManager) deliverWebhook(hook database.WhaleWebhook, alertID int64, payload []byte) {
 	// Basic implementation without fancy retry logic for MVP phase 1
 	maxRetries := hook.RetryCount
@@ -196,19 +258,14 @@ func (wm *WebhookManager) deliverWebhook(hook database.WhaleWebhook, alertID int
 	var err error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		req, _ := http.NewRequest(hook.Method, hook.URL, bytes.NewBuffer(payload))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("User-Agent", "Stockbit-Whale-Alert/1.0")
+		var req *http.Request
+		req, err = wm.newRequest(hook, payload)
+		if err != nil {
+			break
+		}
 
 		log.Printf("🔹 Sending webhook to %s (Attempt %d/%d)", hook.URL, attempt, maxRetries)
 
-		// Auth headers
-		if hook.AuthType == "BEARER" {
-			req.Header.Set("Authorization", "Bearer "+hook.AuthValue)
-		} else if hook.AuthHeader != "" {
-			req.Header.Set(hook.AuthHeader, hook.AuthValue)
-		}
-
 		resp, err = wm.client.Do(req)
 		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			// Success
@@ -239,6 +296,89 @@ func (wm *WebhookManager) deliverWebhook(hook database.WhaleWebhook, alertID int
 	wm.logDelivery(hook.ID, alertID, status, statusCode, errMsg, maxRetries)
 }
 
+// WebhookTestResult reports the outcome of a test delivery
+type WebhookTestResult struct {
+	Success      bool   `json:"success"`
+	StatusCode   int    `json:"status_code,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMs   int64  `json:"duration_ms"`
+}
+
+// TestWebhook sends a synthetic alert to a webhook once (no retries) and reports the response
+// Filters are bypassed; payload formatting and auth are identical to real deliveries
+func (wm *WebhookManager) TestWebhook(hook database.WhaleWebhook) WebhookTestResult {
+	zScore, volPct := 4.5, 500.0
+	alert := &database.WhaleAlert{
+		DetectedAt:        time.Now(),
+		StockSymbol:       "TEST",
+		AlertType:         "SINGLE_TRADE",
+		Action:            "BUY",
+		TriggerPrice:      1000,
+		TriggerVolumeLots: 50000,
+		TriggerValue:      5000000000,
+		ZScore:            &zScore,
+		VolumeVsAvgPct:    &volPct,
+		ConfidenceScore:   90,
+		MarketBoard:       "RG",
+	}
+	payload := wm.CreatePayload(alert)
+	payload.Message = "[TEST] " + payload.Message
+
+	result := WebhookTestResult{}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	req, err := wm.newRequest(hook, payloadBytes)
+	if err != nil {
+		result.Error = err.Error()
+		wm.logTestDelivery(hook.ID, result)
+		return result
+	}
+
+	start := time.Now()
+	resp, err := wm.client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		result.StatusCode = resp.StatusCode
+		result.ResponseBody = string(body)
+		result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+
+	wm.logTestDelivery(hook.ID, result)
+	return result
+}
+
+// logTestDelivery records a test delivery; test logs have no alert ID and a TEST_ status
+func (wm *WebhookManager) logTestDelivery(webhookID int, result WebhookTestResult) {
+	status := "TEST_FAILED"
+	if result.Success {
+		status = "TEST_SUCCESS"
+	}
+	logEntry := &database.WhaleWebhookLog{
+		WebhookID:    webhookID,
+		TriggeredAt:  time.Now(),
+		Status:       status,
+		ResponseBody: result.ResponseBody,
+		ErrorMessage: result.Error,
+		RetryAttempt: 1,
+	}
+	if result.StatusCode != 0 {
+		logEntry.HTTPStatusCode = &result.StatusCode
+	}
+
+	if dbErr := wm.repo.SaveWebhookLog(logEntry); dbErr != nil {
+		log.Printf("⚠️  Failed to save webhook log: %v", dbErr)
+	}
+}
+
 func (wm *WebhookManager) logDelivery(webhookID int, alertID int64, status string, code int, err string, attempt int) {
 	logEntry := &database.WhaleWebhookLog{
 		WebhookID:    webhookID,
@@ -2,104 +2,274 @@ package realtime
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
 )
 
+// ErrTooManyClients is returned when the broker is at its client limit
+var ErrTooManyClients = errors.New("too many SSE clients")
+
+// Defaults used when NewBroker gets non-positive limits
+const (
+	defaultMaxClients   = 100
+	defaultClientBuffer = 100
+)
+
+// unreplayedEvents are not kept for replay: the live tape is stale within seconds and
+// would push whale alerts out of the replay buffer
+var unreplayedEvents = map[string]bool{"trade": true}
+
 // Broker handles Server-Sent Events (SSE) clients and broadcasting
 type Broker struct {
-	clients    map[chan []byte]bool
-	register   chan chan []byte
-	unregister chan chan []byte
-	broadcast  chan []byte
-	mu         sync.RWMutex
+	clients   map[chan []byte]string // Client -> event filter ("" = every event, SSE frame with ID)
+	broadcast chan message
+	mu        sync.RWMutex
+
+	maxClients   int
+	clientBuffer int // Per-client channel size; a full channel drops its oldest message
+
+	// Event IDs and the replay ring buffer are only written by Run, under mu
+	lastID     uint64
+	replay     []replayEvent // Ring buffer of the most recent replayable events
+	replayNext int           // Next slot to overwrite once the buffer is full
+}
+
+// message is a broadcast event: the {event, payload} envelope for /api/events clients
+// and the bare payload for clients subscribed to that event
+type message struct {
+	event    string
+	envelope []byte
+	payload  []byte
+}
+
+// replayEvent is a formatted /api/events frame kept for clients reconnecting with Last-Event-ID
+type replayEvent struct {
+	id    uint64
+	frame []byte
 }
 
-// NewBroker creates a new SSE broker
-func NewBroker() *Broker {
+// NewBroker creates a new SSE broker accepting up to maxClients concurrent clients,
+// each buffering up to clientBuffer messages. The last replaySize events (except trades)
+// are kept for replay to reconnecting /api/events clients; 0 disables replay.
+func NewBroker(maxClients, clientBuffer, replaySize int) *Broker {
+	if maxClients <= 0 {
+		maxClients = defaultMaxClients
+	}
+	if clientBuffer <= 0 {
+		clientBuffer = defaultClientBuffer
+	}
+	if replaySize < 0 {
+		replaySize = 0
+	}
 	return &Broker{
-		clients:    make(map[chan []byte]bool),
-		register:   make(chan chan []byte),
-		unregister: make(chan chan []byte),
-		broadcast:  make(chan []byte, 1000), // Buffer broadcast (Limit increased to 1000)
+		clients:      make(map[chan []byte]string),
+		broadcast:    make(chan message, 1000), // Buffer broadcast (Limit increased to 1000)
+		maxClients:   maxClients,
+		clientBuffer: clientBuffer,
+		replay:       make([]replayEvent, 0, replaySize),
 	}
 }
 
-// Run starts the broker loop
+// Run starts the broker loop. IDs are assigned here rather than in Broadcast so they
+// increase in delivery order; the write lock keeps a reconnecting client from seeing an
+// event both in its replay and live.
 func (b *Broker) Run() {
-	for {
-		select {
-		case client := <-b.register:
-			b.mu.Lock()
-			b.clients[client] = true
-			b.mu.Unlock()
-			log.Printf("SSE Client connected. Total: %d", len(b.clients))
-
-		case client := <-b.unregister:
-			b.mu.Lock()
-			if _, ok := b.clients[client]; ok {
-				delete(b.clients, client)
-				close(client)
-				log.Printf("SSE Client disconnected. Total: %d", len(b.clients))
-			}
-			b.mu.Unlock()
-
-		case msg := <-b.broadcast:
-			b.mu.RLock()
-			for client := range b.clients {
-				select {
-				case client <- msg:
-				default:
-					// Skip if client buffer is full to prevent blocking
+	for msg := range b.broadcast {
+		b.mu.Lock()
+		b.lastID++
+		frame := []byte(fmt.Sprintf("id: %d\ndata: %s\n\n", b.lastID, msg.envelope))
+		if !unreplayedEvents[msg.event] {
+			b.remember(replayEvent{id: b.lastID, frame: frame})
+		}
+
+		for client, event := range b.clients {
+			data := frame
+			if event != "" {
+				if event != msg.event {
+					continue
 				}
+				data = msg.payload
 			}
-			b.mu.RUnlock()
+			deliver(client, data)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// remember appends an event to the replay ring buffer, overwriting the oldest when full
+func (b *Broker) remember(ev replayEvent) {
+	switch {
+	case cap(b.replay) == 0:
+		return
+	case len(b.replay) < cap(b.replay):
+		b.replay = append(b.replay, ev)
+	default:
+		b.replay[b.replayNext] = ev
+		b.replayNext = (b.replayNext + 1) % len(b.replay)
+	}
+}
+
+// eventsSince returns the buffered frames with an ID after lastID, oldest first. Caller holds mu.
+func (b *Broker) eventsSince(lastID uint64) [][]byte {
+	var frames [][]byte
+	for i := range b.replay {
+		ev := b.replay[(b.replayNext+i)%len(b.replay)]
+		if ev.id > lastID {
+			frames = append(frames, ev.frame)
 		}
 	}
+	return frames
+}
+
+// deliver sends without blocking; a slow client loses its oldest buffered message
+// rather than stalling the broker or missing the newest state
+func deliver(client chan []byte, data []byte) {
+	select {
+	case client <- data:
+		return
+	default:
+	}
+
+	select {
+	case <-client:
+	default:
+	}
+
+	select {
+	case client <- data:
+	default:
+		// Reader drained and refilled concurrently; drop this one
+	}
+}
+
+// register adds a client for event ("" for all), enforcing the client limit
+func (b *Broker) register(event string) (chan []byte, error) {
+	client, _, err := b.registerSince(event, 0)
+	return client, err
+}
+
+// registerSince registers a client and, when lastID is set, returns the buffered frames it
+// missed. Both happen under one lock so no event falls between the replay and the live feed.
+func (b *Broker) registerSince(event string, lastID uint64) (chan []byte, [][]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.clients) >= b.maxClients {
+		return nil, nil, ErrTooManyClients
+	}
+
+	client := make(chan []byte, b.clientBuffer)
+	b.clients[client] = event
+	log.Printf("SSE Client connected. Total: %d", len(b.clients))
+
+	var missed [][]byte
+	if lastID > 0 {
+		missed = b.eventsSince(lastID)
+	}
+	return client, missed, nil
+}
+
+// unregister removes a client and closes its channel; safe to call more than once
+func (b *Broker) unregister(client chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.clients[client]; ok {
+		delete(b.clients, client)
+		close(client)
+		log.Printf("SSE Client disconnected. Total: %d", len(b.clients))
+	}
 }
 
 // ServeHTTP handles the SSE endpoint
 func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// EventSource sends Last-Event-ID on automatic reconnects; the query parameter covers
+	// clients that open a new connection themselves
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastID, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	clientChan, missed, err := b.registerSince("", lastID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer b.unregister(clientChan)
+
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	clientChan := make(chan []byte, 10)
-	b.register <- clientChan
+	if len(missed) > 0 {
+		for _, frame := range missed {
+			if _, err := w.Write(frame); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+		log.Printf("SSE Client resumed after event %d: replayed %d events", lastID, len(missed))
+	}
 
 	notify := r.Context().Done()
 
 	for {
 		select {
 		case <-notify:
-			b.unregister <- clientChan
 			return
-		case msg := <-clientChan:
-			fmt.Fprintf(w, "data: %s\n\n", msg)
-			w.(http.Flusher).Flush()
+		case frame := <-clientChan:
+			if _, err := w.Write(frame); err != nil {
+				return // Connection gone
+			}
+			flusher.Flush()
 		}
 	}
 }
 
+// Subscribe returns a channel receiving the JSON payload of each broadcast of event only,
+// so a busy event stream (e.g. trades) can't crowd it out. Release it with Unsubscribe.
+// Returns ErrTooManyClients at the client limit.
+func (b *Broker) Subscribe(event string) (chan []byte, error) {
+	return b.register(event)
+}
+
+// Unsubscribe removes a client registered with Subscribe and closes its channel
+func (b *Broker) Unsubscribe(clientChan chan []byte) {
+	b.unregister(clientChan)
+}
+
 // Broadcast sends a message to all connected clients
 func (b *Broker) Broadcast(event string, payload interface{}) {
-	data := map[string]interface{}{
-		"event":   event,
-		"payload": payload,
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling broadcast message: %v", err)
+		return
 	}
 
-	jsonBytes, err := json.Marshal(data)
+	envelope, err := json.Marshal(map[string]interface{}{
+		"event":   event,
+		"payload": json.RawMessage(payloadBytes),
+	})
 	if err != nil {
 		log.Printf("Error marshalling broadcast message: %v", err)
 		return
 	}
 
 	select {
-	case b.broadcast <- jsonBytes:
+	case b.broadcast <- message{event: event, envelope: envelope, payload: payloadBytes}:
 	default:
 		// Drop if broadcast buffer full
 	}